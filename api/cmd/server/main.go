@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -9,35 +12,87 @@ import (
 	"syscall"
 	"time"
 
+	"gopkg.in/yaml.v3"
+
 	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/database"
 	"github.com/secura/api/internal/handlers"
-	"github.com/secura/api/internal/middlewares"
+	"github.com/secura/api/internal/jobs"
+	"github.com/secura/api/internal/metrics"
+	"github.com/secura/api/internal/telemetry"
 )
 
+// configReloadInterval is how often the config file is checked for changes
+const configReloadInterval = 10 * time.Second
+
+// databaseConnectTimeout bounds connecting to the database at startup
+const databaseConnectTimeout = 10 * time.Second
+
 func main() {
+	configPath := flag.String("config", "", "path to a YAML or TOML config file (defaults to $SECURA_CONFIG)")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration with secrets redacted and exit")
+	flag.Parse()
+
 	// Load configuration
-	cfg, err := config.Load()
+	cfg, err := config.Load(*configPath)
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	if *printConfig {
+		out, err := yaml.Marshal(cfg.Redacted())
+		if err != nil {
+			log.Fatalf("Failed to print configuration: %v", err)
+		}
+		fmt.Print(string(out))
+		return
+	}
+
 	// Set up logger
 	logger := config.SetupLogger(cfg.LogLevel)
 	defer logger.Sync()
 
-	// Initialize router
-	router := handlers.SetupRouter(cfg, logger)
+	// Set up tracing
+	shutdownTracing, err := telemetry.Setup(context.Background(), cfg)
+	if err != nil {
+		logger.Fatal("Failed to set up tracing: " + err.Error())
+	}
+
+	// Watch for configuration reloads
+	cfgStore := config.NewStore(cfg)
+	reloadCtx, stopReloader := context.WithCancel(context.Background())
+	defer stopReloader()
+	go config.NewReloader(*configPath, cfgStore, logger, configReloadInterval).Watch(reloadCtx)
+
+	// Refresh secrets loaded from files, the environment or Vault
+	go config.NewSecretRefresher(cfgStore, logger, cfg.SecretsRefreshInterval).Watch(reloadCtx)
 
-	// Add middlewares
-	router.Use(middlewares.Logger(logger))
-	router.Use(middlewares.Recover())
+	// Connect to the database if enabled
+	var db *sql.DB
+	if cfg.DBEnabled {
+		connectCtx, cancelConnect := context.WithTimeout(context.Background(), databaseConnectTimeout)
+		db, err = database.Open(connectCtx, cfg)
+		cancelConnect()
+		if err != nil {
+			logger.Fatal("Failed to connect to database: " + err.Error())
+		}
+		defer db.Close()
+	} else {
+		logger.Warn("Database disabled: users, API keys, usage and the audit index are kept in memory")
+	}
+
+	// Initialize router and background jobs
+	scheduler := jobs.NewScheduler(logger)
+	router := handlers.SetupRouter(cfgStore, logger, scheduler, db)
+	scheduler.Start(reloadCtx)
 
-	// Create HTTP server
+	// Create HTTP server. Responses may be written until the request timeout
+	// has passed, plus time to write the error response.
 	server := &http.Server{
 		Addr:         ":" + cfg.Port,
 		Handler:      router,
 		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+		WriteTimeout: cfg.Timeouts.Request + 15*time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 
@@ -49,6 +104,26 @@ func main() {
 		}
 	}()
 
+	// Start the admin server for metrics if a separate port is configured
+	var adminServer *http.Server
+	if cfg.MetricsEnabled && cfg.MetricsPort != "" {
+		adminMux := http.NewServeMux()
+		adminMux.Handle("/metrics", metrics.Handler())
+		adminServer = &http.Server{
+			Addr:         ":" + cfg.MetricsPort,
+			Handler:      adminMux,
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: 10 * time.Second,
+		}
+
+		go func() {
+			logger.Info("Starting admin server on port " + cfg.MetricsPort)
+			if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Fatal("Failed to start admin server: " + err.Error())
+			}
+		}()
+	}
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -63,6 +138,16 @@ func main() {
 	if err := server.Shutdown(ctx); err != nil {
 		logger.Fatal("Server forced to shutdown: " + err.Error())
 	}
+	if adminServer != nil {
+		if err := adminServer.Shutdown(ctx); err != nil {
+			logger.Error("Admin server forced to shutdown: " + err.Error())
+		}
+	}
+
+	// Flush any buffered spans
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("Failed to shut down tracing: " + err.Error())
+	}
 
 	logger.Info("Server exiting")
-} 
\ No newline at end of file
+}
@@ -2,21 +2,36 @@ package blockchain
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math/big"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"go.uber.org/zap"
 )
 
+// backend is the Ethereum node API used by the client. It is implemented by
+// ethclient.Client and, in tests, by a simulated chain.
+type backend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
 // Client represents a client for interacting with the blockchain
 type Client struct {
-	ethClient    *ethclient.Client
+	ethClient    backend
 	contractAddr common.Address
 	logger       *zap.Logger
+
+	// Consent registry binding and attester key, set by EnableConsentRegistry
+	consentRegistry *ConsentRegistry
+	attesterKey     *ecdsa.PrivateKey
 }
 
 // AuditLogData represents the data for an audit log
@@ -35,7 +50,11 @@ func NewClient(nodeURL string, contractAddress string, logger *zap.Logger) (*Cli
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Ethereum node: %w", err)
 	}
+	return newClient(ethClient, contractAddress, logger)
+}
 
+// newClient creates a blockchain client using backend to reach the node
+func newClient(ethClient backend, contractAddress string, logger *zap.Logger) (*Client, error) {
 	// Validate contract address
 	if !common.IsHexAddress(contractAddress) {
 		return nil, fmt.Errorf("invalid contract address: %s", contractAddress)
@@ -100,33 +119,20 @@ func (c *Client) VerifyContentHash(ctx context.Context, contentHash string) (boo
 	return true, nil
 }
 
-// GetAuditLogsByUser retrieves all audit logs for a specific user
-func (c *Client) GetAuditLogsByUser(ctx context.Context, userID string) ([]map[string]interface{}, error) {
-	// For MVP, just log the request and return mock data
-	// TODO: Implement actual blockchain queries
-	c.logger.Info("Getting audit logs for user",
-		zap.String("user_id", userID),
-	)
+// CheckHealth verifies the node is reachable and the audit contract is deployed
+func (c *Client) CheckHealth(ctx context.Context) error {
+	if _, err := c.ethClient.ChainID(ctx); err != nil {
+		return fmt.Errorf("failed to get chain ID: %w", err)
+	}
 
-	// Return mock data
-	return []map[string]interface{}{
-		{
-			"index":     0,
-			"user_id":   userID,
-			"action":    "completion",
-			"hash":      "0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",
-			"timestamp": 1633042800,
-			"metadata":  `{"model":"gpt-4","token_count":150}`,
-		},
-		{
-			"index":     1,
-			"user_id":   userID,
-			"action":    "chat",
-			"hash":      "0xabcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890",
-			"timestamp": 1633046400,
-			"metadata":  `{"model":"gpt-3.5-turbo","token_count":200}`,
-		},
-	}, nil
+	code, err := c.ethClient.CodeAt(ctx, c.contractAddr, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get contract code: %w", err)
+	}
+	if len(code) == 0 {
+		return fmt.Errorf("no contract deployed at %s", c.contractAddr.Hex())
+	}
+	return nil
 }
 
 // GenerateContentHash generates a content hash from request and response data
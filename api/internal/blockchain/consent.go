@@ -0,0 +1,189 @@
+package blockchain
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/zap"
+)
+
+//go:generate abigen --abi ConsentRegistry.abi --pkg blockchain --type ConsentRegistry --out consent_registry.go
+
+// ErrConsentRegistryDisabled is returned when no consent registry is configured
+var ErrConsentRegistryDisabled = errors.New("consent registry is not configured")
+
+// ErrNoAttesterKey is returned when attesting consent without a signing key
+var ErrNoAttesterKey = errors.New("no blockchain private key configured for consent attestation")
+
+// ConsentAttestation is the on-chain state of a subject's consent for a purpose
+type ConsentAttestation struct {
+	Valid     bool       `json:"valid"`
+	ConsentID string     `json:"consent_id,omitempty"`
+	GrantedAt *time.Time `json:"granted_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// SubjectHash returns the on-chain identifier of a tenant-scoped data subject,
+// an HMAC-SHA256 of the tenant and subject ID under key. Only the hash is
+// stored on-chain so no personal data is published, and keying it stops
+// guessable subject IDs, such as email addresses, from being confirmed by
+// hashing candidates and searching the public chain for them.
+func SubjectHash(key []byte, tenant string, subjectID string) [32]byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(tenant + "|" + subjectID))
+
+	var hash [32]byte
+	copy(hash[:], mac.Sum(nil))
+	return hash
+}
+
+// EnableConsentRegistry binds the client to the ConsentRegistry contract at
+// address. Attesting consent additionally requires a hex-encoded private key
+// of an address allowed to attest; without one the registry is read-only.
+func (c *Client) EnableConsentRegistry(address string, privateKeyHex string) error {
+	if !common.IsHexAddress(address) {
+		return fmt.Errorf("invalid consent registry address: %s", address)
+	}
+
+	registry, err := NewConsentRegistry(common.HexToAddress(address), c.ethClient)
+	if err != nil {
+		return fmt.Errorf("failed to bind consent registry: %w", err)
+	}
+
+	if privateKeyHex != "" {
+		privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
+		if err != nil {
+			return fmt.Errorf("invalid blockchain private key: %w", err)
+		}
+		c.attesterKey = privateKey
+	}
+
+	c.consentRegistry = registry
+	return nil
+}
+
+// AttestConsentGrant records a consent grant on-chain and waits for it to be
+// mined. A nil expiresAt grants consent until it is revoked.
+func (c *Client) AttestConsentGrant(ctx context.Context, subjectHash [32]byte, purpose string, consentID string, expiresAt *time.Time) (string, error) {
+	opts, err := c.transactOpts(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	expiry := new(big.Int)
+	if expiresAt != nil {
+		expiry.SetInt64(expiresAt.Unix())
+	}
+
+	tx, err := c.consentRegistry.GrantConsent(opts, subjectHash, purpose, consentID, expiry)
+	if err != nil {
+		return "", fmt.Errorf("failed to send consent grant: %w", err)
+	}
+	return c.waitMined(ctx, tx)
+}
+
+// AttestConsentRevocation records a consent revocation on-chain and waits for it to be mined
+func (c *Client) AttestConsentRevocation(ctx context.Context, subjectHash [32]byte, purpose string) (string, error) {
+	opts, err := c.transactOpts(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := c.consentRegistry.RevokeConsent(opts, subjectHash, purpose)
+	if err != nil {
+		return "", fmt.Errorf("failed to send consent revocation: %w", err)
+	}
+	return c.waitMined(ctx, tx)
+}
+
+// GetConsent returns the latest on-chain consent for a subject and purpose
+func (c *Client) GetConsent(ctx context.Context, subjectHash [32]byte, purpose string) (ConsentAttestation, error) {
+	if c.consentRegistry == nil {
+		return ConsentAttestation{}, ErrConsentRegistryDisabled
+	}
+
+	consent, err := c.consentRegistry.GetConsent(&bind.CallOpts{Context: ctx}, subjectHash, purpose)
+	if err != nil {
+		return ConsentAttestation{}, fmt.Errorf("failed to get consent: %w", err)
+	}
+
+	return ConsentAttestation{
+		Valid:     consent.Valid,
+		ConsentID: consent.ConsentId,
+		GrantedAt: unixTime(consent.GrantedAt),
+		ExpiresAt: unixTime(consent.ExpiresAt),
+		RevokedAt: unixTime(consent.RevokedAt),
+	}, nil
+}
+
+// WasConsentValidAt reports whether a subject had valid on-chain consent for
+// a purpose at a point in time, and the reference of that consent
+func (c *Client) WasConsentValidAt(ctx context.Context, subjectHash [32]byte, purpose string, at time.Time) (bool, string, error) {
+	if c.consentRegistry == nil {
+		return false, "", ErrConsentRegistryDisabled
+	}
+
+	result, err := c.consentRegistry.WasConsentValidAt(&bind.CallOpts{Context: ctx}, subjectHash, purpose, big.NewInt(at.Unix()))
+	if err != nil {
+		return false, "", fmt.Errorf("failed to check consent: %w", err)
+	}
+	return result.Valid, result.ConsentId, nil
+}
+
+// transactOpts returns signing options for consent attestation transactions
+func (c *Client) transactOpts(ctx context.Context) (*bind.TransactOpts, error) {
+	if c.consentRegistry == nil {
+		return nil, ErrConsentRegistryDisabled
+	}
+	if c.attesterKey == nil {
+		return nil, ErrNoAttesterKey
+	}
+
+	chainID, err := c.ethClient.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID: %w", err)
+	}
+	opts, err := bind.NewKeyedTransactorWithChainID(c.attesterKey, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transactor: %w", err)
+	}
+	opts.Context = ctx
+	return opts, nil
+}
+
+// waitMined waits for a transaction to be mined and checks that it succeeded
+func (c *Client) waitMined(ctx context.Context, tx *types.Transaction) (string, error) {
+	receipt, err := bind.WaitMined(ctx, c.ethClient, tx)
+	if err != nil {
+		return "", fmt.Errorf("failed to wait for transaction %s: %w", tx.Hash().Hex(), err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return "", fmt.Errorf("transaction %s reverted", tx.Hash().Hex())
+	}
+
+	c.logger.Info("Consent attestation mined",
+		zap.String("tx_hash", tx.Hash().Hex()),
+		zap.Uint64("block", receipt.BlockNumber.Uint64()),
+	)
+	return tx.Hash().Hex(), nil
+}
+
+// unixTime converts an on-chain timestamp to a time, treating zero as unset
+func unixTime(timestamp *big.Int) *time.Time {
+	if timestamp == nil || timestamp.Sign() == 0 {
+		return nil
+	}
+	t := time.Unix(timestamp.Int64(), 0).UTC()
+	return &t
+}
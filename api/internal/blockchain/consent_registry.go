@@ -0,0 +1,882 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package blockchain
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+	_ = abi.ConvertType
+)
+
+// ConsentRegistryMetaData contains all meta data concerning the ConsentRegistry contract.
+var ConsentRegistryMetaData = &bind.MetaData{
+	ABI: "[{\"inputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"internalType\":\"address\",\"name\":\"attester\",\"type\":\"address\",\"indexed\":true},{\"internalType\":\"bool\",\"name\":\"allowed\",\"type\":\"bool\",\"indexed\":false}],\"name\":\"AttesterUpdated\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"subjectHash\",\"type\":\"bytes32\",\"indexed\":true},{\"internalType\":\"string\",\"name\":\"purpose\",\"type\":\"string\",\"indexed\":false},{\"internalType\":\"string\",\"name\":\"consentId\",\"type\":\"string\",\"indexed\":false},{\"internalType\":\"uint256\",\"name\":\"expiresAt\",\"type\":\"uint256\",\"indexed\":false},{\"internalType\":\"uint256\",\"name\":\"timestamp\",\"type\":\"uint256\",\"indexed\":false}],\"name\":\"ConsentGranted\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"subjectHash\",\"type\":\"bytes32\",\"indexed\":true},{\"internalType\":\"string\",\"name\":\"purpose\",\"type\":\"string\",\"indexed\":false},{\"internalType\":\"string\",\"name\":\"consentId\",\"type\":\"string\",\"indexed\":false},{\"internalType\":\"uint256\",\"name\":\"timestamp\",\"type\":\"uint256\",\"indexed\":false}],\"name\":\"ConsentRevoked\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"name\":\"attesters\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"subjectHash\",\"type\":\"bytes32\"},{\"internalType\":\"string\",\"name\":\"purpose\",\"type\":\"string\"}],\"name\":\"getConsent\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"valid\",\"type\":\"bool\"},{\"internalType\":\"string\",\"name\":\"consentId\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"grantedAt\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"expiresAt\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"revokedAt\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"subjectHash\",\"type\":\"bytes32\"},{\"internalType\":\"string\",\"name\":\"purpose\",\"type\":\"string\"}],\"name\":\"getConsentCount\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"subjectHash\",\"type\":\"bytes32\"},{\"internalType\":\"string\",\"name\":\"purpose\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"consentId\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"expiresAt\",\"type\":\"uint256\"}],\"name\":\"grantConsent\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"owner\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"subjectHash\",\"type\":\"bytes32\"},{\"internalType\":\"string\",\"name\":\"purpose\",\"type\":\"string\"}],\"name\":\"revokeConsent\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"attester\",\"type\":\"address\"},{\"internalType\":\"bool\",\"name\":\"allowed\",\"type\":\"bool\"}],\"name\":\"setAttester\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"subjectHash\",\"type\":\"bytes32\"},{\"internalType\":\"string\",\"name\":\"purpose\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"timestamp\",\"type\":\"uint256\"}],\"name\":\"wasConsentValidAt\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"valid\",\"type\":\"bool\"},{\"internalType\":\"string\",\"name\":\"consentId\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"}]",
+}
+
+// ConsentRegistryABI is the input ABI used to generate the binding from.
+// Deprecated: Use ConsentRegistryMetaData.ABI instead.
+var ConsentRegistryABI = ConsentRegistryMetaData.ABI
+
+// ConsentRegistry is an auto generated Go binding around an Ethereum contract.
+type ConsentRegistry struct {
+	ConsentRegistryCaller     // Read-only binding to the contract
+	ConsentRegistryTransactor // Write-only binding to the contract
+	ConsentRegistryFilterer   // Log filterer for contract events
+}
+
+// ConsentRegistryCaller is an auto generated read-only Go binding around an Ethereum contract.
+type ConsentRegistryCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ConsentRegistryTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type ConsentRegistryTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ConsentRegistryFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type ConsentRegistryFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ConsentRegistrySession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type ConsentRegistrySession struct {
+	Contract     *ConsentRegistry  // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// ConsentRegistryCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type ConsentRegistryCallerSession struct {
+	Contract *ConsentRegistryCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts          // Call options to use throughout this session
+}
+
+// ConsentRegistryTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type ConsentRegistryTransactorSession struct {
+	Contract     *ConsentRegistryTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts          // Transaction auth options to use throughout this session
+}
+
+// ConsentRegistryRaw is an auto generated low-level Go binding around an Ethereum contract.
+type ConsentRegistryRaw struct {
+	Contract *ConsentRegistry // Generic contract binding to access the raw methods on
+}
+
+// ConsentRegistryCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type ConsentRegistryCallerRaw struct {
+	Contract *ConsentRegistryCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// ConsentRegistryTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type ConsentRegistryTransactorRaw struct {
+	Contract *ConsentRegistryTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewConsentRegistry creates a new instance of ConsentRegistry, bound to a specific deployed contract.
+func NewConsentRegistry(address common.Address, backend bind.ContractBackend) (*ConsentRegistry, error) {
+	contract, err := bindConsentRegistry(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &ConsentRegistry{ConsentRegistryCaller: ConsentRegistryCaller{contract: contract}, ConsentRegistryTransactor: ConsentRegistryTransactor{contract: contract}, ConsentRegistryFilterer: ConsentRegistryFilterer{contract: contract}}, nil
+}
+
+// NewConsentRegistryCaller creates a new read-only instance of ConsentRegistry, bound to a specific deployed contract.
+func NewConsentRegistryCaller(address common.Address, caller bind.ContractCaller) (*ConsentRegistryCaller, error) {
+	contract, err := bindConsentRegistry(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ConsentRegistryCaller{contract: contract}, nil
+}
+
+// NewConsentRegistryTransactor creates a new write-only instance of ConsentRegistry, bound to a specific deployed contract.
+func NewConsentRegistryTransactor(address common.Address, transactor bind.ContractTransactor) (*ConsentRegistryTransactor, error) {
+	contract, err := bindConsentRegistry(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ConsentRegistryTransactor{contract: contract}, nil
+}
+
+// NewConsentRegistryFilterer creates a new log filterer instance of ConsentRegistry, bound to a specific deployed contract.
+func NewConsentRegistryFilterer(address common.Address, filterer bind.ContractFilterer) (*ConsentRegistryFilterer, error) {
+	contract, err := bindConsentRegistry(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &ConsentRegistryFilterer{contract: contract}, nil
+}
+
+// bindConsentRegistry binds a generic wrapper to an already deployed contract.
+func bindConsentRegistry(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := ConsentRegistryMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, *parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_ConsentRegistry *ConsentRegistryRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _ConsentRegistry.Contract.ConsentRegistryCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_ConsentRegistry *ConsentRegistryRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _ConsentRegistry.Contract.ConsentRegistryTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_ConsentRegistry *ConsentRegistryRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _ConsentRegistry.Contract.ConsentRegistryTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_ConsentRegistry *ConsentRegistryCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _ConsentRegistry.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_ConsentRegistry *ConsentRegistryTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _ConsentRegistry.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_ConsentRegistry *ConsentRegistryTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _ConsentRegistry.Contract.contract.Transact(opts, method, params...)
+}
+
+// Attesters is a free data retrieval call binding the contract method 0x131a91ad.
+//
+// Solidity: function attesters(address ) view returns(bool)
+func (_ConsentRegistry *ConsentRegistryCaller) Attesters(opts *bind.CallOpts, arg0 common.Address) (bool, error) {
+	var out []interface{}
+	err := _ConsentRegistry.contract.Call(opts, &out, "attesters", arg0)
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// Attesters is a free data retrieval call binding the contract method 0x131a91ad.
+//
+// Solidity: function attesters(address ) view returns(bool)
+func (_ConsentRegistry *ConsentRegistrySession) Attesters(arg0 common.Address) (bool, error) {
+	return _ConsentRegistry.Contract.Attesters(&_ConsentRegistry.CallOpts, arg0)
+}
+
+// Attesters is a free data retrieval call binding the contract method 0x131a91ad.
+//
+// Solidity: function attesters(address ) view returns(bool)
+func (_ConsentRegistry *ConsentRegistryCallerSession) Attesters(arg0 common.Address) (bool, error) {
+	return _ConsentRegistry.Contract.Attesters(&_ConsentRegistry.CallOpts, arg0)
+}
+
+// GetConsent is a free data retrieval call binding the contract method 0x5934b6c0.
+//
+// Solidity: function getConsent(bytes32 subjectHash, string purpose) view returns(bool valid, string consentId, uint256 grantedAt, uint256 expiresAt, uint256 revokedAt)
+func (_ConsentRegistry *ConsentRegistryCaller) GetConsent(opts *bind.CallOpts, subjectHash [32]byte, purpose string) (struct {
+	Valid     bool
+	ConsentId string
+	GrantedAt *big.Int
+	ExpiresAt *big.Int
+	RevokedAt *big.Int
+}, error) {
+	var out []interface{}
+	err := _ConsentRegistry.contract.Call(opts, &out, "getConsent", subjectHash, purpose)
+
+	outstruct := new(struct {
+		Valid     bool
+		ConsentId string
+		GrantedAt *big.Int
+		ExpiresAt *big.Int
+		RevokedAt *big.Int
+	})
+	if err != nil {
+		return *outstruct, err
+	}
+
+	outstruct.Valid = *abi.ConvertType(out[0], new(bool)).(*bool)
+	outstruct.ConsentId = *abi.ConvertType(out[1], new(string)).(*string)
+	outstruct.GrantedAt = *abi.ConvertType(out[2], new(*big.Int)).(**big.Int)
+	outstruct.ExpiresAt = *abi.ConvertType(out[3], new(*big.Int)).(**big.Int)
+	outstruct.RevokedAt = *abi.ConvertType(out[4], new(*big.Int)).(**big.Int)
+
+	return *outstruct, err
+
+}
+
+// GetConsent is a free data retrieval call binding the contract method 0x5934b6c0.
+//
+// Solidity: function getConsent(bytes32 subjectHash, string purpose) view returns(bool valid, string consentId, uint256 grantedAt, uint256 expiresAt, uint256 revokedAt)
+func (_ConsentRegistry *ConsentRegistrySession) GetConsent(subjectHash [32]byte, purpose string) (struct {
+	Valid     bool
+	ConsentId string
+	GrantedAt *big.Int
+	ExpiresAt *big.Int
+	RevokedAt *big.Int
+}, error) {
+	return _ConsentRegistry.Contract.GetConsent(&_ConsentRegistry.CallOpts, subjectHash, purpose)
+}
+
+// GetConsent is a free data retrieval call binding the contract method 0x5934b6c0.
+//
+// Solidity: function getConsent(bytes32 subjectHash, string purpose) view returns(bool valid, string consentId, uint256 grantedAt, uint256 expiresAt, uint256 revokedAt)
+func (_ConsentRegistry *ConsentRegistryCallerSession) GetConsent(subjectHash [32]byte, purpose string) (struct {
+	Valid     bool
+	ConsentId string
+	GrantedAt *big.Int
+	ExpiresAt *big.Int
+	RevokedAt *big.Int
+}, error) {
+	return _ConsentRegistry.Contract.GetConsent(&_ConsentRegistry.CallOpts, subjectHash, purpose)
+}
+
+// GetConsentCount is a free data retrieval call binding the contract method 0x76c8cb6c.
+//
+// Solidity: function getConsentCount(bytes32 subjectHash, string purpose) view returns(uint256)
+func (_ConsentRegistry *ConsentRegistryCaller) GetConsentCount(opts *bind.CallOpts, subjectHash [32]byte, purpose string) (*big.Int, error) {
+	var out []interface{}
+	err := _ConsentRegistry.contract.Call(opts, &out, "getConsentCount", subjectHash, purpose)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// GetConsentCount is a free data retrieval call binding the contract method 0x76c8cb6c.
+//
+// Solidity: function getConsentCount(bytes32 subjectHash, string purpose) view returns(uint256)
+func (_ConsentRegistry *ConsentRegistrySession) GetConsentCount(subjectHash [32]byte, purpose string) (*big.Int, error) {
+	return _ConsentRegistry.Contract.GetConsentCount(&_ConsentRegistry.CallOpts, subjectHash, purpose)
+}
+
+// GetConsentCount is a free data retrieval call binding the contract method 0x76c8cb6c.
+//
+// Solidity: function getConsentCount(bytes32 subjectHash, string purpose) view returns(uint256)
+func (_ConsentRegistry *ConsentRegistryCallerSession) GetConsentCount(subjectHash [32]byte, purpose string) (*big.Int, error) {
+	return _ConsentRegistry.Contract.GetConsentCount(&_ConsentRegistry.CallOpts, subjectHash, purpose)
+}
+
+// Owner is a free data retrieval call binding the contract method 0x8da5cb5b.
+//
+// Solidity: function owner() view returns(address)
+func (_ConsentRegistry *ConsentRegistryCaller) Owner(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _ConsentRegistry.contract.Call(opts, &out, "owner")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Owner is a free data retrieval call binding the contract method 0x8da5cb5b.
+//
+// Solidity: function owner() view returns(address)
+func (_ConsentRegistry *ConsentRegistrySession) Owner() (common.Address, error) {
+	return _ConsentRegistry.Contract.Owner(&_ConsentRegistry.CallOpts)
+}
+
+// Owner is a free data retrieval call binding the contract method 0x8da5cb5b.
+//
+// Solidity: function owner() view returns(address)
+func (_ConsentRegistry *ConsentRegistryCallerSession) Owner() (common.Address, error) {
+	return _ConsentRegistry.Contract.Owner(&_ConsentRegistry.CallOpts)
+}
+
+// WasConsentValidAt is a free data retrieval call binding the contract method 0x9de48c63.
+//
+// Solidity: function wasConsentValidAt(bytes32 subjectHash, string purpose, uint256 timestamp) view returns(bool valid, string consentId)
+func (_ConsentRegistry *ConsentRegistryCaller) WasConsentValidAt(opts *bind.CallOpts, subjectHash [32]byte, purpose string, timestamp *big.Int) (struct {
+	Valid     bool
+	ConsentId string
+}, error) {
+	var out []interface{}
+	err := _ConsentRegistry.contract.Call(opts, &out, "wasConsentValidAt", subjectHash, purpose, timestamp)
+
+	outstruct := new(struct {
+		Valid     bool
+		ConsentId string
+	})
+	if err != nil {
+		return *outstruct, err
+	}
+
+	outstruct.Valid = *abi.ConvertType(out[0], new(bool)).(*bool)
+	outstruct.ConsentId = *abi.ConvertType(out[1], new(string)).(*string)
+
+	return *outstruct, err
+
+}
+
+// WasConsentValidAt is a free data retrieval call binding the contract method 0x9de48c63.
+//
+// Solidity: function wasConsentValidAt(bytes32 subjectHash, string purpose, uint256 timestamp) view returns(bool valid, string consentId)
+func (_ConsentRegistry *ConsentRegistrySession) WasConsentValidAt(subjectHash [32]byte, purpose string, timestamp *big.Int) (struct {
+	Valid     bool
+	ConsentId string
+}, error) {
+	return _ConsentRegistry.Contract.WasConsentValidAt(&_ConsentRegistry.CallOpts, subjectHash, purpose, timestamp)
+}
+
+// WasConsentValidAt is a free data retrieval call binding the contract method 0x9de48c63.
+//
+// Solidity: function wasConsentValidAt(bytes32 subjectHash, string purpose, uint256 timestamp) view returns(bool valid, string consentId)
+func (_ConsentRegistry *ConsentRegistryCallerSession) WasConsentValidAt(subjectHash [32]byte, purpose string, timestamp *big.Int) (struct {
+	Valid     bool
+	ConsentId string
+}, error) {
+	return _ConsentRegistry.Contract.WasConsentValidAt(&_ConsentRegistry.CallOpts, subjectHash, purpose, timestamp)
+}
+
+// GrantConsent is a paid mutator transaction binding the contract method 0x5dcffd13.
+//
+// Solidity: function grantConsent(bytes32 subjectHash, string purpose, string consentId, uint256 expiresAt) returns()
+func (_ConsentRegistry *ConsentRegistryTransactor) GrantConsent(opts *bind.TransactOpts, subjectHash [32]byte, purpose string, consentId string, expiresAt *big.Int) (*types.Transaction, error) {
+	return _ConsentRegistry.contract.Transact(opts, "grantConsent", subjectHash, purpose, consentId, expiresAt)
+}
+
+// GrantConsent is a paid mutator transaction binding the contract method 0x5dcffd13.
+//
+// Solidity: function grantConsent(bytes32 subjectHash, string purpose, string consentId, uint256 expiresAt) returns()
+func (_ConsentRegistry *ConsentRegistrySession) GrantConsent(subjectHash [32]byte, purpose string, consentId string, expiresAt *big.Int) (*types.Transaction, error) {
+	return _ConsentRegistry.Contract.GrantConsent(&_ConsentRegistry.TransactOpts, subjectHash, purpose, consentId, expiresAt)
+}
+
+// GrantConsent is a paid mutator transaction binding the contract method 0x5dcffd13.
+//
+// Solidity: function grantConsent(bytes32 subjectHash, string purpose, string consentId, uint256 expiresAt) returns()
+func (_ConsentRegistry *ConsentRegistryTransactorSession) GrantConsent(subjectHash [32]byte, purpose string, consentId string, expiresAt *big.Int) (*types.Transaction, error) {
+	return _ConsentRegistry.Contract.GrantConsent(&_ConsentRegistry.TransactOpts, subjectHash, purpose, consentId, expiresAt)
+}
+
+// RevokeConsent is a paid mutator transaction binding the contract method 0x380b413f.
+//
+// Solidity: function revokeConsent(bytes32 subjectHash, string purpose) returns()
+func (_ConsentRegistry *ConsentRegistryTransactor) RevokeConsent(opts *bind.TransactOpts, subjectHash [32]byte, purpose string) (*types.Transaction, error) {
+	return _ConsentRegistry.contract.Transact(opts, "revokeConsent", subjectHash, purpose)
+}
+
+// RevokeConsent is a paid mutator transaction binding the contract method 0x380b413f.
+//
+// Solidity: function revokeConsent(bytes32 subjectHash, string purpose) returns()
+func (_ConsentRegistry *ConsentRegistrySession) RevokeConsent(subjectHash [32]byte, purpose string) (*types.Transaction, error) {
+	return _ConsentRegistry.Contract.RevokeConsent(&_ConsentRegistry.TransactOpts, subjectHash, purpose)
+}
+
+// RevokeConsent is a paid mutator transaction binding the contract method 0x380b413f.
+//
+// Solidity: function revokeConsent(bytes32 subjectHash, string purpose) returns()
+func (_ConsentRegistry *ConsentRegistryTransactorSession) RevokeConsent(subjectHash [32]byte, purpose string) (*types.Transaction, error) {
+	return _ConsentRegistry.Contract.RevokeConsent(&_ConsentRegistry.TransactOpts, subjectHash, purpose)
+}
+
+// SetAttester is a paid mutator transaction binding the contract method 0x5c0204d9.
+//
+// Solidity: function setAttester(address attester, bool allowed) returns()
+func (_ConsentRegistry *ConsentRegistryTransactor) SetAttester(opts *bind.TransactOpts, attester common.Address, allowed bool) (*types.Transaction, error) {
+	return _ConsentRegistry.contract.Transact(opts, "setAttester", attester, allowed)
+}
+
+// SetAttester is a paid mutator transaction binding the contract method 0x5c0204d9.
+//
+// Solidity: function setAttester(address attester, bool allowed) returns()
+func (_ConsentRegistry *ConsentRegistrySession) SetAttester(attester common.Address, allowed bool) (*types.Transaction, error) {
+	return _ConsentRegistry.Contract.SetAttester(&_ConsentRegistry.TransactOpts, attester, allowed)
+}
+
+// SetAttester is a paid mutator transaction binding the contract method 0x5c0204d9.
+//
+// Solidity: function setAttester(address attester, bool allowed) returns()
+func (_ConsentRegistry *ConsentRegistryTransactorSession) SetAttester(attester common.Address, allowed bool) (*types.Transaction, error) {
+	return _ConsentRegistry.Contract.SetAttester(&_ConsentRegistry.TransactOpts, attester, allowed)
+}
+
+// ConsentRegistryAttesterUpdatedIterator is returned from FilterAttesterUpdated and is used to iterate over the raw logs and unpacked data for AttesterUpdated events raised by the ConsentRegistry contract.
+type ConsentRegistryAttesterUpdatedIterator struct {
+	Event *ConsentRegistryAttesterUpdated // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *ConsentRegistryAttesterUpdatedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(ConsentRegistryAttesterUpdated)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(ConsentRegistryAttesterUpdated)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *ConsentRegistryAttesterUpdatedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *ConsentRegistryAttesterUpdatedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// ConsentRegistryAttesterUpdated represents a AttesterUpdated event raised by the ConsentRegistry contract.
+type ConsentRegistryAttesterUpdated struct {
+	Attester common.Address
+	Allowed  bool
+	Raw      types.Log // Blockchain specific contextual infos
+}
+
+// FilterAttesterUpdated is a free log retrieval operation binding the contract event 0x2f6baedf5d85c15bc97b3e106fb2c744a011c1a294456f6369281584f778fb00.
+//
+// Solidity: event AttesterUpdated(address indexed attester, bool allowed)
+func (_ConsentRegistry *ConsentRegistryFilterer) FilterAttesterUpdated(opts *bind.FilterOpts, attester []common.Address) (*ConsentRegistryAttesterUpdatedIterator, error) {
+
+	var attesterRule []interface{}
+	for _, attesterItem := range attester {
+		attesterRule = append(attesterRule, attesterItem)
+	}
+
+	logs, sub, err := _ConsentRegistry.contract.FilterLogs(opts, "AttesterUpdated", attesterRule)
+	if err != nil {
+		return nil, err
+	}
+	return &ConsentRegistryAttesterUpdatedIterator{contract: _ConsentRegistry.contract, event: "AttesterUpdated", logs: logs, sub: sub}, nil
+}
+
+// WatchAttesterUpdated is a free log subscription operation binding the contract event 0x2f6baedf5d85c15bc97b3e106fb2c744a011c1a294456f6369281584f778fb00.
+//
+// Solidity: event AttesterUpdated(address indexed attester, bool allowed)
+func (_ConsentRegistry *ConsentRegistryFilterer) WatchAttesterUpdated(opts *bind.WatchOpts, sink chan<- *ConsentRegistryAttesterUpdated, attester []common.Address) (event.Subscription, error) {
+
+	var attesterRule []interface{}
+	for _, attesterItem := range attester {
+		attesterRule = append(attesterRule, attesterItem)
+	}
+
+	logs, sub, err := _ConsentRegistry.contract.WatchLogs(opts, "AttesterUpdated", attesterRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(ConsentRegistryAttesterUpdated)
+				if err := _ConsentRegistry.contract.UnpackLog(event, "AttesterUpdated", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseAttesterUpdated is a log parse operation binding the contract event 0x2f6baedf5d85c15bc97b3e106fb2c744a011c1a294456f6369281584f778fb00.
+//
+// Solidity: event AttesterUpdated(address indexed attester, bool allowed)
+func (_ConsentRegistry *ConsentRegistryFilterer) ParseAttesterUpdated(log types.Log) (*ConsentRegistryAttesterUpdated, error) {
+	event := new(ConsentRegistryAttesterUpdated)
+	if err := _ConsentRegistry.contract.UnpackLog(event, "AttesterUpdated", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ConsentRegistryConsentGrantedIterator is returned from FilterConsentGranted and is used to iterate over the raw logs and unpacked data for ConsentGranted events raised by the ConsentRegistry contract.
+type ConsentRegistryConsentGrantedIterator struct {
+	Event *ConsentRegistryConsentGranted // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *ConsentRegistryConsentGrantedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(ConsentRegistryConsentGranted)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(ConsentRegistryConsentGranted)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *ConsentRegistryConsentGrantedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *ConsentRegistryConsentGrantedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// ConsentRegistryConsentGranted represents a ConsentGranted event raised by the ConsentRegistry contract.
+type ConsentRegistryConsentGranted struct {
+	SubjectHash [32]byte
+	Purpose     string
+	ConsentId   string
+	ExpiresAt   *big.Int
+	Timestamp   *big.Int
+	Raw         types.Log // Blockchain specific contextual infos
+}
+
+// FilterConsentGranted is a free log retrieval operation binding the contract event 0x3c6e3731b7e72bd0149d64284237d1d26c9ba14a29097866acc9594892dc9998.
+//
+// Solidity: event ConsentGranted(bytes32 indexed subjectHash, string purpose, string consentId, uint256 expiresAt, uint256 timestamp)
+func (_ConsentRegistry *ConsentRegistryFilterer) FilterConsentGranted(opts *bind.FilterOpts, subjectHash [][32]byte) (*ConsentRegistryConsentGrantedIterator, error) {
+
+	var subjectHashRule []interface{}
+	for _, subjectHashItem := range subjectHash {
+		subjectHashRule = append(subjectHashRule, subjectHashItem)
+	}
+
+	logs, sub, err := _ConsentRegistry.contract.FilterLogs(opts, "ConsentGranted", subjectHashRule)
+	if err != nil {
+		return nil, err
+	}
+	return &ConsentRegistryConsentGrantedIterator{contract: _ConsentRegistry.contract, event: "ConsentGranted", logs: logs, sub: sub}, nil
+}
+
+// WatchConsentGranted is a free log subscription operation binding the contract event 0x3c6e3731b7e72bd0149d64284237d1d26c9ba14a29097866acc9594892dc9998.
+//
+// Solidity: event ConsentGranted(bytes32 indexed subjectHash, string purpose, string consentId, uint256 expiresAt, uint256 timestamp)
+func (_ConsentRegistry *ConsentRegistryFilterer) WatchConsentGranted(opts *bind.WatchOpts, sink chan<- *ConsentRegistryConsentGranted, subjectHash [][32]byte) (event.Subscription, error) {
+
+	var subjectHashRule []interface{}
+	for _, subjectHashItem := range subjectHash {
+		subjectHashRule = append(subjectHashRule, subjectHashItem)
+	}
+
+	logs, sub, err := _ConsentRegistry.contract.WatchLogs(opts, "ConsentGranted", subjectHashRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(ConsentRegistryConsentGranted)
+				if err := _ConsentRegistry.contract.UnpackLog(event, "ConsentGranted", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseConsentGranted is a log parse operation binding the contract event 0x3c6e3731b7e72bd0149d64284237d1d26c9ba14a29097866acc9594892dc9998.
+//
+// Solidity: event ConsentGranted(bytes32 indexed subjectHash, string purpose, string consentId, uint256 expiresAt, uint256 timestamp)
+func (_ConsentRegistry *ConsentRegistryFilterer) ParseConsentGranted(log types.Log) (*ConsentRegistryConsentGranted, error) {
+	event := new(ConsentRegistryConsentGranted)
+	if err := _ConsentRegistry.contract.UnpackLog(event, "ConsentGranted", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ConsentRegistryConsentRevokedIterator is returned from FilterConsentRevoked and is used to iterate over the raw logs and unpacked data for ConsentRevoked events raised by the ConsentRegistry contract.
+type ConsentRegistryConsentRevokedIterator struct {
+	Event *ConsentRegistryConsentRevoked // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *ConsentRegistryConsentRevokedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(ConsentRegistryConsentRevoked)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(ConsentRegistryConsentRevoked)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *ConsentRegistryConsentRevokedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *ConsentRegistryConsentRevokedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// ConsentRegistryConsentRevoked represents a ConsentRevoked event raised by the ConsentRegistry contract.
+type ConsentRegistryConsentRevoked struct {
+	SubjectHash [32]byte
+	Purpose     string
+	ConsentId   string
+	Timestamp   *big.Int
+	Raw         types.Log // Blockchain specific contextual infos
+}
+
+// FilterConsentRevoked is a free log retrieval operation binding the contract event 0x0cf6eaed9792dd101fe745dde8255a771b6e6b13bb90fce9055fbc3442239733.
+//
+// Solidity: event ConsentRevoked(bytes32 indexed subjectHash, string purpose, string consentId, uint256 timestamp)
+func (_ConsentRegistry *ConsentRegistryFilterer) FilterConsentRevoked(opts *bind.FilterOpts, subjectHash [][32]byte) (*ConsentRegistryConsentRevokedIterator, error) {
+
+	var subjectHashRule []interface{}
+	for _, subjectHashItem := range subjectHash {
+		subjectHashRule = append(subjectHashRule, subjectHashItem)
+	}
+
+	logs, sub, err := _ConsentRegistry.contract.FilterLogs(opts, "ConsentRevoked", subjectHashRule)
+	if err != nil {
+		return nil, err
+	}
+	return &ConsentRegistryConsentRevokedIterator{contract: _ConsentRegistry.contract, event: "ConsentRevoked", logs: logs, sub: sub}, nil
+}
+
+// WatchConsentRevoked is a free log subscription operation binding the contract event 0x0cf6eaed9792dd101fe745dde8255a771b6e6b13bb90fce9055fbc3442239733.
+//
+// Solidity: event ConsentRevoked(bytes32 indexed subjectHash, string purpose, string consentId, uint256 timestamp)
+func (_ConsentRegistry *ConsentRegistryFilterer) WatchConsentRevoked(opts *bind.WatchOpts, sink chan<- *ConsentRegistryConsentRevoked, subjectHash [][32]byte) (event.Subscription, error) {
+
+	var subjectHashRule []interface{}
+	for _, subjectHashItem := range subjectHash {
+		subjectHashRule = append(subjectHashRule, subjectHashItem)
+	}
+
+	logs, sub, err := _ConsentRegistry.contract.WatchLogs(opts, "ConsentRevoked", subjectHashRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(ConsentRegistryConsentRevoked)
+				if err := _ConsentRegistry.contract.UnpackLog(event, "ConsentRevoked", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseConsentRevoked is a log parse operation binding the contract event 0x0cf6eaed9792dd101fe745dde8255a771b6e6b13bb90fce9055fbc3442239733.
+//
+// Solidity: event ConsentRevoked(bytes32 indexed subjectHash, string purpose, string consentId, uint256 timestamp)
+func (_ConsentRegistry *ConsentRegistryFilterer) ParseConsentRevoked(log types.Log) (*ConsentRegistryConsentRevoked, error) {
+	event := new(ConsentRegistryConsentRevoked)
+	if err := _ConsentRegistry.contract.UnpackLog(event, "ConsentRevoked", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
@@ -0,0 +1,348 @@
+package blockchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/asm"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/zap"
+)
+
+// simulatedChain is a simulated backend that mines every transaction as it
+// is sent, as a development node with instant sealing does
+type simulatedChain struct {
+	*backends.SimulatedBackend
+}
+
+func (c simulatedChain) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := c.SimulatedBackend.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	c.Commit()
+	return nil
+}
+
+func (c simulatedChain) ChainID(ctx context.Context) (*big.Int, error) {
+	return c.Blockchain().Config().ChainID, nil
+}
+
+// now returns the timestamp of the latest block
+func (c simulatedChain) now(t *testing.T) time.Time {
+	t.Helper()
+	header, err := c.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("HeaderByNumber() error = %v", err)
+	}
+	return time.Unix(int64(header.Time), 0).UTC()
+}
+
+// assemble compiles an EVM assembly file from testdata
+func assemble(t *testing.T, name string) []byte {
+	t.Helper()
+	source, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", name, err)
+	}
+	compiler := asm.NewCompiler(false)
+	compiler.Feed(asm.Lex(source, false))
+	code, errs := compiler.Compile()
+	if len(errs) > 0 {
+		t.Fatalf("failed to assemble %s: %v", name, errs)
+	}
+	bytecode, err := hex.DecodeString(code)
+	if err != nil {
+		t.Fatalf("failed to decode %s: %v", name, err)
+	}
+	return bytecode
+}
+
+// deployConsentRegistry deploys the EVM assembly port of ConsentRegistry.sol
+// from testdata to a simulated chain, owned by the returned key
+func deployConsentRegistry(t *testing.T) (simulatedChain, common.Address, *ecdsa.PrivateKey) {
+	t.Helper()
+	owner, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	funds := new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18))
+	chain := simulatedChain{backends.NewSimulatedBackend(core.GenesisAlloc{
+		crypto.PubkeyToAddress(owner.PublicKey): {Balance: funds},
+	}, 30_000_000)}
+	t.Cleanup(func() { chain.Close() })
+
+	parsed, err := ConsentRegistryMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("GetAbi() error = %v", err)
+	}
+	bytecode := append(assemble(t, "ConsentRegistry.init.evm"), assemble(t, "ConsentRegistry.evm")...)
+
+	chainID, _ := chain.ChainID(context.Background())
+	opts, err := bind.NewKeyedTransactorWithChainID(owner, chainID)
+	if err != nil {
+		t.Fatalf("NewKeyedTransactorWithChainID() error = %v", err)
+	}
+	address, _, _, err := bind.DeployContract(opts, *parsed, bytecode, chain)
+	if err != nil {
+		t.Fatalf("DeployContract() error = %v", err)
+	}
+	return chain, address, owner
+}
+
+// newRegistryClient creates a client bound to the consent registry at
+// address, attesting with key
+func newRegistryClient(t *testing.T, chain simulatedChain, address common.Address, key *ecdsa.PrivateKey) *Client {
+	t.Helper()
+	client, err := newClient(chain, address.Hex(), zap.NewNop())
+	if err != nil {
+		t.Fatalf("newClient() error = %v", err)
+	}
+	if err := client.EnableConsentRegistry(address.Hex(), hex.EncodeToString(crypto.FromECDSA(key))); err != nil {
+		t.Fatalf("EnableConsentRegistry() error = %v", err)
+	}
+	return client
+}
+
+func TestSubjectHash(t *testing.T) {
+	key := []byte("subject-key")
+	hash := SubjectHash(key, "acme", "john@example.com")
+
+	if SubjectHash(key, "acme", "john@example.com") != hash {
+		t.Error("SubjectHash() is not deterministic")
+	}
+	if SubjectHash([]byte("other-key"), "acme", "john@example.com") == hash {
+		t.Error("SubjectHash() does not depend on the key")
+	}
+	if SubjectHash(key, "globex", "john@example.com") == hash {
+		t.Error("SubjectHash() does not depend on the tenant")
+	}
+	if crypto.Keccak256Hash([]byte("acme|john@example.com")) == hash {
+		t.Error("SubjectHash() can be computed without the key")
+	}
+}
+
+func TestConsentRegistry(t *testing.T) {
+	ctx := context.Background()
+	chain, address, owner := deployConsentRegistry(t)
+	client := newRegistryClient(t, chain, address, owner)
+	subject := SubjectHash([]byte("subject-key"), "acme", "subject-1")
+	// Consent IDs are UUIDs, longer than a storage word
+	secondID := "6f1c2b9e-6d0a-4c1e-9b7a-2f5e8d4c1a02"
+
+	if err := client.CheckHealth(ctx); err != nil {
+		t.Errorf("CheckHealth() error = %v", err)
+	}
+
+	attestation, err := client.GetConsent(ctx, subject, "summarization")
+	if err != nil {
+		t.Fatalf("GetConsent() error = %v", err)
+	}
+	if attestation.Valid || attestation.ConsentID != "" || attestation.GrantedAt != nil {
+		t.Errorf("GetConsent() before any grant = %+v, want no consent", attestation)
+	}
+
+	// Revoking requires an active grant
+	if _, err := client.AttestConsentRevocation(ctx, subject, "summarization"); err == nil || !strings.Contains(err.Error(), "No active consent") {
+		t.Errorf("AttestConsentRevocation() without a grant error = %v, want No active consent", err)
+	}
+
+	txHash, err := client.AttestConsentGrant(ctx, subject, "summarization", "consent-1", nil)
+	if err != nil {
+		t.Fatalf("AttestConsentGrant() error = %v", err)
+	}
+	receipt, err := chain.TransactionReceipt(ctx, common.HexToHash(txHash))
+	if err != nil || receipt.Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("grant receipt = %+v, %v, want a successful transaction", receipt, err)
+	}
+	granted := chain.now(t)
+
+	attestation, err = client.GetConsent(ctx, subject, "summarization")
+	if err != nil {
+		t.Fatalf("GetConsent() error = %v", err)
+	}
+	if !attestation.Valid || attestation.ConsentID != "consent-1" || attestation.GrantedAt == nil || !attestation.GrantedAt.Equal(granted) ||
+		attestation.ExpiresAt != nil || attestation.RevokedAt != nil {
+		t.Errorf("GetConsent() after grant = %+v, want consent-1 granted at %v", attestation, granted)
+	}
+
+	// Consent is per purpose
+	if attestation, err := client.GetConsent(ctx, subject, "marketing"); err != nil || attestation.Valid {
+		t.Errorf("GetConsent() for another purpose = %+v, %v, want no consent", attestation, err)
+	}
+
+	// A new grant supersedes the active one; the earlier window stays provable
+	expiresAt := granted.Add(time.Hour)
+	if _, err := client.AttestConsentGrant(ctx, subject, "summarization", secondID, &expiresAt); err != nil {
+		t.Fatalf("AttestConsentGrant() error = %v", err)
+	}
+	superseded := chain.now(t)
+
+	attestation, err = client.GetConsent(ctx, subject, "summarization")
+	if err != nil {
+		t.Fatalf("GetConsent() error = %v", err)
+	}
+	if !attestation.Valid || attestation.ConsentID != secondID || attestation.ExpiresAt == nil || !attestation.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("GetConsent() after second grant = %+v, want the second consent expiring at %v", attestation, expiresAt)
+	}
+
+	if _, err := client.AttestConsentRevocation(ctx, subject, "summarization"); err != nil {
+		t.Fatalf("AttestConsentRevocation() error = %v", err)
+	}
+	revoked := chain.now(t)
+
+	attestation, err = client.GetConsent(ctx, subject, "summarization")
+	if err != nil {
+		t.Fatalf("GetConsent() error = %v", err)
+	}
+	if attestation.Valid || attestation.ConsentID != secondID || attestation.RevokedAt == nil || !attestation.RevokedAt.Equal(revoked) {
+		t.Errorf("GetConsent() after revocation = %+v, want the second consent revoked at %v", attestation, revoked)
+	}
+
+	tests := []struct {
+		name      string
+		at        time.Time
+		valid     bool
+		consentID string
+	}{
+		{name: "before the first grant", at: granted.Add(-time.Second)},
+		{name: "at the first grant", at: granted, valid: true, consentID: "consent-1"},
+		{name: "at the second grant", at: superseded, valid: true, consentID: secondID},
+		{name: "at the revocation", at: revoked},
+		{name: "after the expiry", at: expiresAt.Add(time.Second)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, consentID, err := client.WasConsentValidAt(ctx, subject, "summarization", tt.at)
+			if err != nil {
+				t.Fatalf("WasConsentValidAt() error = %v", err)
+			}
+			if valid != tt.valid || consentID != tt.consentID {
+				t.Errorf("WasConsentValidAt() = %v, %q, want %v, %q", valid, consentID, tt.valid, tt.consentID)
+			}
+		})
+	}
+
+	// Revoked consent cannot be revoked again
+	if _, err := client.AttestConsentRevocation(ctx, subject, "summarization"); err == nil || !strings.Contains(err.Error(), "No active consent") {
+		t.Errorf("second AttestConsentRevocation() error = %v, want No active consent", err)
+	}
+
+	// Simulated block times start at zero, so the latest block time is the
+	// earliest expiry already in the past for the next block
+	past := chain.now(t)
+	if _, err := client.AttestConsentGrant(ctx, subject, "summarization", "consent-3", &past); err == nil || !strings.Contains(err.Error(), "Expiry must be in the future") {
+		t.Errorf("AttestConsentGrant() with a past expiry error = %v, want Expiry must be in the future", err)
+	}
+
+	// Grants and revocations are published as events indexed by subject hash
+	registry, err := NewConsentRegistry(address, chain)
+	if err != nil {
+		t.Fatalf("NewConsentRegistry() error = %v", err)
+	}
+	grants, err := registry.FilterConsentGranted(&bind.FilterOpts{Context: ctx}, [][32]byte{subject})
+	if err != nil {
+		t.Fatalf("FilterConsentGranted() error = %v", err)
+	}
+	defer grants.Close()
+	var consentIDs []string
+	for grants.Next() {
+		if grants.Event.Purpose != "summarization" {
+			t.Errorf("ConsentGranted purpose = %q, want summarization", grants.Event.Purpose)
+		}
+		consentIDs = append(consentIDs, grants.Event.ConsentId)
+	}
+	if len(consentIDs) != 2 || consentIDs[0] != "consent-1" || consentIDs[1] != secondID {
+		t.Errorf("ConsentGranted events = %q, want consent-1 and %s", consentIDs, secondID)
+	}
+
+	revocations, err := registry.FilterConsentRevoked(&bind.FilterOpts{Context: ctx}, [][32]byte{subject})
+	if err != nil {
+		t.Fatalf("FilterConsentRevoked() error = %v", err)
+	}
+	defer revocations.Close()
+	if !revocations.Next() || revocations.Event.ConsentId != secondID || revocations.Event.Timestamp.Int64() != revoked.Unix() || revocations.Next() {
+		t.Errorf("ConsentRevoked events do not record the single revocation of %s", secondID)
+	}
+}
+
+func TestConsentRegistryRequiresAttester(t *testing.T) {
+	ctx := context.Background()
+	chain, address, owner := deployConsentRegistry(t)
+
+	stranger, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	// Fund the stranger so only the contract can refuse it
+	ownerClient := newRegistryClient(t, chain, address, owner)
+	opts, err := ownerClient.transactOpts(ctx)
+	if err != nil {
+		t.Fatalf("transactOpts() error = %v", err)
+	}
+	nonce, err := chain.PendingNonceAt(ctx, opts.From)
+	if err != nil {
+		t.Fatalf("PendingNonceAt() error = %v", err)
+	}
+	header, err := chain.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatalf("HeaderByNumber() error = %v", err)
+	}
+	to := crypto.PubkeyToAddress(stranger.PublicKey)
+	tx, err := opts.Signer(opts.From, types.NewTx(&types.DynamicFeeTx{
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: new(big.Int).Mul(header.BaseFee, big.NewInt(2)),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(1e18),
+	}))
+	if err != nil {
+		t.Fatalf("failed to sign transfer: %v", err)
+	}
+	if err := chain.SendTransaction(ctx, tx); err != nil {
+		t.Fatalf("failed to fund stranger: %v", err)
+	}
+
+	subject := SubjectHash([]byte("subject-key"), "acme", "subject-1")
+	client := newRegistryClient(t, chain, address, stranger)
+	if _, err := client.AttestConsentGrant(ctx, subject, "summarization", "consent-1", nil); err == nil || !strings.Contains(err.Error(), "Caller is not an attester") {
+		t.Errorf("AttestConsentGrant() by a non-attester error = %v, want Caller is not an attester", err)
+	}
+
+	// Only the owner can allow attesters
+	registry, err := NewConsentRegistry(address, chain)
+	if err != nil {
+		t.Fatalf("NewConsentRegistry() error = %v", err)
+	}
+	strangerOpts, err := client.transactOpts(ctx)
+	if err != nil {
+		t.Fatalf("transactOpts() error = %v", err)
+	}
+	if _, err := registry.SetAttester(strangerOpts, to, true); err == nil || !strings.Contains(err.Error(), "Caller is not the owner") {
+		t.Errorf("SetAttester() by a non-owner error = %v, want Caller is not the owner", err)
+	}
+	if _, err := registry.SetAttester(opts, to, true); err != nil {
+		t.Fatalf("SetAttester() error = %v", err)
+	}
+	if allowed, err := registry.Attesters(&bind.CallOpts{Context: ctx}, to); err != nil || !allowed {
+		t.Errorf("Attesters() = %v, %v, want true", allowed, err)
+	}
+
+	if _, err := client.AttestConsentGrant(ctx, subject, "summarization", "consent-1", nil); err != nil {
+		t.Fatalf("AttestConsentGrant() by an allowed attester error = %v", err)
+	}
+	if valid, consentID, err := client.WasConsentValidAt(ctx, subject, "summarization", chain.now(t)); err != nil || !valid || consentID != "consent-1" {
+		t.Errorf("WasConsentValidAt() = %v, %q, %v, want consent-1", valid, consentID, err)
+	}
+}
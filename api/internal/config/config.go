@@ -1,8 +1,13 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
@@ -16,12 +21,26 @@ type Config struct {
 	Environment string
 	LogLevel    string
 
-	// Database settings
+	// Metrics settings
+	MetricsEnabled bool
+	MetricsPort    string // Serve /metrics on a separate admin port when set
+
+	// Tracing settings
+	TracingEnabled     bool
+	ServiceName        string
+	OTLPEndpoint       string
+	OTLPInsecure       bool
+	TracingSampleRatio float64
+
+	// Database settings. Without a database, users, API keys, usage counters
+	// and the audit index are kept in memory and lost on restart.
+	DBEnabled  bool
 	DBHost     string
 	DBPort     string
 	DBUser     string
 	DBPassword string
 	DBName     string
+	DBSSLMode  string
 
 	// Service URLs
 	NLPServiceURL string
@@ -29,51 +48,966 @@ type Config struct {
 	// Blockchain settings
 	BlockchainNodeURL         string
 	BlockchainContractAddress string
+	ConsentRegistryAddress    string // ConsentRegistry contract; empty disables on-chain consent attestation
+	BlockchainPrivateKey      string // Key used to sign consent attestations
+	ConsentSubjectKey         string // Secret keying the subject hashes published to the consent registry
 
 	// JWT settings
 	JWTSecret      string
 	JWTExpiryHours int
 
 	// OpenAI settings
-	OpenAIAPIKey string
+	OpenAIAPIKey  string
+	OpenAIBaseURL string
+	OpenAIRegion  string // Region the OpenAI endpoint processes data in, for data residency rules
+
+	// Secrets settings. Secret values may be references such as
+	// file:///run/secrets/openai, env://OPENAI_KEY or vault://path#key.
+	VaultAddress           string
+	VaultToken             string
+	SecretsRefreshInterval time.Duration
+
+	// Health check settings
+	HealthCheckTimeout time.Duration
+
+	// Usage and billing settings
+	UserUsageLimits     UsageLimits
+	TeamUsageLimits     UsageLimits
+	UsageSoftLimitRatio float64
+
+	// Models, policies, tenants and teams
+	Models   map[string]ModelConfig
+	Policies PolicyConfig
+	Tenants  map[string]TenantConfig
+	Teams    map[string]TeamConfig
+
+	// Routing of models to provider deployments
+	Routing RoutingConfig
+
+	// Residency maps a data residency tag, such as "eu", to the deployment
+	// regions that tenants with the tag may be routed to
+	Residency map[string][]string
+
+	// Timeouts bound the stages of a request
+	Timeouts TimeoutsConfig
+
+	// Audit retention settings
+	Retention RetentionConfig
+
+	// secretRefs holds the unresolved secret values for periodic refresh
+	secretRefs secretRefs
 }
 
-// Load loads the configuration from environment variables
-func Load() (*Config, error) {
-	// Load .env file if it exists
-	godotenv.Load()
+// UsageLimits holds the hard token and cost quotas for a usage scope.
+// A zero value means the limit is disabled.
+type UsageLimits struct {
+	DailyTokens   int64   `yaml:"daily_tokens" toml:"daily_tokens"`
+	MonthlyTokens int64   `yaml:"monthly_tokens" toml:"monthly_tokens"`
+	DailyCost     float64 `yaml:"daily_cost" toml:"daily_cost"`
+	MonthlyCost   float64 `yaml:"monthly_cost" toml:"monthly_cost"`
+}
+
+// ModelPrice holds the USD price per 1K tokens for a model
+type ModelPrice struct {
+	PromptPer1K     float64 `yaml:"prompt_per_1k" toml:"prompt_per_1k"`
+	CompletionPer1K float64 `yaml:"completion_per_1k" toml:"completion_per_1k"`
+}
+
+// ModelConfig holds the settings for a model exposed by the gateway
+type ModelConfig struct {
+	Provider      string     `yaml:"provider" toml:"provider"`
+	ContextWindow int        `yaml:"context_window,omitempty" toml:"context_window,omitempty"`
+	Price         ModelPrice `yaml:"price" toml:"price"`
+}
+
+// PolicyConfig holds request policies enforced by the gateway
+type PolicyConfig struct {
+	// AllowedModels restricts the models callers may use. Empty allows all models.
+	AllowedModels []string `yaml:"allowed_models" toml:"allowed_models"`
+
+	// RolePermissions maps a role to the permissions it grants ("*" grants all).
+	// Unset uses DefaultRolePermissions.
+	RolePermissions map[string][]string `yaml:"role_permissions" toml:"role_permissions"`
+
+	// Anonymization controls how prompts are de-identified before forwarding
+	Anonymization AnonymizationPolicy `yaml:"anonymization" toml:"anonymization"`
+
+	// Consent controls whether data subject consent is required before forwarding
+	Consent ConsentPolicy `yaml:"consent" toml:"consent"`
+
+	// Output controls how sensitive data in model responses is handled
+	Output OutputPolicy `yaml:"output" toml:"output"`
+
+	// Guardrail controls prompt injection and jailbreak detection on prompts
+	Guardrail GuardrailPolicy `yaml:"guardrail" toml:"guardrail"`
+}
+
+// PolicyOverrides holds the policies a tenant sets over the global policies.
+// Each unset field keeps the global value, so a tenant that only restricts
+// models is still anonymized and checked like every other tenant.
+type PolicyOverrides struct {
+	// AllowedModels replaces the global list when set. An empty list allows
+	// all models.
+	AllowedModels []string `yaml:"allowed_models,omitempty" toml:"allowed_models,omitempty"`
+
+	// RolePermissions replaces the permissions of the roles it lists
+	RolePermissions map[string][]string `yaml:"role_permissions,omitempty" toml:"role_permissions,omitempty"`
+
+	Anonymization AnonymizationOverrides `yaml:"anonymization,omitempty" toml:"anonymization,omitempty"`
+	Consent       ConsentOverrides       `yaml:"consent,omitempty" toml:"consent,omitempty"`
+	Output        OutputOverrides        `yaml:"output,omitempty" toml:"output,omitempty"`
+	Guardrail     GuardrailOverrides     `yaml:"guardrail,omitempty" toml:"guardrail,omitempty"`
+}
+
+// AnonymizationOverrides holds a tenant's anonymization rules
+type AnonymizationOverrides struct {
+	Enabled *bool `yaml:"enabled,omitempty" toml:"enabled,omitempty"`
+
+	// OnPrem replaces the relaxation of the models it lists
+	OnPrem map[string]ModelAnonymization `yaml:"on_prem,omitempty" toml:"on_prem,omitempty"`
+}
+
+// ConsentOverrides holds a tenant's consent rules
+type ConsentOverrides struct {
+	Required *bool `yaml:"required,omitempty" toml:"required,omitempty"`
+}
+
+// OutputOverrides holds a tenant's output rules
+type OutputOverrides struct {
+	Action *string `yaml:"action,omitempty" toml:"action,omitempty"`
+}
+
+// GuardrailOverrides holds a tenant's guardrail rules
+type GuardrailOverrides struct {
+	Action    *string  `yaml:"action,omitempty" toml:"action,omitempty"`
+	Threshold *float64 `yaml:"threshold,omitempty" toml:"threshold,omitempty"`
+}
+
+// apply returns the global policies with the overrides applied. Maps are
+// copied so the global policies are never modified.
+func (o *PolicyOverrides) apply(global PolicyConfig) PolicyConfig {
+	policies := global
+	if o.AllowedModels != nil {
+		policies.AllowedModels = o.AllowedModels
+	}
+	if o.RolePermissions != nil {
+		policies.RolePermissions = make(map[string][]string, len(global.RolePermissions)+len(o.RolePermissions))
+		for role, permissions := range global.RolePermissions {
+			policies.RolePermissions[role] = permissions
+		}
+		for role, permissions := range o.RolePermissions {
+			policies.RolePermissions[role] = permissions
+		}
+	}
+	if o.Anonymization.Enabled != nil {
+		policies.Anonymization.Enabled = *o.Anonymization.Enabled
+	}
+	if o.Anonymization.OnPrem != nil {
+		policies.Anonymization.OnPrem = make(map[string]ModelAnonymization, len(global.Anonymization.OnPrem)+len(o.Anonymization.OnPrem))
+		for model, anonymization := range global.Anonymization.OnPrem {
+			policies.Anonymization.OnPrem[model] = anonymization
+		}
+		for model, anonymization := range o.Anonymization.OnPrem {
+			policies.Anonymization.OnPrem[model] = anonymization
+		}
+	}
+	if o.Consent.Required != nil {
+		policies.Consent.Required = *o.Consent.Required
+	}
+	if o.Output.Action != nil {
+		policies.Output.Action = *o.Output.Action
+	}
+	if o.Guardrail.Action != nil {
+		policies.Guardrail.Action = *o.Guardrail.Action
+	}
+	if o.Guardrail.Threshold != nil {
+		policies.Guardrail.Threshold = *o.Guardrail.Threshold
+	}
+	return policies
+}
+
+// AnonymizationPolicy holds the anonymization rules applied to prompts
+type AnonymizationPolicy struct {
+	// Enabled sends prompts through the anonymization service before forwarding
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+
+	// OnPrem relaxes anonymization for models, by name, when every deployment
+	// serving the model is on-prem
+	OnPrem map[string]ModelAnonymization `yaml:"on_prem,omitempty" toml:"on_prem,omitempty"`
+}
+
+// Anonymization modes
+const (
+	AnonymizationModeFull  = "full"
+	AnonymizationModeRelax = "relax"
+	AnonymizationModeSkip  = "skip"
+)
 
-	config := &Config{
+// ModelAnonymization holds how prompts for a model are anonymized
+type ModelAnonymization struct {
+	// Mode is "full" to mask every entity detected, "relax" to leave the
+	// entity types in KeepEntities in clear, or "skip" to forward prompts
+	// unchanged
+	Mode string `yaml:"mode" toml:"mode"`
+
+	// KeepEntities lists the entity types, such as PERSON or DATE, that
+	// relax mode does not mask
+	KeepEntities []string `yaml:"keep_entities,omitempty" toml:"keep_entities,omitempty"`
+}
+
+// ConsentPolicy holds the consent rules applied to LLM requests
+type ConsentPolicy struct {
+	// Required rejects requests without a subject_id and purpose that the
+	// subject has consented to. Requests that name a subject are always checked.
+	Required bool `yaml:"required" toml:"required"`
+}
+
+// Output policy actions
+const (
+	OutputActionOff    = "off"
+	OutputActionFlag   = "flag"
+	OutputActionRedact = "redact"
+	OutputActionBlock  = "block"
+)
+
+// OutputPolicy holds the rules applied to model responses before they are returned
+type OutputPolicy struct {
+	// Action is taken when a response contains sensitive data: "flag" returns
+	// it marked with a header, "redact" returns it anonymized and "block"
+	// rejects it. "off" or empty skips response scanning.
+	Action string `yaml:"action" toml:"action"`
+}
+
+// Enabled reports whether model responses are scanned
+func (p OutputPolicy) Enabled() bool {
+	return p.Action != "" && p.Action != OutputActionOff
+}
+
+// Guardrail policy actions
+const (
+	GuardrailActionOff   = "off"
+	GuardrailActionFlag  = "flag"
+	GuardrailActionBlock = "block"
+)
+
+// DefaultGuardrailThreshold is the injection score at which the guardrail acts
+// when no threshold is configured
+const DefaultGuardrailThreshold = 0.5
+
+// GuardrailPolicy holds the prompt injection rules applied to prompts
+type GuardrailPolicy struct {
+	// Action is taken when a prompt scores at or above the threshold: "flag"
+	// forwards it marked with a header and "block" rejects it. "off" or empty
+	// skips detection.
+	Action string `yaml:"action" toml:"action"`
+
+	// Threshold is the injection score between 0 and 1 at which the action is
+	// taken. Zero uses DefaultGuardrailThreshold.
+	Threshold float64 `yaml:"threshold" toml:"threshold"`
+}
+
+// Enabled reports whether prompts are checked for injection
+func (p GuardrailPolicy) Enabled() bool {
+	return p.Action != "" && p.Action != GuardrailActionOff
+}
+
+// EffectiveThreshold returns the configured threshold or the default
+func (p GuardrailPolicy) EffectiveThreshold() float64 {
+	if p.Threshold == 0 {
+		return DefaultGuardrailThreshold
+	}
+	return p.Threshold
+}
+
+// RoutingConfig holds how requests are routed to provider deployments, retried
+// and failed over
+type RoutingConfig struct {
+	// MaxAttempts is the number of attempts made on a deployment before
+	// failing over to the next one
+	MaxAttempts int
+
+	// BackoffInitial and BackoffMax bound the exponential backoff between
+	// attempts. The actual delay is jittered between zero and the bound.
+	BackoffInitial time.Duration
+	BackoffMax     time.Duration
+
+	// BreakerThreshold is the number of consecutive failures that opens a
+	// deployment's circuit breaker. Zero disables circuit breakers.
+	BreakerThreshold int
+
+	// BreakerCooldown is how long an open circuit breaker rejects requests
+	// before letting a trial request through
+	BreakerCooldown time.Duration
+
+	// Routes maps a logical model name to the deployments serving it. Models
+	// without a route are sent to OpenAI under their own name.
+	Routes map[string]ModelRoute
+}
+
+// TimeoutsConfig holds the deadlines of the stages of a request. A stage
+// also ends when the client disconnects.
+type TimeoutsConfig struct {
+	// Request bounds the whole request, including every stage below
+	Request time.Duration
+
+	// Anonymization bounds the calls to the anonymization service made for
+	// a request, both for prompts and for scanning responses
+	Anonymization time.Duration
+
+	// Provider bounds each attempt on a provider deployment, so a hanging
+	// deployment is retried or failed over
+	Provider time.Duration
+
+	// Audit bounds recording an interaction in the audit trail. Audit
+	// records are written even if the client has disconnected.
+	Audit time.Duration
+}
+
+// ModelRoute lists the provider deployments that serve a logical model
+type ModelRoute struct {
+	Deployments []DeploymentConfig `yaml:"deployments" toml:"deployments"`
+}
+
+// DeploymentConfig holds a provider deployment a logical model can be routed to
+type DeploymentConfig struct {
+	// Name identifies the deployment in metrics, audit records and circuit
+	// breakers. It must be unique across routes.
+	Name string `yaml:"name" toml:"name"`
+
+	// Provider is the provider API the deployment speaks
+	Provider string `yaml:"provider" toml:"provider"`
+
+	// Model is the provider's name for the model. For Azure OpenAI it is
+	// the name of the Azure deployment.
+	Model string `yaml:"model" toml:"model"`
+
+	// BaseURL and APIKey default to the tenant's OpenAI credentials when
+	// empty; Gemini and Cohere default to their public APIs. The API key may
+	// be a secret reference.
+	BaseURL string `yaml:"base_url,omitempty" toml:"base_url,omitempty"`
+	APIKey  string `yaml:"api_key,omitempty" toml:"api_key,omitempty"`
+
+	// APIVersion is the Azure OpenAI API version. Empty uses DefaultAzureAPIVersion.
+	APIVersion string `yaml:"api_version,omitempty" toml:"api_version,omitempty"`
+
+	// OnPrem marks a deployment running on infrastructure the organization
+	// operates, which may receive prompts with relaxed anonymization
+	OnPrem bool `yaml:"on_prem,omitempty" toml:"on_prem,omitempty"`
+
+	// Region is where the deployment processes data, such as "westeurope".
+	// It is matched against the data residency rules and recorded in audit
+	// records.
+	Region string `yaml:"region,omitempty" toml:"region,omitempty"`
+
+	// Priority orders deployments: lower priorities are tried first and
+	// higher ones only serve as fallbacks
+	Priority int `yaml:"priority" toml:"priority"`
+
+	// Weight balances load between deployments of the same priority. Zero
+	// counts as one.
+	Weight int `yaml:"weight" toml:"weight"`
+}
+
+// Supported deployment providers
+const (
+	ProviderOpenAI           = "openai"
+	ProviderAzure            = "azure"
+	ProviderOllama           = "ollama"
+	ProviderOpenAICompatible = "openai_compatible" // Self-hosted OpenAI-compatible servers such as vLLM and llama.cpp
+	ProviderGemini           = "gemini"
+	ProviderCohere           = "cohere"
+)
+
+// DefaultAzureAPIVersion is the Azure OpenAI API version used when a
+// deployment does not set one
+const DefaultAzureAPIVersion = "2024-06-01"
+
+// Base URLs used when a Gemini or Cohere deployment does not set one
+const (
+	DefaultGeminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+	DefaultCohereBaseURL = "https://api.cohere.com/v2"
+)
+
+// TenantConfig holds the settings of a tenant organization, such as a hospital
+// department or client organization, keyed by the tenant claim in tokens
+type TenantConfig struct {
+	Name string `yaml:"name" toml:"name"`
+
+	// Members lists the user IDs that belong to the tenant
+	Members []string `yaml:"members" toml:"members"`
+
+	// Limits holds the tenant-wide usage quotas
+	Limits UsageLimits `yaml:"limits" toml:"limits"`
+
+	// Policies overrides the global policies for the tenant. Policies the
+	// tenant does not set keep their global value.
+	Policies *PolicyOverrides `yaml:"policies,omitempty" toml:"policies,omitempty"`
+
+	// Providers holds tenant-scoped provider credentials. Empty values fall
+	// back to the global provider settings.
+	Providers TenantProviders `yaml:"providers" toml:"providers"`
+
+	// Retention overrides the global audit retention rules for the tenant when set
+	Retention *RetentionRules `yaml:"retention,omitempty" toml:"retention,omitempty"`
+
+	// Residency is the tenant's data residency tag, such as "eu". Requests
+	// are only routed to deployments in the regions the residency rules allow
+	// for the tag, and rejected when there are none.
+	Residency string `yaml:"residency,omitempty" toml:"residency,omitempty"`
+}
+
+// TeamConfig holds the settings of a team, keyed by the team claim in tokens.
+// The members of a team share the team usage quotas.
+type TeamConfig struct {
+	// Members lists the user IDs that belong to the team. A user belongs to
+	// at most one team.
+	Members []string `yaml:"members" toml:"members"`
+}
+
+// TenantProviders holds the provider credentials of a tenant
+type TenantProviders struct {
+	OpenAI ProviderCredentials `yaml:"openai" toml:"openai"`
+}
+
+// ProviderCredentials holds the credentials and endpoint for an LLM provider.
+// The API key may be a secret reference.
+type ProviderCredentials struct {
+	APIKey  string `yaml:"api_key" toml:"api_key"`
+	BaseURL string `yaml:"base_url" toml:"base_url"`
+	Region  string `yaml:"region,omitempty" toml:"region,omitempty"` // Region of BaseURL
+}
+
+// HasMember reports whether a user belongs to the tenant
+func (t TenantConfig) HasMember(userID string) bool {
+	for _, member := range t.Members {
+		if member == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// TeamOf returns the team a user belongs to, or "" if they belong to none
+func (c *Config) TeamOf(userID string) string {
+	for id, team := range c.Teams {
+		for _, member := range team.Members {
+			if member == userID {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// MultiTenant reports whether tenants are configured. When they are, every
+// authenticated request must carry a tenant claim.
+func (c *Config) MultiTenant() bool {
+	return len(c.Tenants) > 0
+}
+
+// IsMember reports whether a user belongs to a tenant. Without tenants every
+// user belongs to the single, unnamed tenant.
+func (c *Config) IsMember(tenantID string, userID string) bool {
+	if !c.MultiTenant() {
+		return tenantID == ""
+	}
+	tenant, ok := c.Tenants[tenantID]
+	return ok && tenant.HasMember(userID)
+}
+
+// PoliciesFor returns the policies that apply to a tenant: the global
+// policies with the tenant's overrides applied
+func (c *Config) PoliciesFor(tenantID string) PolicyConfig {
+	if tenant, ok := c.Tenants[tenantID]; ok && tenant.Policies != nil {
+		return tenant.Policies.apply(c.Policies)
+	}
+	return c.Policies
+}
+
+// OpenAICredentials returns the OpenAI API key, base URL and region to use for a tenant
+func (c *Config) OpenAICredentials(tenantID string) ProviderCredentials {
+	credentials := ProviderCredentials{
+		APIKey:  c.OpenAIAPIKey,
+		BaseURL: c.OpenAIBaseURL,
+		Region:  c.OpenAIRegion,
+	}
+	if tenant, ok := c.Tenants[tenantID]; ok {
+		if tenant.Providers.OpenAI.APIKey != "" {
+			credentials.APIKey = tenant.Providers.OpenAI.APIKey
+		}
+		if tenant.Providers.OpenAI.BaseURL != "" {
+			// A tenant endpoint may sit in another region than the global one
+			credentials.BaseURL = strings.TrimSuffix(tenant.Providers.OpenAI.BaseURL, "/")
+			credentials.Region = tenant.Providers.OpenAI.Region
+		}
+	}
+	return credentials
+}
+
+// DefaultDeployment names the deployment serving models that have no route
+const DefaultDeployment = "openai"
+
+// ErrResidencyViolation is returned when none of the deployments serving a
+// model is in a region the tenant's data may be processed in
+var ErrResidencyViolation = errors.New("no deployment serving the model is in the tenant's data residency regions")
+
+// DeploymentsFor returns the deployments serving a model for a tenant, with
+// empty credentials filled in from the tenant's provider settings. A model
+// without a route is served by OpenAI under its own name. Tenants with a
+// data residency tag only get the deployments in the regions it allows.
+func (c *Config) DeploymentsFor(model string, tenantID string) ([]DeploymentConfig, error) {
+	route, ok := c.Routing.Routes[model]
+	if !ok {
+		route = ModelRoute{Deployments: []DeploymentConfig{{
+			Name:     DefaultDeployment,
+			Provider: ProviderOpenAI,
+			Model:    model,
+		}}}
+	}
+
+	credentials := c.OpenAICredentials(tenantID)
+	regions, restricted := c.ResidencyRegions(tenantID)
+	deployments := make([]DeploymentConfig, 0, len(route.Deployments))
+	for _, deployment := range route.Deployments {
+		switch {
+		case deployment.Provider == ProviderOpenAI:
+			if deployment.APIKey == "" {
+				deployment.APIKey = credentials.APIKey
+			}
+			if deployment.BaseURL == "" {
+				deployment.BaseURL = credentials.BaseURL
+				if deployment.Region == "" {
+					deployment.Region = credentials.Region
+				}
+			}
+		case deployment.Provider == ProviderGemini && deployment.BaseURL == "":
+			deployment.BaseURL = DefaultGeminiBaseURL
+		case deployment.Provider == ProviderCohere && deployment.BaseURL == "":
+			deployment.BaseURL = DefaultCohereBaseURL
+		}
+		if restricted && !containsRegion(regions, deployment.Region) {
+			continue
+		}
+		deployment.BaseURL = strings.TrimSuffix(deployment.BaseURL, "/")
+		deployments = append(deployments, deployment)
+	}
+	if len(deployments) == 0 {
+		return nil, fmt.Errorf("model %s: %w", model, ErrResidencyViolation)
+	}
+	return deployments, nil
+}
+
+// AnonymizationFor returns how prompts for a model are anonymized for a
+// tenant. The on-prem relaxation of the tenant's policy only applies when
+// every deployment the request may be routed to is on-prem, so failover can
+// never send relaxed prompts off-premises.
+func (c *Config) AnonymizationFor(model string, tenantID string) ModelAnonymization {
+	policy := c.PoliciesFor(tenantID).Anonymization
+	if !policy.Enabled {
+		return ModelAnonymization{Mode: AnonymizationModeSkip}
+	}
+
+	relaxed, ok := policy.OnPrem[model]
+	if !ok || relaxed.Mode == AnonymizationModeFull {
+		return ModelAnonymization{Mode: AnonymizationModeFull}
+	}
+	deployments, err := c.DeploymentsFor(model, tenantID)
+	if err != nil {
+		return ModelAnonymization{Mode: AnonymizationModeFull}
+	}
+	for _, deployment := range deployments {
+		if !deployment.OnPrem {
+			return ModelAnonymization{Mode: AnonymizationModeFull}
+		}
+	}
+	return relaxed
+}
+
+// ResidencyRegions returns the regions a tenant's data may be processed in.
+// It reports false when the tenant has no data residency requirement.
+func (c *Config) ResidencyRegions(tenantID string) ([]string, bool) {
+	tenant, ok := c.Tenants[tenantID]
+	if !ok || tenant.Residency == "" {
+		return nil, false
+	}
+	return c.Residency[tenant.Residency], true
+}
+
+// containsRegion reports whether region is one of regions. Deployments
+// without a region are never in an allowed region.
+func containsRegion(regions []string, region string) bool {
+	if region == "" {
+		return false
+	}
+	for _, allowed := range regions {
+		if strings.EqualFold(allowed, region) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsModelAllowed reports whether the policy allows a model
+func (p PolicyConfig) IsModelAllowed(model string) bool {
+	if len(p.AllowedModels) == 0 {
+		return true
+	}
+	for _, allowed := range p.AllowedModels {
+		if allowed == model {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission reports whether the policy grants a permission to a role.
+// Roles the policy does not list are granted nothing.
+func (p PolicyConfig) HasPermission(role string, permission string) bool {
+	for _, granted := range p.RolePermissions[role] {
+		if granted == "*" || granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultRolePermissions returns the role permissions used when none are
+// configured: users may call the models and manage their own usage, audit
+// logs and consents, while data subject requests and retention are left to
+// administrators
+func DefaultRolePermissions() map[string][]string {
+	return map[string][]string{
+		"admin": {"*"},
+		"user": {
+			"llm:completion",
+			"llm:chat",
+			"llm:embeddings",
+			"usage:read",
+			"audit:read",
+			"consent:read",
+			"consent:write",
+		},
+	}
+}
+
+// Defaults returns the built-in configuration used before the config file and
+// environment variables are applied
+func Defaults() *Config {
+	return &Config{
 		// Server settings
-		Port:        getEnv("PORT", "8080"),
-		Environment: getEnv("APP_ENV", "development"),
-		LogLevel:    getEnv("LOG_LEVEL", "info"),
+		Port:        "8080",
+		Environment: "development",
+		LogLevel:    "info",
+
+		// Metrics settings
+		MetricsEnabled: true,
+
+		// Tracing settings
+		ServiceName:        "secura-api",
+		OTLPEndpoint:       "localhost:4318",
+		OTLPInsecure:       true,
+		TracingSampleRatio: 1.0,
 
 		// Database settings
-		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBPort:     getEnv("DB_PORT", "5432"),
-		DBUser:     getEnv("DB_USER", "secura"),
-		DBPassword: getEnv("DB_PASSWORD", "securapassword"),
-		DBName:     getEnv("DB_NAME", "secura"),
+		DBHost:     "localhost",
+		DBPort:     "5432",
+		DBUser:     "secura",
+		DBPassword: defaultDBPassword,
+		DBName:     "secura",
+		DBSSLMode:  "disable",
 
 		// Service URLs
-		NLPServiceURL: getEnv("NLP_SERVICE_URL", "http://localhost:8000"),
+		NLPServiceURL: "http://localhost:8000",
 
 		// Blockchain settings
-		BlockchainNodeURL:         getEnv("BLOCKCHAIN_NODE_URL", "http://localhost:8545"),
-		BlockchainContractAddress: getEnv("BLOCKCHAIN_CONTRACT_ADDRESS", "0x0000000000000000000000000000000000000000"),
+		BlockchainNodeURL:         "http://localhost:8545",
+		BlockchainContractAddress: zeroAddress,
 
 		// JWT settings
-		JWTSecret:      getEnv("JWT_SECRET", "your-secret-key"),
-		JWTExpiryHours: 24, // Default 24 hours
+		JWTSecret:      defaultJWTSecret,
+		JWTExpiryHours: 24,
 
 		// OpenAI settings
-		OpenAIAPIKey: getEnv("OPENAI_API_KEY", ""),
+		OpenAIBaseURL: "https://api.openai.com/v1",
+
+		// Secrets settings
+		SecretsRefreshInterval: 5 * time.Minute,
+
+		// Health check settings
+		HealthCheckTimeout: 2 * time.Second,
+
+		// Usage and billing settings
+		UsageSoftLimitRatio: 0.8,
+
+		// Models
+		Models: map[string]ModelConfig{
+			"gpt-4":         {Provider: "openai", Price: ModelPrice{PromptPer1K: 0.03, CompletionPer1K: 0.06}},
+			"gpt-4-32k":     {Provider: "openai", Price: ModelPrice{PromptPer1K: 0.06, CompletionPer1K: 0.12}},
+			"gpt-3.5-turbo": {Provider: "openai", Price: ModelPrice{PromptPer1K: 0.0015, CompletionPer1K: 0.002}},
+
+			"text-embedding-3-small": {Provider: "openai", Price: ModelPrice{PromptPer1K: 0.00002}},
+			"text-embedding-3-large": {Provider: "openai", Price: ModelPrice{PromptPer1K: 0.00013}},
+		},
+		Policies: PolicyConfig{
+			Anonymization: AnonymizationPolicy{Enabled: true},
+		},
+		Tenants: map[string]TenantConfig{},
+		Teams:   map[string]TeamConfig{},
+
+		// Routing settings
+		Routing: RoutingConfig{
+			MaxAttempts:      2,
+			BackoffInitial:   200 * time.Millisecond,
+			BackoffMax:       2 * time.Second,
+			BreakerThreshold: 5,
+			BreakerCooldown:  30 * time.Second,
+			Routes:           map[string]ModelRoute{},
+		},
+		Residency: map[string][]string{},
+		Timeouts: TimeoutsConfig{
+			Request:       2 * time.Minute,
+			Anonymization: 10 * time.Second,
+			Provider:      60 * time.Second,
+			Audit:         10 * time.Second,
+		},
+
+		// Audit retention settings
+		Retention: RetentionConfig{
+			Interval: 24 * time.Hour,
+		},
+	}
+}
+
+// Load loads the configuration. Built-in defaults are overridden by the config
+// file at path (YAML or TOML, or SECURA_CONFIG if path is empty), which is in
+// turn overridden by environment variables. The result is validated.
+func Load(path string) (*Config, error) {
+	config, err := read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve secret references from files, the environment or Vault
+	ctx, cancel := context.WithTimeout(context.Background(), secretsTimeout)
+	defer cancel()
+	if err := config.resolveSecrets(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
 
 	return config, nil
 }
 
+// reload loads the configuration like Load and returns current with the
+// reloadable sections taken from it. Only secret references that were added
+// or changed since current are resolved.
+func reload(path string, current *Config) (*Config, error) {
+	next, err := read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretsTimeout)
+	defer cancel()
+	if err := next.resolveReloadedSecrets(ctx, current); err != nil {
+		return nil, err
+	}
+
+	if err := next.Validate(); err != nil {
+		return nil, err
+	}
+
+	return current.withReloadable(next), nil
+}
+
+// read loads the defaults, the config file and the environment overrides
+// without resolving secret references
+func read(path string) (*Config, error) {
+	// Load .env file if it exists
+	godotenv.Load()
+
+	config := Defaults()
+
+	// Apply the config file if one is configured
+	if path == "" {
+		path = os.Getenv("SECURA_CONFIG")
+	}
+	if path != "" {
+		if err := loadFile(path, config); err != nil {
+			return nil, err
+		}
+	}
+
+	// Apply environment variable overrides
+	if err := applyEnv(config); err != nil {
+		return nil, err
+	}
+
+	// Set after the file is applied, so configured role permissions replace
+	// the defaults instead of being merged into them
+	if config.Policies.RolePermissions == nil {
+		config.Policies.RolePermissions = DefaultRolePermissions()
+	}
+
+	return config, nil
+}
+
+// applyEnv overrides configuration values with any environment variables that
+// are set. It fails if a variable is set to a value that cannot be parsed.
+func applyEnv(config *Config) error {
+	env := &envReader{}
+
+	// Server settings
+	config.Port = getEnv("PORT", config.Port)
+	config.Environment = getEnv("APP_ENV", config.Environment)
+	config.LogLevel = getEnv("LOG_LEVEL", config.LogLevel)
+
+	// Metrics settings
+	config.MetricsEnabled = env.getBool("METRICS_ENABLED", config.MetricsEnabled)
+	config.MetricsPort = getEnv("METRICS_PORT", config.MetricsPort)
+
+	// Tracing settings
+	config.TracingEnabled = env.getBool("TRACING_ENABLED", config.TracingEnabled)
+	config.ServiceName = getEnv("OTEL_SERVICE_NAME", config.ServiceName)
+	config.OTLPEndpoint = getEnv("TRACING_OTLP_ENDPOINT", config.OTLPEndpoint)
+	config.OTLPInsecure = env.getBool("TRACING_OTLP_INSECURE", config.OTLPInsecure)
+	config.TracingSampleRatio = env.getFloat("TRACING_SAMPLE_RATIO", config.TracingSampleRatio)
+
+	// Database settings
+	config.DBEnabled = env.getBool("DB_ENABLED", config.DBEnabled)
+	config.DBHost = getEnv("DB_HOST", config.DBHost)
+	config.DBPort = getEnv("DB_PORT", config.DBPort)
+	config.DBUser = getEnv("DB_USER", config.DBUser)
+	config.DBPassword = getEnv("DB_PASSWORD", config.DBPassword)
+	config.DBName = getEnv("DB_NAME", config.DBName)
+	config.DBSSLMode = getEnv("DB_SSLMODE", config.DBSSLMode)
+
+	// Service URLs
+	config.NLPServiceURL = getEnv("NLP_SERVICE_URL", config.NLPServiceURL)
+
+	// Blockchain settings
+	config.BlockchainNodeURL = getEnv("BLOCKCHAIN_NODE_URL", config.BlockchainNodeURL)
+	config.BlockchainContractAddress = getEnv("BLOCKCHAIN_CONTRACT_ADDRESS", config.BlockchainContractAddress)
+	config.ConsentRegistryAddress = getEnv("BLOCKCHAIN_CONSENT_REGISTRY_ADDRESS", config.ConsentRegistryAddress)
+	config.BlockchainPrivateKey = getEnv("BLOCKCHAIN_PRIVATE_KEY", config.BlockchainPrivateKey)
+	config.ConsentSubjectKey = getEnv("BLOCKCHAIN_SUBJECT_KEY", config.ConsentSubjectKey)
+
+	// JWT settings
+	config.JWTSecret = getEnv("JWT_SECRET", config.JWTSecret)
+	config.JWTExpiryHours = int(env.getInt64("JWT_EXPIRY_HOURS", int64(config.JWTExpiryHours)))
+
+	// OpenAI settings
+	config.OpenAIAPIKey = getEnv("OPENAI_API_KEY", config.OpenAIAPIKey)
+	config.OpenAIBaseURL = strings.TrimSuffix(getEnv("OPENAI_BASE_URL", config.OpenAIBaseURL), "/")
+	config.OpenAIRegion = getEnv("OPENAI_REGION", config.OpenAIRegion)
+
+	// Secrets settings
+	config.VaultAddress = getEnv("VAULT_ADDR", config.VaultAddress)
+	config.VaultToken = getEnv("VAULT_TOKEN", config.VaultToken)
+	config.SecretsRefreshInterval = env.getDuration("SECRETS_REFRESH_INTERVAL", config.SecretsRefreshInterval)
+
+	// Health check settings
+	config.HealthCheckTimeout = env.getDuration("HEALTH_CHECK_TIMEOUT", config.HealthCheckTimeout)
+
+	// Usage and billing settings
+	config.UserUsageLimits = UsageLimits{
+		DailyTokens:   env.getInt64("USAGE_USER_DAILY_TOKENS", config.UserUsageLimits.DailyTokens),
+		MonthlyTokens: env.getInt64("USAGE_USER_MONTHLY_TOKENS", config.UserUsageLimits.MonthlyTokens),
+		DailyCost:     env.getFloat("USAGE_USER_DAILY_COST", config.UserUsageLimits.DailyCost),
+		MonthlyCost:   env.getFloat("USAGE_USER_MONTHLY_COST", config.UserUsageLimits.MonthlyCost),
+	}
+	config.TeamUsageLimits = UsageLimits{
+		DailyTokens:   env.getInt64("USAGE_TEAM_DAILY_TOKENS", config.TeamUsageLimits.DailyTokens),
+		MonthlyTokens: env.getInt64("USAGE_TEAM_MONTHLY_TOKENS", config.TeamUsageLimits.MonthlyTokens),
+		DailyCost:     env.getFloat("USAGE_TEAM_DAILY_COST", config.TeamUsageLimits.DailyCost),
+		MonthlyCost:   env.getFloat("USAGE_TEAM_MONTHLY_COST", config.TeamUsageLimits.MonthlyCost),
+	}
+	config.UsageSoftLimitRatio = env.getFloat("USAGE_SOFT_LIMIT_RATIO", config.UsageSoftLimitRatio)
+
+	// Override model prices if a price table is configured
+	if raw := os.Getenv("MODEL_PRICES"); raw != "" {
+		prices, err := ParseModelPrices(raw)
+		if err != nil {
+			return err
+		}
+		for model, price := range prices {
+			modelConfig, ok := config.Models[model]
+			if !ok {
+				modelConfig.Provider = "openai"
+			}
+			modelConfig.Price = price
+			config.Models[model] = modelConfig
+		}
+	}
+
+	// Override the allowed models policy
+	if raw := os.Getenv("ALLOWED_MODELS"); raw != "" {
+		config.Policies.AllowedModels = splitList(raw)
+	}
+	config.Policies.Consent.Required = env.getBool("CONSENT_REQUIRED", config.Policies.Consent.Required)
+	config.Policies.Output.Action = getEnv("OUTPUT_POLICY_ACTION", config.Policies.Output.Action)
+	config.Policies.Guardrail.Action = getEnv("GUARDRAIL_ACTION", config.Policies.Guardrail.Action)
+	config.Policies.Guardrail.Threshold = env.getFloat("GUARDRAIL_THRESHOLD", config.Policies.Guardrail.Threshold)
+
+	// Routing settings
+	config.Routing.MaxAttempts = int(env.getInt64("ROUTING_MAX_ATTEMPTS", int64(config.Routing.MaxAttempts)))
+	config.Routing.BackoffInitial = env.getDuration("ROUTING_BACKOFF_INITIAL", config.Routing.BackoffInitial)
+	config.Routing.BackoffMax = env.getDuration("ROUTING_BACKOFF_MAX", config.Routing.BackoffMax)
+	config.Routing.BreakerThreshold = int(env.getInt64("ROUTING_BREAKER_THRESHOLD", int64(config.Routing.BreakerThreshold)))
+	config.Routing.BreakerCooldown = env.getDuration("ROUTING_BREAKER_COOLDOWN", config.Routing.BreakerCooldown)
+
+	// Timeout settings
+	config.Timeouts.Request = env.getDuration("TIMEOUT_REQUEST", config.Timeouts.Request)
+	config.Timeouts.Anonymization = env.getDuration("TIMEOUT_ANONYMIZATION", config.Timeouts.Anonymization)
+	config.Timeouts.Provider = env.getDuration("TIMEOUT_PROVIDER", config.Timeouts.Provider)
+	config.Timeouts.Audit = env.getDuration("TIMEOUT_AUDIT", config.Timeouts.Audit)
+
+	// Audit retention settings
+	config.Retention.Interval = env.getDuration("AUDIT_RETENTION_INTERVAL", config.Retention.Interval)
+	config.Retention.DryRun = env.getBool("AUDIT_RETENTION_DRY_RUN", config.Retention.DryRun)
+
+	return errors.Join(env.errs...)
+}
+
+// ParseModelPrices parses a price table in the form
+// "model=prompt/completion,model=prompt/completion" where prices are USD per 1K tokens
+func ParseModelPrices(raw string) (map[string]ModelPrice, error) {
+	prices := make(map[string]ModelPrice)
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		model, rates, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid model price entry %q: expected model=prompt/completion", entry)
+		}
+		promptRate, completionRate, ok := strings.Cut(rates, "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid model price entry %q: expected model=prompt/completion", entry)
+		}
+
+		prompt, err := strconv.ParseFloat(strings.TrimSpace(promptRate), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prompt price for model %s: %w", model, err)
+		}
+		completion, err := strconv.ParseFloat(strings.TrimSpace(completionRate), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid completion price for model %s: %w", model, err)
+		}
+
+		prices[strings.TrimSpace(model)] = ModelPrice{
+			PromptPer1K:     prompt,
+			CompletionPer1K: completion,
+		}
+	}
+	return prices, nil
+}
+
 // SetupLogger configures the global logger
 func SetupLogger(logLevel string) *zap.Logger {
 	level := zap.InfoLevel
@@ -122,3 +1056,88 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// envReader reads typed environment variables. Variables that are set but
+// malformed are collected as errors rather than replaced by the default, so a
+// typo cannot silently disable a setting such as a quota.
+type envReader struct {
+	errs []error
+}
+
+// lookup returns the value of a set, non-empty environment variable
+func (r *envReader) lookup(key string) (string, bool) {
+	value := os.Getenv(key)
+	return value, value != ""
+}
+
+// fail records a malformed environment variable
+func (r *envReader) fail(key string, value string, err error) {
+	r.errs = append(r.errs, fmt.Errorf("invalid %s %q: %w", key, value, errors.Unwrap(err)))
+}
+
+// getInt64 returns an integer environment variable or a default value
+func (r *envReader) getInt64(key string, defaultValue int64) int64 {
+	raw, ok := r.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		r.fail(key, raw, err)
+		return defaultValue
+	}
+	return value
+}
+
+// getFloat returns a float environment variable or a default value
+func (r *envReader) getFloat(key string, defaultValue float64) float64 {
+	raw, ok := r.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		r.fail(key, raw, err)
+		return defaultValue
+	}
+	return value
+}
+
+// getBool returns a boolean environment variable or a default value
+func (r *envReader) getBool(key string, defaultValue bool) bool {
+	raw, ok := r.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		r.fail(key, raw, err)
+		return defaultValue
+	}
+	return value
+}
+
+// getDuration returns a duration environment variable (e.g. "5s") or a default value
+func (r *envReader) getDuration(key string, defaultValue time.Duration) time.Duration {
+	raw, ok := r.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("invalid %s: %w", key, err))
+		return defaultValue
+	}
+	return value
+}
+
+// Helper function to split a comma-separated list
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
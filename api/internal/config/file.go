@@ -0,0 +1,419 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig is the nested layout of the YAML/TOML config file
+type fileConfig struct {
+	Server     serverSection           `yaml:"server" toml:"server"`
+	Metrics    metricsSection          `yaml:"metrics" toml:"metrics"`
+	Tracing    tracingSection          `yaml:"tracing" toml:"tracing"`
+	Database   databaseSection         `yaml:"database" toml:"database"`
+	Services   servicesSection         `yaml:"services" toml:"services"`
+	Blockchain blockchainSection       `yaml:"blockchain" toml:"blockchain"`
+	Auth       authSection             `yaml:"auth" toml:"auth"`
+	Providers  providersSection        `yaml:"providers" toml:"providers"`
+	Secrets    secretsSection          `yaml:"secrets" toml:"secrets"`
+	Models     map[string]ModelConfig  `yaml:"models" toml:"models"`
+	Policies   PolicyConfig            `yaml:"policies" toml:"policies"`
+	Limits     limitsSection           `yaml:"limits" toml:"limits"`
+	Tenants    map[string]TenantConfig `yaml:"tenants" toml:"tenants"`
+	Teams      map[string]TeamConfig   `yaml:"teams" toml:"teams"`
+	Routing    routingSection          `yaml:"routing" toml:"routing"`
+	Residency  map[string][]string     `yaml:"residency" toml:"residency"`
+	Timeouts   timeoutsSection         `yaml:"timeouts" toml:"timeouts"`
+	Health     healthSection           `yaml:"health" toml:"health"`
+	Retention  retentionSection        `yaml:"retention" toml:"retention"`
+}
+
+type serverSection struct {
+	Port        string `yaml:"port" toml:"port"`
+	Environment string `yaml:"environment" toml:"environment"`
+	LogLevel    string `yaml:"log_level" toml:"log_level"`
+}
+
+type metricsSection struct {
+	Enabled bool   `yaml:"enabled" toml:"enabled"`
+	Port    string `yaml:"port" toml:"port"`
+}
+
+type tracingSection struct {
+	Enabled      bool    `yaml:"enabled" toml:"enabled"`
+	ServiceName  string  `yaml:"service_name" toml:"service_name"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint" toml:"otlp_endpoint"`
+	OTLPInsecure bool    `yaml:"otlp_insecure" toml:"otlp_insecure"`
+	SampleRatio  float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+}
+
+type databaseSection struct {
+	Enabled  bool   `yaml:"enabled" toml:"enabled"`
+	Host     string `yaml:"host" toml:"host"`
+	Port     string `yaml:"port" toml:"port"`
+	User     string `yaml:"user" toml:"user"`
+	Password string `yaml:"password" toml:"password"`
+	Name     string `yaml:"name" toml:"name"`
+	SSLMode  string `yaml:"sslmode" toml:"sslmode"`
+}
+
+type servicesSection struct {
+	NLPURL string `yaml:"nlp_url" toml:"nlp_url"`
+}
+
+type blockchainSection struct {
+	NodeURL                string `yaml:"node_url" toml:"node_url"`
+	ContractAddress        string `yaml:"contract_address" toml:"contract_address"`
+	ConsentRegistryAddress string `yaml:"consent_registry_address" toml:"consent_registry_address"`
+	PrivateKey             string `yaml:"private_key" toml:"private_key"`
+	SubjectKey             string `yaml:"subject_key" toml:"subject_key"`
+}
+
+type authSection struct {
+	JWTSecret      string `yaml:"jwt_secret" toml:"jwt_secret"`
+	JWTExpiryHours int    `yaml:"jwt_expiry_hours" toml:"jwt_expiry_hours"`
+}
+
+type providersSection struct {
+	OpenAI openAIProviderSection `yaml:"openai" toml:"openai"`
+}
+
+type openAIProviderSection struct {
+	APIKey  string `yaml:"api_key" toml:"api_key"`
+	BaseURL string `yaml:"base_url" toml:"base_url"`
+	Region  string `yaml:"region" toml:"region"`
+}
+
+type secretsSection struct {
+	VaultAddress    string `yaml:"vault_address" toml:"vault_address"`
+	VaultToken      string `yaml:"vault_token" toml:"vault_token"`
+	RefreshInterval string `yaml:"refresh_interval" toml:"refresh_interval"`
+}
+
+type limitsSection struct {
+	User           UsageLimits `yaml:"user" toml:"user"`
+	Team           UsageLimits `yaml:"team" toml:"team"`
+	SoftLimitRatio float64     `yaml:"soft_limit_ratio" toml:"soft_limit_ratio"`
+}
+
+type routingSection struct {
+	MaxAttempts      int                   `yaml:"max_attempts" toml:"max_attempts"`
+	BackoffInitial   string                `yaml:"backoff_initial" toml:"backoff_initial"`
+	BackoffMax       string                `yaml:"backoff_max" toml:"backoff_max"`
+	BreakerThreshold int                   `yaml:"breaker_threshold" toml:"breaker_threshold"`
+	BreakerCooldown  string                `yaml:"breaker_cooldown" toml:"breaker_cooldown"`
+	Routes           map[string]ModelRoute `yaml:"routes" toml:"routes"`
+}
+
+type timeoutsSection struct {
+	Request       string `yaml:"request" toml:"request"`
+	Anonymization string `yaml:"anonymization" toml:"anonymization"`
+	Provider      string `yaml:"provider" toml:"provider"`
+	Audit         string `yaml:"audit" toml:"audit"`
+}
+
+type healthSection struct {
+	CheckTimeout string `yaml:"check_timeout" toml:"check_timeout"`
+}
+
+type retentionSection struct {
+	Interval       string          `yaml:"interval" toml:"interval"`
+	DryRun         bool            `yaml:"dry_run" toml:"dry_run"`
+	Minimum        RetentionPeriod `yaml:"minimum" toml:"minimum"`
+	RetentionRules `yaml:",inline" toml:",inline"`
+}
+
+// loadFile applies the YAML or TOML config file at path on top of config.
+// Unknown keys are rejected so typos do not silently fall back to defaults.
+func loadFile(path string, config *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// Decode on top of the current values so keys missing from the file keep them
+	file := newFileConfig(config)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&file); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&file); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unsupported config file format %q: use .yaml, .yml or .toml", filepath.Ext(path))
+	}
+
+	return file.applyTo(config)
+}
+
+// newFileConfig converts a Config to the nested file layout
+func newFileConfig(config *Config) fileConfig {
+	return fileConfig{
+		Server: serverSection{
+			Port:        config.Port,
+			Environment: config.Environment,
+			LogLevel:    config.LogLevel,
+		},
+		Metrics: metricsSection{
+			Enabled: config.MetricsEnabled,
+			Port:    config.MetricsPort,
+		},
+		Tracing: tracingSection{
+			Enabled:      config.TracingEnabled,
+			ServiceName:  config.ServiceName,
+			OTLPEndpoint: config.OTLPEndpoint,
+			OTLPInsecure: config.OTLPInsecure,
+			SampleRatio:  config.TracingSampleRatio,
+		},
+		Database: databaseSection{
+			Enabled:  config.DBEnabled,
+			Host:     config.DBHost,
+			Port:     config.DBPort,
+			User:     config.DBUser,
+			Password: config.DBPassword,
+			Name:     config.DBName,
+			SSLMode:  config.DBSSLMode,
+		},
+		Services: servicesSection{
+			NLPURL: config.NLPServiceURL,
+		},
+		Blockchain: blockchainSection{
+			NodeURL:                config.BlockchainNodeURL,
+			ContractAddress:        config.BlockchainContractAddress,
+			ConsentRegistryAddress: config.ConsentRegistryAddress,
+			PrivateKey:             config.BlockchainPrivateKey,
+			SubjectKey:             config.ConsentSubjectKey,
+		},
+		Auth: authSection{
+			JWTSecret:      config.JWTSecret,
+			JWTExpiryHours: config.JWTExpiryHours,
+		},
+		Providers: providersSection{
+			OpenAI: openAIProviderSection{
+				APIKey:  config.OpenAIAPIKey,
+				BaseURL: config.OpenAIBaseURL,
+				Region:  config.OpenAIRegion,
+			},
+		},
+		Secrets: secretsSection{
+			VaultAddress:    config.VaultAddress,
+			VaultToken:      config.VaultToken,
+			RefreshInterval: config.SecretsRefreshInterval.String(),
+		},
+		Models:   config.Models,
+		Policies: config.Policies,
+		Limits: limitsSection{
+			User:           config.UserUsageLimits,
+			Team:           config.TeamUsageLimits,
+			SoftLimitRatio: config.UsageSoftLimitRatio,
+		},
+		Tenants: config.Tenants,
+		Teams:   config.Teams,
+		Routing: routingSection{
+			MaxAttempts:      config.Routing.MaxAttempts,
+			BackoffInitial:   config.Routing.BackoffInitial.String(),
+			BackoffMax:       config.Routing.BackoffMax.String(),
+			BreakerThreshold: config.Routing.BreakerThreshold,
+			BreakerCooldown:  config.Routing.BreakerCooldown.String(),
+			Routes:           config.Routing.Routes,
+		},
+		Residency: config.Residency,
+		Timeouts: timeoutsSection{
+			Request:       config.Timeouts.Request.String(),
+			Anonymization: config.Timeouts.Anonymization.String(),
+			Provider:      config.Timeouts.Provider.String(),
+			Audit:         config.Timeouts.Audit.String(),
+		},
+		Health: healthSection{
+			CheckTimeout: config.HealthCheckTimeout.String(),
+		},
+		Retention: retentionSection{
+			Interval:       config.Retention.Interval.String(),
+			DryRun:         config.Retention.DryRun,
+			Minimum:        config.Retention.Minimum,
+			RetentionRules: config.Retention.Rules,
+		},
+	}
+}
+
+// applyTo copies the file values into config
+func (f fileConfig) applyTo(config *Config) error {
+	checkTimeout, err := time.ParseDuration(f.Health.CheckTimeout)
+	if err != nil {
+		return fmt.Errorf("invalid health.check_timeout: %w", err)
+	}
+	refreshInterval, err := time.ParseDuration(f.Secrets.RefreshInterval)
+	if err != nil {
+		return fmt.Errorf("invalid secrets.refresh_interval: %w", err)
+	}
+	retentionInterval, err := time.ParseDuration(f.Retention.Interval)
+	if err != nil {
+		return fmt.Errorf("invalid retention.interval: %w", err)
+	}
+	backoffInitial, err := time.ParseDuration(f.Routing.BackoffInitial)
+	if err != nil {
+		return fmt.Errorf("invalid routing.backoff_initial: %w", err)
+	}
+	backoffMax, err := time.ParseDuration(f.Routing.BackoffMax)
+	if err != nil {
+		return fmt.Errorf("invalid routing.backoff_max: %w", err)
+	}
+	breakerCooldown, err := time.ParseDuration(f.Routing.BreakerCooldown)
+	if err != nil {
+		return fmt.Errorf("invalid routing.breaker_cooldown: %w", err)
+	}
+	var timeouts TimeoutsConfig
+	for _, timeout := range []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{
+		{"request", f.Timeouts.Request, &timeouts.Request},
+		{"anonymization", f.Timeouts.Anonymization, &timeouts.Anonymization},
+		{"provider", f.Timeouts.Provider, &timeouts.Provider},
+		{"audit", f.Timeouts.Audit, &timeouts.Audit},
+	} {
+		if *timeout.dest, err = time.ParseDuration(timeout.value); err != nil {
+			return fmt.Errorf("invalid timeouts.%s: %w", timeout.name, err)
+		}
+	}
+
+	config.Port = f.Server.Port
+	config.Environment = f.Server.Environment
+	config.LogLevel = f.Server.LogLevel
+
+	config.MetricsEnabled = f.Metrics.Enabled
+	config.MetricsPort = f.Metrics.Port
+
+	config.TracingEnabled = f.Tracing.Enabled
+	config.ServiceName = f.Tracing.ServiceName
+	config.OTLPEndpoint = f.Tracing.OTLPEndpoint
+	config.OTLPInsecure = f.Tracing.OTLPInsecure
+	config.TracingSampleRatio = f.Tracing.SampleRatio
+
+	config.DBEnabled = f.Database.Enabled
+	config.DBHost = f.Database.Host
+	config.DBPort = f.Database.Port
+	config.DBUser = f.Database.User
+	config.DBPassword = f.Database.Password
+	config.DBName = f.Database.Name
+	config.DBSSLMode = f.Database.SSLMode
+
+	config.NLPServiceURL = f.Services.NLPURL
+
+	config.BlockchainNodeURL = f.Blockchain.NodeURL
+	config.BlockchainContractAddress = f.Blockchain.ContractAddress
+	config.ConsentRegistryAddress = f.Blockchain.ConsentRegistryAddress
+	config.BlockchainPrivateKey = f.Blockchain.PrivateKey
+	config.ConsentSubjectKey = f.Blockchain.SubjectKey
+
+	config.JWTSecret = f.Auth.JWTSecret
+	config.JWTExpiryHours = f.Auth.JWTExpiryHours
+
+	config.OpenAIAPIKey = f.Providers.OpenAI.APIKey
+	config.OpenAIBaseURL = strings.TrimSuffix(f.Providers.OpenAI.BaseURL, "/")
+	config.OpenAIRegion = f.Providers.OpenAI.Region
+
+	config.VaultAddress = f.Secrets.VaultAddress
+	config.VaultToken = f.Secrets.VaultToken
+	config.SecretsRefreshInterval = refreshInterval
+
+	config.HealthCheckTimeout = checkTimeout
+
+	config.UserUsageLimits = f.Limits.User
+	config.TeamUsageLimits = f.Limits.Team
+	config.UsageSoftLimitRatio = f.Limits.SoftLimitRatio
+
+	config.Models = f.Models
+	if config.Models == nil {
+		config.Models = map[string]ModelConfig{}
+	}
+	config.Policies = f.Policies
+	config.Tenants = f.Tenants
+	if config.Tenants == nil {
+		config.Tenants = map[string]TenantConfig{}
+	}
+	config.Teams = f.Teams
+	if config.Teams == nil {
+		config.Teams = map[string]TeamConfig{}
+	}
+
+	config.Routing = RoutingConfig{
+		MaxAttempts:      f.Routing.MaxAttempts,
+		BackoffInitial:   backoffInitial,
+		BackoffMax:       backoffMax,
+		BreakerThreshold: f.Routing.BreakerThreshold,
+		BreakerCooldown:  breakerCooldown,
+		Routes:           f.Routing.Routes,
+	}
+	if config.Routing.Routes == nil {
+		config.Routing.Routes = map[string]ModelRoute{}
+	}
+	config.Residency = f.Residency
+	if config.Residency == nil {
+		config.Residency = map[string][]string{}
+	}
+	config.Timeouts = timeouts
+
+	config.Retention = RetentionConfig{
+		Interval: retentionInterval,
+		DryRun:   f.Retention.DryRun,
+		Minimum:  f.Retention.Minimum,
+		Rules:    f.Retention.RetentionRules,
+	}
+
+	return nil
+}
+
+// Redacted returns a copy of the configuration with secrets masked
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	redacted.DBPassword = redact(c.DBPassword)
+	redacted.JWTSecret = redact(c.JWTSecret)
+	redacted.OpenAIAPIKey = redact(c.OpenAIAPIKey)
+	redacted.VaultToken = redact(c.VaultToken)
+	redacted.BlockchainPrivateKey = redact(c.BlockchainPrivateKey)
+	redacted.ConsentSubjectKey = redact(c.ConsentSubjectKey)
+	redacted.Tenants = make(map[string]TenantConfig, len(c.Tenants))
+	for id, tenant := range c.Tenants {
+		tenant.Providers.OpenAI.APIKey = redact(tenant.Providers.OpenAI.APIKey)
+		redacted.Tenants[id] = tenant
+	}
+	redacted.Routing.Routes = make(map[string]ModelRoute, len(c.Routing.Routes))
+	for model, route := range c.Routing.Routes {
+		deployments := make([]DeploymentConfig, len(route.Deployments))
+		for i, deployment := range route.Deployments {
+			deployment.APIKey = redact(deployment.APIKey)
+			deployments[i] = deployment
+		}
+		redacted.Routing.Routes[model] = ModelRoute{Deployments: deployments}
+	}
+	return &redacted
+}
+
+// MarshalYAML renders the configuration in the nested config file layout
+func (c *Config) MarshalYAML() (interface{}, error) {
+	return newFileConfig(c), nil
+}
+
+// redact masks a secret value, keeping empty values visible
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "********"
+}
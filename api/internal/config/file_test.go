@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestRedactedHidesResolvedSecrets(t *testing.T) {
+	secretValues := map[string]string{
+		"SECURA_TEST_JWT_SECRET":     "resolved-jwt-secret",
+		"SECURA_TEST_DB_PASSWORD":    "resolved-db-password",
+		"SECURA_TEST_OPENAI_KEY":     "resolved-openai-key",
+		"SECURA_TEST_PRIVATE_KEY":    "resolved-private-key",
+		"SECURA_TEST_SUBJECT_KEY":    "resolved-subject-key",
+		"SECURA_TEST_TENANT_KEY":     "resolved-tenant-key",
+		"SECURA_TEST_DEPLOYMENT_KEY": "resolved-deployment-key",
+	}
+	for name, value := range secretValues {
+		t.Setenv(name, value)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `database:
+  password: env://SECURA_TEST_DB_PASSWORD
+blockchain:
+  consent_registry_address: "0xe7f1725E7734CE288F8367e1Bb143E90bb3F0512"
+  private_key: env://SECURA_TEST_PRIVATE_KEY
+  subject_key: env://SECURA_TEST_SUBJECT_KEY
+auth:
+  jwt_secret: env://SECURA_TEST_JWT_SECRET
+providers:
+  openai:
+    api_key: env://SECURA_TEST_OPENAI_KEY
+tenants:
+  acme:
+    members: [user-123]
+    providers:
+      openai:
+        api_key: env://SECURA_TEST_TENANT_KEY
+routing:
+  routes:
+    test-model:
+      deployments:
+        - name: test-deployment
+          provider: openai_compatible
+          model: test
+          base_url: http://localhost:8000/v1
+          api_key: env://SECURA_TEST_DEPLOYMENT_KEY
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.JWTSecret != "resolved-jwt-secret" || cfg.Tenants["acme"].Providers.OpenAI.APIKey != "resolved-tenant-key" || deploymentKey(NewStore(cfg)) != "resolved-deployment-key" {
+		t.Fatal("Load() did not resolve the secret references")
+	}
+
+	redacted := cfg.Redacted()
+	out, err := yaml.Marshal(redacted)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	for name, value := range secretValues {
+		if strings.Contains(string(out), value) {
+			t.Errorf("redacted configuration contains the value of %s:\n%s", name, out)
+		}
+	}
+
+	// Redacting works on a copy
+	if cfg.JWTSecret != "resolved-jwt-secret" || cfg.Tenants["acme"].Providers.OpenAI.APIKey != "resolved-tenant-key" || deploymentKey(NewStore(cfg)) != "resolved-deployment-key" {
+		t.Error("Redacted() modified the configuration")
+	}
+}
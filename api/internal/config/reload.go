@@ -0,0 +1,113 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/secura/api/internal/metrics"
+)
+
+// Reloader reloads the reloadable parts of the configuration on SIGHUP and
+// when the config file changes on disk
+type Reloader struct {
+	path     string
+	store    *Store
+	logger   *zap.Logger
+	interval time.Duration
+	modTime  time.Time
+}
+
+// NewReloader creates a reloader for the config file at path. If path is
+// empty, SECURA_CONFIG is used; without a file only SIGHUP reloads apply,
+// re-reading environment overrides.
+func NewReloader(path string, store *Store, logger *zap.Logger, interval time.Duration) *Reloader {
+	if path == "" {
+		path = os.Getenv("SECURA_CONFIG")
+	}
+
+	reloader := &Reloader{
+		path:     path,
+		store:    store,
+		logger:   logger,
+		interval: interval,
+	}
+	if info, err := os.Stat(path); err == nil {
+		reloader.modTime = info.ModTime()
+	}
+	return reloader
+}
+
+// Watch reloads the configuration on SIGHUP or file change until ctx is done
+func (r *Reloader) Watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if r.path != "" && r.interval > 0 {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.logger.Info("Received SIGHUP, reloading configuration")
+			r.Reload()
+		case <-tick:
+			if r.fileChanged() {
+				r.logger.Info("Configuration file changed, reloading", zap.String("path", r.path))
+				r.Reload()
+			}
+		}
+	}
+}
+
+// Reload loads and validates the configuration and atomically swaps in its
+// reloadable sections. On failure the current configuration is kept.
+func (r *Reloader) Reload() error {
+	// Secrets are resolved under the store lock so unchanged references reuse
+	// the values of the snapshot being replaced, not an older one
+	var next *Config
+	var err error
+	r.store.update(func(current *Config) *Config {
+		if next, err = reload(r.path, current); err != nil {
+			return current
+		}
+		return next
+	})
+	if err != nil {
+		r.logger.Error("Failed to reload configuration, keeping current configuration", zap.Error(err))
+		metrics.ConfigReloadsTotal.WithLabelValues("failure").Inc()
+		return err
+	}
+
+	r.logger.Info("Configuration reloaded",
+		zap.Int("models", len(next.Models)),
+		zap.Int("tenants", len(next.Tenants)),
+	)
+	metrics.ConfigReloadsTotal.WithLabelValues("success").Inc()
+	metrics.ConfigLastReloadSuccess.SetToCurrentTime()
+	return nil
+}
+
+// fileChanged reports whether the config file modification time has changed
+func (r *Reloader) fileChanged() bool {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return false
+	}
+	if info.ModTime().Equal(r.modTime) {
+		return false
+	}
+	r.modTime = info.ModTime()
+	return true
+}
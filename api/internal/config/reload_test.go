@@ -0,0 +1,196 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// newVaultServer starts a stand-in for Vault serving the KV v2 secrets in
+// values, keyed by path, and counts the reads of each path
+func newVaultServer(t *testing.T, values map[string]string) (*httptest.Server, func(path string) int) {
+	t.Helper()
+	var mu sync.Mutex
+	reads := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v1/")
+		mu.Lock()
+		reads[path]++
+		mu.Unlock()
+		value, ok := values[path]
+		if !ok || r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"data": {"data": {"value": "` + value + `"}}}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, func(path string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return reads[path]
+	}
+}
+
+// writeFile writes a config file for the test
+func writeFile(t *testing.T, path string, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+}
+
+func TestReloadUpdatesReloadableSections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, `server:
+  port: "8080"
+limits:
+  user:
+    daily_tokens: 1000
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	store := NewStore(cfg)
+
+	writeFile(t, path, `server:
+  port: "9090"
+limits:
+  user:
+    daily_tokens: 2000
+tenants:
+  acme:
+    members: [user-123]
+`)
+	if err := NewReloader(path, store, zap.NewNop(), 0).Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	current := store.Current()
+	if current.UserUsageLimits.DailyTokens != 2000 {
+		t.Errorf("daily token limit = %d, want the reloaded 2000", current.UserUsageLimits.DailyTokens)
+	}
+	if _, ok := current.Tenants["acme"]; !ok {
+		t.Error("reloaded tenant acme is missing")
+	}
+	if current.Port != "8080" {
+		t.Errorf("port = %q, want 8080 kept until restart", current.Port)
+	}
+	// The previous snapshot is not modified
+	if cfg.UserUsageLimits.DailyTokens != 1000 || len(cfg.Tenants) != 0 {
+		t.Error("Reload() modified the previous snapshot")
+	}
+}
+
+func TestReloadFailureKeepsSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, `limits:
+  user:
+    daily_tokens: 1000
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	store := NewStore(cfg)
+	reloader := NewReloader(path, store, zap.NewNop(), 0)
+
+	for name, data := range map[string]string{
+		"invalid value":       "limits:\n  user:\n    daily_tokens: -1\n",
+		"unknown key":         "limits:\n  user:\n    daily_tokenz: 2000\n",
+		"malformed file":      "limits: [\n",
+		"unresolvable secret": "tenants:\n  acme:\n    members: [user-123]\n    providers:\n      openai:\n        api_key: env://SECURA_TEST_UNSET_KEY\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			writeFile(t, path, data)
+			if err := reloader.Reload(); err == nil {
+				t.Fatal("Reload() error = nil")
+			}
+			if store.Current() != cfg {
+				t.Error("failed Reload() replaced the configuration snapshot")
+			}
+		})
+	}
+}
+
+func TestReloadResolvesChangedSecretsOnly(t *testing.T) {
+	vault, reads := newVaultServer(t, map[string]string{
+		"secret/data/jwt":    strings.Repeat("j", minProductionSecretLength),
+		"secret/data/acme":   "acme-key",
+		"secret/data/first":  "first-key",
+		"secret/data/second": "second-key",
+	})
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	configFile := func(deploymentRef string, dailyTokens int) string {
+		return `auth:
+  jwt_secret: vault://secret/data/jwt#value
+secrets:
+  vault_address: ` + vault.URL + `
+  vault_token: test-token
+limits:
+  user:
+    daily_tokens: ` + strconv.Itoa(dailyTokens) + `
+tenants:
+  acme:
+    members: [user-123]
+    providers:
+      openai:
+        api_key: vault://secret/data/acme#value
+routing:
+  routes:
+    test-model:
+      deployments:
+        - name: test-deployment
+          provider: openai_compatible
+          model: test
+          base_url: http://localhost:8000/v1
+          api_key: vault://` + deploymentRef + `#value
+`
+	}
+
+	writeFile(t, path, configFile("secret/data/first", 1000))
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	store := NewStore(cfg)
+	reloader := NewReloader(path, store, zap.NewNop(), 0)
+
+	// Changing a section without secrets reads nothing from Vault
+	writeFile(t, path, configFile("secret/data/first", 2000))
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	for _, path := range []string{"secret/data/jwt", "secret/data/acme", "secret/data/first"} {
+		if got := reads(path); got != 1 {
+			t.Errorf("%s read %d times, want once at load", path, got)
+		}
+	}
+	current := store.Current()
+	if current.UserUsageLimits.DailyTokens != 2000 || current.Tenants["acme"].Providers.OpenAI.APIKey != "acme-key" || deploymentKey(store) != "first-key" {
+		t.Errorf("configuration after Reload() = %+v, want the new limit and the resolved keys kept", current)
+	}
+
+	// A changed reference is resolved, the others are not read again
+	writeFile(t, path, configFile("secret/data/second", 2000))
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if deploymentKey(store) != "second-key" {
+		t.Errorf("deployment key = %q, want second-key", deploymentKey(store))
+	}
+	if reads("secret/data/second") != 1 || reads("secret/data/first") != 1 || reads("secret/data/acme") != 1 || reads("secret/data/jwt") != 1 {
+		t.Errorf("Vault reads = jwt %d, acme %d, first %d, second %d, want one each", reads("secret/data/jwt"), reads("secret/data/acme"), reads("secret/data/first"), reads("secret/data/second"))
+	}
+	if store.Current().Tenants["acme"].Providers.OpenAI.APIKey != "acme-key" {
+		t.Error("unchanged tenant key was lost on reload")
+	}
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetentionPeriod is how long audit records are kept. In config files it is
+// written as a Go duration ("720h") or a number of days ("2190d"). Zero keeps
+// records forever.
+type RetentionPeriod time.Duration
+
+// UnmarshalText parses a retention period
+func (p *RetentionPeriod) UnmarshalText(text []byte) error {
+	raw := strings.TrimSpace(string(text))
+	if raw == "" || raw == "0" {
+		*p = 0
+		return nil
+	}
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return fmt.Errorf("invalid retention period %q", raw)
+		}
+		*p = RetentionPeriod(time.Duration(n) * 24 * time.Hour)
+		return nil
+	}
+	duration, err := time.ParseDuration(raw)
+	if err != nil {
+		return fmt.Errorf("invalid retention period %q", raw)
+	}
+	*p = RetentionPeriod(duration)
+	return nil
+}
+
+// MarshalText formats a retention period
+func (p RetentionPeriod) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// String formats a retention period, in days when it is a whole number of days
+func (p RetentionPeriod) String() string {
+	duration := time.Duration(p)
+	switch {
+	case duration == 0:
+		return "0"
+	case duration%(24*time.Hour) == 0:
+		return strconv.FormatInt(int64(duration/(24*time.Hour)), 10) + "d"
+	default:
+		return duration.String()
+	}
+}
+
+// RetentionRules sets how long audit records are kept, by action type
+type RetentionRules struct {
+	// Default applies to action types without a rule. Unset keeps records forever.
+	Default *RetentionPeriod `yaml:"default,omitempty" toml:"default,omitempty"`
+
+	// Actions maps an action type (e.g. "completion") to its retention period
+	Actions map[string]RetentionPeriod `yaml:"actions,omitempty" toml:"actions,omitempty"`
+}
+
+// RetentionConfig holds the audit retention rules and the purge job settings
+type RetentionConfig struct {
+	// Interval is how often the purge job runs. Zero disables the job.
+	Interval time.Duration
+
+	// DryRun makes scheduled runs report what they would purge without deleting anything
+	DryRun bool
+
+	// Minimum is the shortest retention any rule may set, e.g. six years for
+	// HIPAA documentation. Records younger than this are never purged.
+	Minimum RetentionPeriod
+
+	// Rules apply to every tenant unless the tenant overrides them
+	Rules RetentionRules
+}
+
+// RetentionFor returns how long a tenant's audit records of an action type are
+// kept, and false if they are kept forever. Tenant rules take precedence over
+// the global rules, and action rules over defaults.
+func (c *Config) RetentionFor(tenantID string, actionType string) (time.Duration, bool) {
+	if tenant, ok := c.Tenants[tenantID]; ok && tenant.Retention != nil {
+		if period, ok := tenant.Retention.Actions[actionType]; ok {
+			return time.Duration(period), period > 0
+		}
+		if tenant.Retention.Default != nil {
+			return time.Duration(*tenant.Retention.Default), *tenant.Retention.Default > 0
+		}
+	}
+	if period, ok := c.Retention.Rules.Actions[actionType]; ok {
+		return time.Duration(period), period > 0
+	}
+	if c.Retention.Rules.Default != nil {
+		return time.Duration(*c.Retention.Rules.Default), *c.Retention.Rules.Default > 0
+	}
+	return 0, false
+}
+
+// validateRetention checks that no retention rule is negative or shorter than the minimum
+func validateRetention(name string, rules *RetentionRules, minimum RetentionPeriod) []error {
+	if rules == nil {
+		return nil
+	}
+
+	var errs []error
+	check := func(field string, period RetentionPeriod) {
+		switch {
+		case period < 0:
+			errs = append(errs, fmt.Errorf("%s.%s must not be negative", name, field))
+		case period > 0 && period < minimum:
+			errs = append(errs, fmt.Errorf("%s.%s is shorter than the minimum retention of %s", name, field, minimum))
+		}
+	}
+	if rules.Default != nil {
+		check("default", *rules.Default)
+	}
+	for action, period := range rules.Actions {
+		check("actions."+action, period)
+	}
+	return errs
+}
@@ -0,0 +1,368 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/secura/api/internal/metrics"
+	"github.com/secura/api/internal/secrets"
+)
+
+// secretsTimeout bounds how long resolving all secrets may take
+const secretsTimeout = 10 * time.Second
+
+// secretRefs holds secret values as configured, before references are resolved
+type secretRefs struct {
+	DBPassword   string
+	JWTSecret    string
+	OpenAIAPIKey string
+
+	// BlockchainPrivateKey is read at startup only; rotating it requires a restart
+	BlockchainPrivateKey string
+
+	// ConsentSubjectKey is read at startup only. Rotating it unlinks subjects
+	// from the consents already attested under the previous key.
+	ConsentSubjectKey string
+
+	// TenantOpenAIAPIKeys holds the tenant-scoped OpenAI API keys by tenant ID
+	TenantOpenAIAPIKeys map[string]string
+
+	// DeploymentAPIKeys holds the API keys of routed deployments by deployment name
+	DeploymentAPIKeys map[string]string
+}
+
+// newSecretResolver creates a resolver for file://, env:// and, when a Vault
+// address is configured, vault:// references. The Vault token may itself be
+// a file:// or env:// reference so mounted tokens are re-read on refresh.
+func (c *Config) newSecretResolver(ctx context.Context) (*secrets.Resolver, error) {
+	resolver := secrets.NewResolver()
+	if c.VaultAddress == "" {
+		return resolver, nil
+	}
+
+	token, err := resolver.Resolve(ctx, c.VaultToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve vault token: %w", err)
+	}
+	resolver.Register("vault", secrets.NewVaultProvider(c.VaultAddress, token, &http.Client{Timeout: secretsTimeout}))
+	return resolver, nil
+}
+
+// resolveSecrets replaces secret references with their values and remembers
+// the references so they can be refreshed later
+func (c *Config) resolveSecrets(ctx context.Context) error {
+	c.secretRefs = secretRefs{
+		DBPassword:           c.DBPassword,
+		JWTSecret:            c.JWTSecret,
+		OpenAIAPIKey:         c.OpenAIAPIKey,
+		BlockchainPrivateKey: c.BlockchainPrivateKey,
+		ConsentSubjectKey:    c.ConsentSubjectKey,
+		TenantOpenAIAPIKeys:  c.tenantOpenAIAPIKeys(),
+		DeploymentAPIKeys:    c.deploymentAPIKeys(),
+	}
+
+	resolved, err := c.secretRefs.resolve(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	c.applySecrets(resolved)
+	return nil
+}
+
+// resolveReloadedSecrets resolves the secrets of c, a configuration reloaded
+// to replace the reloadable sections of current. References unchanged since
+// current keep the value current resolved, so Vault is only contacted for
+// secrets that were added or changed. Bootstrap secrets require a restart and
+// are taken from current, as is the Vault connection.
+func (c *Config) resolveReloadedSecrets(ctx context.Context, current *Config) error {
+	c.secretRefs = current.secretRefs
+	c.secretRefs.TenantOpenAIAPIKeys = c.tenantOpenAIAPIKeys()
+	c.secretRefs.DeploymentAPIKeys = c.deploymentAPIKeys()
+
+	resolved := secretRefs{
+		DBPassword:           current.DBPassword,
+		JWTSecret:            current.JWTSecret,
+		OpenAIAPIKey:         current.OpenAIAPIKey,
+		BlockchainPrivateKey: current.BlockchainPrivateKey,
+		ConsentSubjectKey:    current.ConsentSubjectKey,
+		TenantOpenAIAPIKeys:  make(map[string]string, len(c.secretRefs.TenantOpenAIAPIKeys)),
+		DeploymentAPIKeys:    make(map[string]string, len(c.secretRefs.DeploymentAPIKeys)),
+	}
+
+	// The resolver is created on first use so an unchanged reload makes no requests
+	var resolver *secrets.Resolver
+	resolve := func(ref string) (string, error) {
+		if resolver == nil {
+			var err error
+			if resolver, err = current.newSecretResolver(ctx); err != nil {
+				return "", err
+			}
+		}
+		return resolver.Resolve(ctx, ref)
+	}
+
+	currentTenantKeys := make(map[string]string, len(current.Tenants))
+	for id, tenant := range current.Tenants {
+		currentTenantKeys[id] = tenant.Providers.OpenAI.APIKey
+	}
+	for id, ref := range c.secretRefs.TenantOpenAIAPIKeys {
+		if current.secretRefs.TenantOpenAIAPIKeys[id] == ref {
+			resolved.TenantOpenAIAPIKeys[id] = currentTenantKeys[id]
+			continue
+		}
+		apiKey, err := resolve(ref)
+		if err != nil {
+			return fmt.Errorf("OpenAI API key for tenant %s: %w", id, err)
+		}
+		resolved.TenantOpenAIAPIKeys[id] = apiKey
+	}
+
+	currentDeploymentKeys := make(map[string]string)
+	for _, route := range current.Routing.Routes {
+		for _, deployment := range route.Deployments {
+			currentDeploymentKeys[deployment.Name] = deployment.APIKey
+		}
+	}
+	for name, ref := range c.secretRefs.DeploymentAPIKeys {
+		if current.secretRefs.DeploymentAPIKeys[name] == ref {
+			resolved.DeploymentAPIKeys[name] = currentDeploymentKeys[name]
+			continue
+		}
+		apiKey, err := resolve(ref)
+		if err != nil {
+			return fmt.Errorf("API key for deployment %s: %w", name, err)
+		}
+		resolved.DeploymentAPIKeys[name] = apiKey
+	}
+
+	c.applySecrets(resolved)
+	return nil
+}
+
+// tenantOpenAIAPIKeys returns the configured tenant OpenAI API keys by tenant ID
+func (c *Config) tenantOpenAIAPIKeys() map[string]string {
+	keys := make(map[string]string)
+	for id, tenant := range c.Tenants {
+		if tenant.Providers.OpenAI.APIKey != "" {
+			keys[id] = tenant.Providers.OpenAI.APIKey
+		}
+	}
+	return keys
+}
+
+// deploymentAPIKeys returns the configured deployment API keys by deployment name
+func (c *Config) deploymentAPIKeys() map[string]string {
+	keys := make(map[string]string)
+	for _, route := range c.Routing.Routes {
+		for _, deployment := range route.Deployments {
+			if deployment.APIKey != "" {
+				keys[deployment.Name] = deployment.APIKey
+			}
+		}
+	}
+	return keys
+}
+
+// applySecrets sets resolved secret values. Tenants are copied so snapshots
+// sharing the previous tenant map are not modified.
+func (c *Config) applySecrets(resolved secretRefs) {
+	c.DBPassword = resolved.DBPassword
+	c.JWTSecret = resolved.JWTSecret
+	c.OpenAIAPIKey = resolved.OpenAIAPIKey
+	c.BlockchainPrivateKey = resolved.BlockchainPrivateKey
+	c.ConsentSubjectKey = resolved.ConsentSubjectKey
+
+	tenants := make(map[string]TenantConfig, len(c.Tenants))
+	for id, tenant := range c.Tenants {
+		if apiKey, ok := resolved.TenantOpenAIAPIKeys[id]; ok {
+			tenant.Providers.OpenAI.APIKey = apiKey
+		}
+		tenants[id] = tenant
+	}
+	c.Tenants = tenants
+
+	routes := make(map[string]ModelRoute, len(c.Routing.Routes))
+	for model, route := range c.Routing.Routes {
+		deployments := make([]DeploymentConfig, len(route.Deployments))
+		for i, deployment := range route.Deployments {
+			if apiKey, ok := resolved.DeploymentAPIKeys[deployment.Name]; ok {
+				deployment.APIKey = apiKey
+			}
+			deployments[i] = deployment
+		}
+		routes[model] = ModelRoute{Deployments: deployments}
+	}
+	c.Routing.Routes = routes
+}
+
+// hasReferences reports whether any secret is loaded from a provider
+func (r secretRefs) hasReferences(resolver *secrets.Resolver) bool {
+	if resolver.IsReference(r.DBPassword) ||
+		resolver.IsReference(r.JWTSecret) ||
+		resolver.IsReference(r.OpenAIAPIKey) ||
+		resolver.IsReference(r.BlockchainPrivateKey) ||
+		resolver.IsReference(r.ConsentSubjectKey) {
+		return true
+	}
+	for _, apiKey := range r.TenantOpenAIAPIKeys {
+		if resolver.IsReference(apiKey) {
+			return true
+		}
+	}
+	for _, apiKey := range r.DeploymentAPIKeys {
+		if resolver.IsReference(apiKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the current values of the referenced secrets
+func (r secretRefs) resolve(ctx context.Context, c *Config) (secretRefs, error) {
+	resolver, err := c.newSecretResolver(ctx)
+	if err != nil {
+		return secretRefs{}, err
+	}
+
+	var resolved secretRefs
+	if resolved.DBPassword, err = resolver.Resolve(ctx, r.DBPassword); err != nil {
+		return secretRefs{}, fmt.Errorf("database password: %w", err)
+	}
+	if resolved.JWTSecret, err = resolver.Resolve(ctx, r.JWTSecret); err != nil {
+		return secretRefs{}, fmt.Errorf("JWT secret: %w", err)
+	}
+	if resolved.OpenAIAPIKey, err = resolver.Resolve(ctx, r.OpenAIAPIKey); err != nil {
+		return secretRefs{}, fmt.Errorf("OpenAI API key: %w", err)
+	}
+	if resolved.BlockchainPrivateKey, err = resolver.Resolve(ctx, r.BlockchainPrivateKey); err != nil {
+		return secretRefs{}, fmt.Errorf("blockchain private key: %w", err)
+	}
+	if resolved.ConsentSubjectKey, err = resolver.Resolve(ctx, r.ConsentSubjectKey); err != nil {
+		return secretRefs{}, fmt.Errorf("consent subject key: %w", err)
+	}
+	resolved.TenantOpenAIAPIKeys = make(map[string]string, len(r.TenantOpenAIAPIKeys))
+	for id, ref := range r.TenantOpenAIAPIKeys {
+		apiKey, err := resolver.Resolve(ctx, ref)
+		if err != nil {
+			return secretRefs{}, fmt.Errorf("OpenAI API key for tenant %s: %w", id, err)
+		}
+		resolved.TenantOpenAIAPIKeys[id] = apiKey
+	}
+	resolved.DeploymentAPIKeys = make(map[string]string, len(r.DeploymentAPIKeys))
+	for name, ref := range r.DeploymentAPIKeys {
+		apiKey, err := resolver.Resolve(ctx, ref)
+		if err != nil {
+			return secretRefs{}, fmt.Errorf("API key for deployment %s: %w", name, err)
+		}
+		resolved.DeploymentAPIKeys[name] = apiKey
+	}
+	return resolved, nil
+}
+
+// SecretRefresher periodically re-resolves secret references so rotated
+// secrets, such as provider API keys, are picked up without a restart
+type SecretRefresher struct {
+	store    *Store
+	logger   *zap.Logger
+	interval time.Duration
+}
+
+// NewSecretRefresher creates a refresher for the secrets in store
+func NewSecretRefresher(store *Store, logger *zap.Logger, interval time.Duration) *SecretRefresher {
+	return &SecretRefresher{
+		store:    store,
+		logger:   logger,
+		interval: interval,
+	}
+}
+
+// Watch refreshes secrets every interval until ctx is done. It returns
+// immediately if refresh is disabled. Ticks are skipped while no secret is
+// loaded from a provider, so references added by a reload are picked up, and
+// a failed refresh is retried on the next tick.
+func (r *SecretRefresher) Watch(ctx context.Context) {
+	if r.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if r.hasReferences(ctx) {
+				r.Refresh(ctx)
+			}
+		}
+	}
+}
+
+// hasReferences reports whether the current configuration loads any secret
+// from a provider. If the resolver cannot be created, for example because the
+// Vault token cannot be read, it reports true so the refresh reports the failure.
+func (r *SecretRefresher) hasReferences(ctx context.Context) bool {
+	current := r.store.Current()
+	resolver, err := current.newSecretResolver(ctx)
+	if err != nil {
+		return true
+	}
+	return current.secretRefs.hasReferences(resolver)
+}
+
+// Refresh re-resolves the secret references and swaps in any changed values.
+// On failure the current secrets are kept.
+func (r *SecretRefresher) Refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, secretsTimeout)
+	defer cancel()
+
+	current := r.store.Current()
+	resolved, err := current.secretRefs.resolve(ctx, current)
+	if err != nil {
+		r.logger.Error("Failed to refresh secrets, keeping current secrets", zap.Error(err))
+		metrics.SecretRefreshesTotal.WithLabelValues("failure").Inc()
+		return err
+	}
+	metrics.SecretRefreshesTotal.WithLabelValues("success").Inc()
+
+	r.store.update(func(current *Config) *Config {
+		var rotated []string
+		if resolved.DBPassword != current.DBPassword {
+			rotated = append(rotated, "database_password")
+		}
+		if resolved.JWTSecret != current.JWTSecret {
+			rotated = append(rotated, "jwt_secret")
+		}
+		if resolved.OpenAIAPIKey != current.OpenAIAPIKey {
+			rotated = append(rotated, "openai_api_key")
+		}
+		for id, apiKey := range resolved.TenantOpenAIAPIKeys {
+			if tenant, ok := current.Tenants[id]; ok && tenant.Providers.OpenAI.APIKey != apiKey {
+				rotated = append(rotated, "tenants."+id+".openai_api_key")
+			}
+		}
+		for _, route := range current.Routing.Routes {
+			for _, deployment := range route.Deployments {
+				if apiKey, ok := resolved.DeploymentAPIKeys[deployment.Name]; ok && deployment.APIKey != apiKey {
+					rotated = append(rotated, "routing.deployments."+deployment.Name+".api_key")
+				}
+			}
+		}
+		if len(rotated) == 0 {
+			return current
+		}
+
+		r.logger.Info("Secrets rotated", zap.Strings("secrets", rotated))
+		updated := *current
+		updated.applySecrets(resolved)
+		return &updated
+	})
+	return nil
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const testDeploymentKeyEnv = "SECURA_TEST_DEPLOYMENT_KEY"
+
+// writeConfigFile writes a config file routing a model to a deployment with
+// the given API key
+func writeConfigFile(t *testing.T, path string, apiKey string) {
+	t.Helper()
+	data := `routing:
+  routes:
+    test-model:
+      deployments:
+        - name: test-deployment
+          provider: openai_compatible
+          model: test
+          base_url: http://localhost:8000/v1
+          api_key: "` + apiKey + `"
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+}
+
+// deploymentKey returns the current API key of the test deployment
+func deploymentKey(store *Store) string {
+	for _, deployment := range store.Current().Routing.Routes["test-model"].Deployments {
+		if deployment.Name == "test-deployment" {
+			return deployment.APIKey
+		}
+	}
+	return ""
+}
+
+// waitForDeploymentKey waits until the test deployment has the expected API key
+func waitForDeploymentKey(t *testing.T, store *Store, expected string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if deploymentKey(store) == expected {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("deployment key = %q, want %q", deploymentKey(store), expected)
+}
+
+// watch starts a secret refresher with a short interval for the test
+func watch(t *testing.T, store *Store) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go NewSecretRefresher(store, zap.NewNop(), 10*time.Millisecond).Watch(ctx)
+}
+
+func TestSecretRefresherPicksUpReferencesAddedByReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, path, "literal-key")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	store := NewStore(cfg)
+	watch(t, store)
+	time.Sleep(30 * time.Millisecond)
+
+	// Reload with the key now loaded from the environment
+	t.Setenv(testDeploymentKeyEnv, "first")
+	writeConfigFile(t, path, "env://"+testDeploymentKeyEnv)
+	if err := NewReloader(path, store, zap.NewNop(), 0).Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	waitForDeploymentKey(t, store, "first")
+
+	// Rotate the key
+	t.Setenv(testDeploymentKeyEnv, "second")
+	waitForDeploymentKey(t, store, "second")
+}
+
+func TestSecretRefresherRetriesAfterFailure(t *testing.T) {
+	t.Setenv(testDeploymentKeyEnv, "first")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, path, "env://"+testDeploymentKeyEnv)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	store := NewStore(cfg)
+
+	// Refreshes fail while the secret cannot be resolved
+	os.Unsetenv(testDeploymentKeyEnv)
+	if err := NewSecretRefresher(store, zap.NewNop(), time.Minute).Refresh(context.Background()); err == nil {
+		t.Fatal("Refresh() error = nil, want an error for an unset variable")
+	}
+	watch(t, store)
+	time.Sleep(30 * time.Millisecond)
+	if key := deploymentKey(store); key != "first" {
+		t.Fatalf("deployment key after failed refresh = %q, want the current key kept", key)
+	}
+
+	// The refresher keeps ticking and picks up the secret once it resolves
+	t.Setenv(testDeploymentKeyEnv, "second")
+	waitForDeploymentKey(t, store, "second")
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Store holds the current configuration snapshot and allows it to be swapped
+// atomically on reload. Callers should read the snapshot once per request so
+// in-flight requests finish on the configuration they started with.
+type Store struct {
+	current atomic.Pointer[Config]
+	mu      sync.Mutex // serializes updates
+}
+
+// NewStore creates a store holding the initial configuration
+func NewStore(config *Config) *Store {
+	store := &Store{}
+	store.current.Store(config)
+	return store
+}
+
+// Current returns the current configuration snapshot. It must not be modified.
+func (s *Store) Current() *Config {
+	return s.current.Load()
+}
+
+// update replaces the current configuration snapshot with the result of fn.
+// Updates are serialized so concurrent reloads and secret refreshes do not
+// overwrite each other.
+func (s *Store) update(fn func(current *Config) *Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current.Store(fn(s.current.Load()))
+}
+
+// withReloadable returns a copy of c with the reloadable sections taken from
+// next. Bootstrap settings such as ports, secrets and service URLs require a
+// restart and are kept from c.
+func (c *Config) withReloadable(next *Config) *Config {
+	updated := *c
+	updated.Models = next.Models
+	updated.Policies = next.Policies
+	updated.Tenants = next.Tenants
+	updated.Teams = next.Teams
+	updated.secretRefs.TenantOpenAIAPIKeys = next.secretRefs.TenantOpenAIAPIKeys
+	updated.Routing = next.Routing
+	updated.Residency = next.Residency
+	updated.Timeouts = next.Timeouts
+	updated.secretRefs.DeploymentAPIKeys = next.secretRefs.DeploymentAPIKeys
+	updated.UserUsageLimits = next.UserUsageLimits
+	updated.TeamUsageLimits = next.TeamUsageLimits
+	updated.UsageSoftLimitRatio = next.UsageSoftLimitRatio
+	updated.Retention.DryRun = next.Retention.DryRun
+	updated.Retention.Minimum = next.Retention.Minimum
+	updated.Retention.Rules = next.Retention.Rules
+	return &updated
+}
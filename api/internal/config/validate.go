@@ -0,0 +1,312 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Insecure defaults that must never be used in production
+const (
+	defaultJWTSecret  = "your-secret-key"
+	defaultDBPassword = "securapassword"
+	zeroAddress       = "0x0000000000000000000000000000000000000000"
+)
+
+// minProductionSecretLength is the minimum JWT secret length in production
+const minProductionSecretLength = 32
+
+var addressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// IsProduction reports whether the application runs in production
+func (c *Config) IsProduction() bool {
+	return c.Environment == "production"
+}
+
+// Validate checks the configuration and returns all problems found. In
+// production it also refuses insecure defaults and placeholder values.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port <= 0 || port > 65535 {
+		errs = append(errs, fmt.Errorf("invalid port %q", c.Port))
+	}
+	if c.MetricsPort != "" {
+		if port, err := strconv.Atoi(c.MetricsPort); err != nil || port <= 0 || port > 65535 {
+			errs = append(errs, fmt.Errorf("invalid metrics port %q", c.MetricsPort))
+		}
+	}
+	switch strings.ToLower(c.LogLevel) {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("invalid log level %q", c.LogLevel))
+	}
+	switch c.Environment {
+	case "development", "staging", "production", "test":
+	default:
+		errs = append(errs, fmt.Errorf("invalid environment %q", c.Environment))
+	}
+	if c.JWTExpiryHours <= 0 {
+		errs = append(errs, fmt.Errorf("JWT expiry must be positive, got %d hours", c.JWTExpiryHours))
+	}
+	if c.BlockchainContractAddress != "" && !addressPattern.MatchString(c.BlockchainContractAddress) {
+		errs = append(errs, fmt.Errorf("invalid blockchain contract address %q", c.BlockchainContractAddress))
+	}
+	if c.ConsentRegistryAddress != "" && !addressPattern.MatchString(c.ConsentRegistryAddress) {
+		errs = append(errs, fmt.Errorf("invalid consent registry address %q", c.ConsentRegistryAddress))
+	}
+	if c.ConsentRegistryAddress != "" && c.ConsentSubjectKey == "" {
+		errs = append(errs, errors.New("consent registry requires a subject key to hash subject identifiers"))
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("tracing sample ratio must be between 0 and 1, got %g", c.TracingSampleRatio))
+	}
+	if c.UsageSoftLimitRatio < 0 || c.UsageSoftLimitRatio > 1 {
+		errs = append(errs, fmt.Errorf("usage soft limit ratio must be between 0 and 1, got %g", c.UsageSoftLimitRatio))
+	}
+	if c.SecretsRefreshInterval < 0 {
+		errs = append(errs, fmt.Errorf("secrets refresh interval must not be negative"))
+	}
+	if c.HealthCheckTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("health check timeout must be positive"))
+	}
+	if c.Retention.Interval < 0 {
+		errs = append(errs, fmt.Errorf("retention interval must not be negative"))
+	}
+	if c.Retention.Minimum < 0 {
+		errs = append(errs, fmt.Errorf("retention minimum must not be negative"))
+	}
+	errs = append(errs, validateRetention("retention", &c.Retention.Rules, c.Retention.Minimum)...)
+
+	errs = append(errs, validateAnonymizationPolicy("policies.anonymization", c.Policies.Anonymization)...)
+	errs = append(errs, validateOutputPolicy("policies.output", c.Policies.Output)...)
+	errs = append(errs, validateGuardrailPolicy("policies.guardrail", c.Policies.Guardrail)...)
+	errs = append(errs, validateLimits("limits.user", c.UserUsageLimits)...)
+	errs = append(errs, validateLimits("limits.team", c.TeamUsageLimits)...)
+	teams := make(map[string]string)
+	for id, team := range c.Teams {
+		if len(team.Members) == 0 {
+			errs = append(errs, fmt.Errorf("team %s has no members", id))
+		}
+		for _, member := range team.Members {
+			if other, ok := teams[member]; ok && other != id {
+				first, second := other, id
+				if second < first {
+					first, second = second, first
+				}
+				errs = append(errs, fmt.Errorf("user %s belongs to more than one team: %s and %s", member, first, second))
+			}
+			teams[member] = id
+		}
+	}
+	for id, tenant := range c.Tenants {
+		errs = append(errs, validateLimits("tenants."+id+".limits", tenant.Limits)...)
+		if len(tenant.Members) == 0 {
+			errs = append(errs, fmt.Errorf("tenant %s has no members", id))
+		}
+		errs = append(errs, validateRetention("tenants."+id+".retention", tenant.Retention, c.Retention.Minimum)...)
+		if _, ok := c.Residency[tenant.Residency]; tenant.Residency != "" && !ok {
+			errs = append(errs, fmt.Errorf("tenants.%s.residency %q has no residency rule", id, tenant.Residency))
+		}
+		if tenant.Policies != nil {
+			policies := c.PoliciesFor(id)
+			errs = append(errs, validateAnonymizationPolicy("tenants."+id+".policies.anonymization", policies.Anonymization)...)
+			errs = append(errs, validateOutputPolicy("tenants."+id+".policies.output", policies.Output)...)
+			errs = append(errs, validateGuardrailPolicy("tenants."+id+".policies.guardrail", policies.Guardrail)...)
+		}
+	}
+
+	errs = append(errs, validateRouting(c.Routing)...)
+	errs = append(errs, validateTimeouts(c.Timeouts)...)
+	for tag, regions := range c.Residency {
+		if len(regions) == 0 {
+			errs = append(errs, fmt.Errorf("residency.%s allows no regions", tag))
+		}
+	}
+
+	for name, model := range c.Models {
+		if model.Provider == "" {
+			errs = append(errs, fmt.Errorf("model %s has no provider", name))
+		}
+		if model.ContextWindow < 0 {
+			errs = append(errs, fmt.Errorf("model %s has a negative context window", name))
+		}
+		if model.Price.PromptPer1K < 0 || model.Price.CompletionPer1K < 0 {
+			errs = append(errs, fmt.Errorf("model %s has a negative price", name))
+		}
+	}
+
+	if c.IsProduction() {
+		errs = append(errs, c.validateProduction()...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateProduction refuses to run production with default secrets or placeholder values
+func (c *Config) validateProduction() []error {
+	var errs []error
+
+	if c.JWTSecret == defaultJWTSecret || len(c.JWTSecret) < minProductionSecretLength {
+		errs = append(errs, fmt.Errorf("JWT secret must be set to a random value of at least %d characters in production", minProductionSecretLength))
+	}
+	if !c.DBEnabled {
+		errs = append(errs, errors.New("database must be enabled in production"))
+	}
+	if c.DBPassword == defaultDBPassword || c.DBPassword == "" {
+		errs = append(errs, errors.New("database password must be changed from the default in production"))
+	}
+	if c.BlockchainNodeURL != "" && strings.EqualFold(c.BlockchainContractAddress, zeroAddress) {
+		errs = append(errs, errors.New("blockchain contract address must be set in production"))
+	}
+	if c.ConsentRegistryAddress != "" && c.BlockchainPrivateKey == "" {
+		errs = append(errs, errors.New("blockchain private key must be set to attest consent in production"))
+	}
+	if c.ConsentRegistryAddress != "" && len(c.ConsentSubjectKey) < minProductionSecretLength {
+		errs = append(errs, fmt.Errorf("consent subject key must be a random value of at least %d characters in production", minProductionSecretLength))
+	}
+	if c.OpenAIAPIKey == "" {
+		errs = append(errs, errors.New("OpenAI API key must be set in production"))
+	}
+	if !c.Policies.Anonymization.Enabled {
+		errs = append(errs, errors.New("anonymization must be enabled in production"))
+	}
+	for id := range c.Tenants {
+		if !c.PoliciesFor(id).Anonymization.Enabled {
+			errs = append(errs, fmt.Errorf("anonymization must be enabled for tenant %s in production", id))
+		}
+	}
+
+	return errs
+}
+
+// validateAnonymizationPolicy checks that the on-prem relaxations use a known mode
+func validateAnonymizationPolicy(name string, policy AnonymizationPolicy) []error {
+	var errs []error
+	for model, relaxed := range policy.OnPrem {
+		switch relaxed.Mode {
+		case AnonymizationModeFull, AnonymizationModeRelax, AnonymizationModeSkip:
+		default:
+			errs = append(errs, fmt.Errorf("%s.on_prem.%s.mode must be one of full, relax or skip, got %q", name, model, relaxed.Mode))
+		}
+		if relaxed.Mode != AnonymizationModeRelax && len(relaxed.KeepEntities) > 0 {
+			errs = append(errs, fmt.Errorf("%s.on_prem.%s.keep_entities only applies to relax mode", name, model))
+		}
+	}
+	return errs
+}
+
+// validateOutputPolicy checks that an output policy uses a known action
+func validateOutputPolicy(name string, policy OutputPolicy) []error {
+	switch policy.Action {
+	case "", OutputActionOff, OutputActionFlag, OutputActionRedact, OutputActionBlock:
+		return nil
+	default:
+		return []error{fmt.Errorf("%s.action must be one of off, flag, redact or block, got %q", name, policy.Action)}
+	}
+}
+
+// validateGuardrailPolicy checks that a guardrail policy uses a known action
+// and a threshold between 0 and 1
+func validateGuardrailPolicy(name string, policy GuardrailPolicy) []error {
+	var errs []error
+	switch policy.Action {
+	case "", GuardrailActionOff, GuardrailActionFlag, GuardrailActionBlock:
+	default:
+		errs = append(errs, fmt.Errorf("%s.action must be one of off, flag or block, got %q", name, policy.Action))
+	}
+	if policy.Threshold < 0 || policy.Threshold > 1 {
+		errs = append(errs, fmt.Errorf("%s.threshold must be between 0 and 1, got %g", name, policy.Threshold))
+	}
+	return errs
+}
+
+// validateTimeouts checks that every stage has a deadline and that a
+// provider attempt fits in the request deadline
+func validateTimeouts(timeouts TimeoutsConfig) []error {
+	var errs []error
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"request", timeouts.Request},
+		{"anonymization", timeouts.Anonymization},
+		{"provider", timeouts.Provider},
+		{"audit", timeouts.Audit},
+	} {
+		if timeout.value <= 0 {
+			errs = append(errs, fmt.Errorf("timeouts.%s must be positive, got %s", timeout.name, timeout.value))
+		}
+	}
+	if timeouts.Provider > timeouts.Request {
+		errs = append(errs, errors.New("timeouts.provider must not exceed timeouts.request"))
+	}
+	return errs
+}
+
+// validateRouting checks the retry and circuit breaker settings and that every
+// route has uniquely named deployments with a known provider
+func validateRouting(routing RoutingConfig) []error {
+	var errs []error
+	if routing.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("routing.max_attempts must be at least 1, got %d", routing.MaxAttempts))
+	}
+	if routing.BackoffInitial < 0 || routing.BackoffMax < routing.BackoffInitial {
+		errs = append(errs, errors.New("routing backoff must not be negative and backoff_max must not be below backoff_initial"))
+	}
+	if routing.BreakerThreshold < 0 {
+		errs = append(errs, fmt.Errorf("routing.breaker_threshold must not be negative, got %d", routing.BreakerThreshold))
+	}
+	if routing.BreakerThreshold > 0 && routing.BreakerCooldown <= 0 {
+		errs = append(errs, errors.New("routing.breaker_cooldown must be positive when circuit breakers are enabled"))
+	}
+
+	names := make(map[string]string)
+	for model, route := range routing.Routes {
+		if len(route.Deployments) == 0 {
+			errs = append(errs, fmt.Errorf("routing.routes.%s has no deployments", model))
+		}
+		for i, deployment := range route.Deployments {
+			name := fmt.Sprintf("routing.routes.%s.deployments[%d]", model, i)
+			if deployment.Name == "" {
+				errs = append(errs, fmt.Errorf("%s has no name", name))
+			} else if other, ok := names[deployment.Name]; ok {
+				errs = append(errs, fmt.Errorf("%s reuses deployment name %s of route %s", name, deployment.Name, other))
+			} else {
+				names[deployment.Name] = model
+			}
+			switch deployment.Provider {
+			case ProviderOpenAI, ProviderGemini, ProviderCohere:
+			case ProviderAzure:
+				// Azure deployments cannot fall back to the OpenAI endpoint
+				if deployment.BaseURL == "" {
+					errs = append(errs, fmt.Errorf("%s needs a base_url for Azure OpenAI", name))
+				}
+			case ProviderOllama, ProviderOpenAICompatible:
+				if deployment.BaseURL == "" {
+					errs = append(errs, fmt.Errorf("%s needs the base_url of the self-hosted server", name))
+				}
+			default:
+				errs = append(errs, fmt.Errorf("%s.provider must be openai, azure, ollama, openai_compatible, gemini or cohere, got %q", name, deployment.Provider))
+			}
+			if deployment.Model == "" {
+				errs = append(errs, fmt.Errorf("%s has no model", name))
+			}
+			if deployment.Weight < 0 {
+				errs = append(errs, fmt.Errorf("%s.weight must not be negative", name))
+			}
+		}
+	}
+	return errs
+}
+
+// validateLimits checks a set of usage limits for negative values
+func validateLimits(name string, limits UsageLimits) []error {
+	if limits.DailyTokens < 0 || limits.MonthlyTokens < 0 || limits.DailyCost < 0 || limits.MonthlyCost < 0 {
+		return []error{fmt.Errorf("%s must not be negative", name)}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// productionConfig returns a configuration that passes the production checks
+func productionConfig() *Config {
+	cfg := Defaults()
+	cfg.Environment = "production"
+	cfg.JWTSecret = strings.Repeat("j", minProductionSecretLength)
+	cfg.DBEnabled = true
+	cfg.DBPassword = "a-rotated-database-password"
+	cfg.BlockchainContractAddress = "0x5FbDB2315678afecb367f032d93F642f64180aa3"
+	cfg.OpenAIAPIKey = "sk-production"
+	return cfg
+}
+
+func TestValidateProduction(t *testing.T) {
+	if err := productionConfig().Validate(); err != nil {
+		t.Fatalf("Validate() of a production configuration error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(cfg *Config)
+		wantErr string
+	}{
+		{name: "default JWT secret", modify: func(cfg *Config) { cfg.JWTSecret = defaultJWTSecret }, wantErr: "JWT secret must be set"},
+		{name: "short JWT secret", modify: func(cfg *Config) { cfg.JWTSecret = strings.Repeat("j", minProductionSecretLength-1) }, wantErr: "JWT secret must be set"},
+		{name: "database disabled", modify: func(cfg *Config) { cfg.DBEnabled = false }, wantErr: "database must be enabled"},
+		{name: "default database password", modify: func(cfg *Config) { cfg.DBPassword = defaultDBPassword }, wantErr: "database password must be changed"},
+		{name: "empty database password", modify: func(cfg *Config) { cfg.DBPassword = "" }, wantErr: "database password must be changed"},
+		{name: "zero contract address", modify: func(cfg *Config) { cfg.BlockchainContractAddress = zeroAddress }, wantErr: "blockchain contract address must be set"},
+		{name: "missing OpenAI API key", modify: func(cfg *Config) { cfg.OpenAIAPIKey = "" }, wantErr: "OpenAI API key must be set"},
+		{name: "anonymization disabled", modify: func(cfg *Config) { cfg.Policies.Anonymization.Enabled = false }, wantErr: "anonymization must be enabled in production"},
+		{
+			name: "anonymization disabled for a tenant",
+			modify: func(cfg *Config) {
+				disabled := false
+				cfg.Tenants = map[string]TenantConfig{"acme": {Members: []string{"user-123"}, Policies: &PolicyOverrides{Anonymization: AnonymizationOverrides{Enabled: &disabled}}}}
+			},
+			wantErr: "anonymization must be enabled for tenant acme",
+		},
+		{
+			name: "consent registry without a private key",
+			modify: func(cfg *Config) {
+				cfg.ConsentRegistryAddress = "0xe7f1725E7734CE288F8367e1Bb143E90bb3F0512"
+				cfg.ConsentSubjectKey = strings.Repeat("s", minProductionSecretLength)
+			},
+			wantErr: "blockchain private key must be set",
+		},
+		{
+			name: "short consent subject key",
+			modify: func(cfg *Config) {
+				cfg.ConsentRegistryAddress = "0xe7f1725E7734CE288F8367e1Bb143E90bb3F0512"
+				cfg.BlockchainPrivateKey = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+				cfg.ConsentSubjectKey = strings.Repeat("s", minProductionSecretLength-1)
+			},
+			wantErr: "consent subject key must be a random value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := productionConfig()
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+
+			// The same configuration is accepted outside production
+			cfg.Environment = "staging"
+			if err := cfg.Validate(); err != nil {
+				t.Errorf("Validate() in staging error = %v", err)
+			}
+		})
+	}
+}
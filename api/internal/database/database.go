@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	// Registers the "postgres" driver
+	_ "github.com/lib/pq"
+
+	"github.com/secura/api/internal/config"
+)
+
+// Open connects to the PostgreSQL database configured in cfg and checks that
+// it is reachable. The schema is managed by the migrations in db/migrations.
+func Open(ctx context.Context, cfg *config.Config) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dataSourceName(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(10)
+	db.SetConnMaxIdleTime(5 * time.Minute)
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database %s: %w", net.JoinHostPort(cfg.DBHost, cfg.DBPort), err)
+	}
+	return db, nil
+}
+
+// dataSourceName returns the connection URL of the configured database
+func dataSourceName(cfg *config.Config) string {
+	dsn := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(cfg.DBUser, cfg.DBPassword),
+		Host:   net.JoinHostPort(cfg.DBHost, cfg.DBPort),
+		Path:   "/" + cfg.DBName,
+	}
+	if cfg.DBSSLMode != "" {
+		dsn.RawQuery = url.Values{"sslmode": {cfg.DBSSLMode}}.Encode()
+	}
+	return dsn.String()
+}
@@ -0,0 +1,178 @@
+package guardrails
+
+import (
+	"encoding/base64"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Signal categories
+const (
+	CategoryInstructionOverride = "instruction_override"
+	CategoryRoleSpoofing        = "role_spoofing"
+	CategoryExfiltration        = "exfiltration"
+	CategoryEncodedPayload      = "encoded_payload"
+	CategoryHiddenUnicode       = "hidden_unicode"
+)
+
+// Signal is a prompt injection indicator found in a text
+type Signal struct {
+	Rule     string  `json:"rule"`
+	Category string  `json:"category"`
+	Weight   float64 `json:"weight"`
+}
+
+// Verdict is the result of scanning text for prompt injection. Score is
+// between 0 and 1; each distinct rule that fires raises it independently.
+type Verdict struct {
+	Score   float64  `json:"score"`
+	Signals []Signal `json:"signals,omitempty"`
+}
+
+// Rules returns the names of the rules that fired
+func (v Verdict) Rules() []string {
+	rules := make([]string, len(v.Signals))
+	for i, signal := range v.Signals {
+		rules[i] = signal.Rule
+	}
+	return rules
+}
+
+// patternRule is a signal raised when a pattern matches
+type patternRule struct {
+	signal  Signal
+	pattern *regexp.Regexp
+}
+
+var patternRules = []patternRule{
+	// Attempts to replace the instructions the model was given
+	{Signal{"ignore_previous_instructions", CategoryInstructionOverride, 0.6},
+		regexp.MustCompile(`(?i)\b(ignore|disregard|forget|skip|override)\b.{0,30}\b(previous|prior|above|earlier|preceding|all|any|system|original)\b.{0,20}\b(instructions?|prompts?|rules|directions|guidelines|context)\b`)},
+	{Signal{"new_instructions", CategoryInstructionOverride, 0.3},
+		regexp.MustCompile(`(?i)(^|\n)\s*(new|updated|real|actual) (instructions?|task|rules)\s*:`)},
+	{Signal{"reveal_system_prompt", CategoryInstructionOverride, 0.5},
+		regexp.MustCompile(`(?i)\b(reveal|print|show|repeat|output|leak|display)\b.{0,20}\b(system|initial|hidden|secret|original)\s+(prompt|instructions|message)`)},
+	{Signal{"jailbreak_persona", CategoryInstructionOverride, 0.6},
+		regexp.MustCompile(`(?i)\b(do anything now|developer mode|jailbreak(ed)?|DAN mode|unrestricted mode|no (ethical|content) (guidelines|restrictions|filters))\b`)},
+	{Signal{"persona_switch", CategoryInstructionOverride, 0.3},
+		regexp.MustCompile(`(?i)\b(you are no longer|from now on,? you (are|will)|pretend (that )?you (are|have) no)\b`)},
+
+	// Text pretending to be a message from another role or a chat template boundary
+	{Signal{"chat_template_tokens", CategoryRoleSpoofing, 0.5},
+		regexp.MustCompile(`(?i)(<\|im_start\|>|<\|im_end\|>|<\|system\|>|<\|assistant\|>|<<SYS>>|\[/?INST\]|<\|endoftext\|>|</s>)`)},
+	{Signal{"role_prefix", CategoryRoleSpoofing, 0.4},
+		regexp.MustCompile(`(?im)^\s*(#{1,3}\s*)?(system|assistant|developer)\s*(message|prompt)?\s*:`)},
+
+	// Instructions to send data somewhere the caller can read it
+	{Signal{"send_data_to_url", CategoryExfiltration, 0.4},
+		regexp.MustCompile(`(?i)\b(send|post|upload|exfiltrate|forward|transmit|submit)\b.{0,60}\b(to|at|into)\s+(https?://|[\w.+-]+@[\w-]+\.)`)},
+	{Signal{"markdown_image_beacon", CategoryExfiltration, 0.4},
+		regexp.MustCompile(`!\[[^\]]*\]\(https?://[^)\s]+\?[^)\s]*=`)},
+}
+
+// base64Pattern matches long runs of base64 text
+var base64Pattern = regexp.MustCompile(`[A-Za-z0-9+/]{40,}={0,2}`)
+
+// hexEscapePattern matches runs of escaped bytes such as \x69\x67\x6e
+var hexEscapePattern = regexp.MustCompile(`(\\x[0-9a-fA-F]{2}){8,}`)
+
+// Detect scans text for prompt injection indicators
+func Detect(text string) Verdict {
+	fired := map[string]Signal{}
+	add := func(signal Signal) {
+		fired[signal.Rule] = signal
+	}
+
+	for _, rule := range patternRules {
+		if rule.pattern.MatchString(text) {
+			add(rule.signal)
+		}
+	}
+
+	// Encoded payloads hide instructions from pattern matching
+	for _, encoded := range base64Pattern.FindAllString(text, 10) {
+		decoded, ok := decodeBase64Text(encoded)
+		if !ok {
+			continue
+		}
+		add(Signal{"base64_text", CategoryEncodedPayload, 0.3})
+		for _, rule := range patternRules {
+			if rule.signal.Category == CategoryInstructionOverride && rule.pattern.MatchString(decoded) {
+				add(Signal{"encoded_instruction_override", CategoryEncodedPayload, 0.6})
+				break
+			}
+		}
+	}
+	if hexEscapePattern.MatchString(text) {
+		add(Signal{"hex_escaped_text", CategoryEncodedPayload, 0.3})
+	}
+
+	// Characters that are invisible to a human reviewer but read by the model
+	for _, r := range text {
+		switch {
+		case r >= 0xE0000 && r <= 0xE007F:
+			add(Signal{"unicode_tag_characters", CategoryHiddenUnicode, 0.6})
+		case r == 0x202A || r == 0x202B || r == 0x202D || r == 0x202E || (r >= 0x2066 && r <= 0x2069):
+			add(Signal{"bidi_override", CategoryHiddenUnicode, 0.4})
+		case (r >= 0x200B && r <= 0x200F) || (r >= 0x2060 && r <= 0x2064) || r == 0xFEFF:
+			add(Signal{"zero_width_characters", CategoryHiddenUnicode, 0.3})
+		}
+	}
+
+	return newVerdict(fired)
+}
+
+// Combine merges the verdicts of several texts, such as the messages of a chat
+func Combine(verdicts ...Verdict) Verdict {
+	fired := map[string]Signal{}
+	for _, verdict := range verdicts {
+		for _, signal := range verdict.Signals {
+			fired[signal.Rule] = signal
+		}
+	}
+	return newVerdict(fired)
+}
+
+// newVerdict scores a set of fired signals. Each signal is treated as
+// independent evidence: score = 1 - Π(1 - weight).
+func newVerdict(fired map[string]Signal) Verdict {
+	verdict := Verdict{}
+	clean := 1.0
+	for _, signal := range fired {
+		verdict.Signals = append(verdict.Signals, signal)
+		clean *= 1 - signal.Weight
+	}
+	sort.Slice(verdict.Signals, func(i, j int) bool {
+		return verdict.Signals[i].Rule < verdict.Signals[j].Rule
+	})
+	verdict.Score = 1 - clean
+	return verdict
+}
+
+// decodeBase64Text decodes base64 and reports whether the result is mostly
+// printable text rather than binary data
+func decodeBase64Text(encoded string) (string, bool) {
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		decoded, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(encoded, "="))
+		if err != nil {
+			return "", false
+		}
+	}
+	if !utf8.Valid(decoded) {
+		return "", false
+	}
+
+	text := string(decoded)
+	printable, total := 0, 0
+	for _, r := range text {
+		total++
+		if unicode.IsPrint(r) || unicode.IsSpace(r) {
+			printable++
+		}
+	}
+	return text, total > 0 && printable*10 >= total*9
+}
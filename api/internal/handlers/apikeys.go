@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/secura/api/internal/middlewares"
+	"github.com/secura/api/internal/services"
+	"github.com/secura/api/internal/storage"
+)
+
+// CreateAPIKeyRequest represents a request to issue an API key
+type CreateAPIKeyRequest struct {
+	Name          string `json:"name" binding:"required,max=64"`
+	ExpiresInDays int    `json:"expires_in_days,omitempty" binding:"min=0"` // 0 never expires
+}
+
+// CreateAPIKeyResponse carries a new API key. The key is only ever returned here.
+type CreateAPIKeyResponse struct {
+	Key string `json:"key"`
+	storage.APIKeyRecord
+}
+
+// CreateAPIKey returns a handler that issues an API key for the caller, scoped
+// to the caller's tenant
+func CreateAPIKey(apiKeyService *services.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req CreateAPIKeyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middlewares.RespondError(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		var expiresAt *time.Time
+		if req.ExpiresInDays > 0 {
+			at := time.Now().UTC().AddDate(0, 0, req.ExpiresInDays)
+			expiresAt = &at
+		}
+
+		key, record, err := apiKeyService.Create(c.Request.Context(), c.GetString("userID"), c.GetString("tenant"), req.Name, expiresAt)
+		if errors.Is(err, storage.ErrUserNotFound) {
+			middlewares.RespondError(c, http.StatusNotFound, "User not found")
+			return
+		}
+		if err != nil {
+			middlewares.RespondError(c, http.StatusInternalServerError, "Failed to create API key")
+			return
+		}
+
+		c.JSON(http.StatusCreated, CreateAPIKeyResponse{Key: key, APIKeyRecord: record})
+	}
+}
+
+// ListAPIKeys returns a handler that lists the caller's API keys in their tenant
+func ListAPIKeys(apiKeyService *services.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keys, err := apiKeyService.List(c.Request.Context(), c.GetString("userID"), c.GetString("tenant"))
+		if err != nil {
+			middlewares.RespondError(c, http.StatusInternalServerError, "Failed to list API keys")
+			return
+		}
+		if keys == nil {
+			keys = []storage.APIKeyRecord{}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"api_keys": keys,
+			"total":    len(keys),
+		})
+	}
+}
+
+// RevokeAPIKey returns a handler that revokes one of the caller's API keys
+func RevokeAPIKey(apiKeyService *services.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		record, err := apiKeyService.Revoke(c.Request.Context(), c.GetString("userID"), c.GetString("tenant"), c.Param("id"))
+		if errors.Is(err, storage.ErrAPIKeyNotFound) {
+			middlewares.RespondError(c, http.StatusNotFound, "API key not found")
+			return
+		}
+		if err != nil {
+			middlewares.RespondError(c, http.StatusInternalServerError, "Failed to revoke API key")
+			return
+		}
+
+		c.JSON(http.StatusOK, record)
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/secura/api/internal/storage"
+)
+
+// newAuditRouter serves the audit routes to a caller authenticated as userID in tenant
+func newAuditRouter(auditStore storage.AuditStore, tenant string, userID string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	group := router.Group("/audit", func(c *gin.Context) {
+		c.Set("userID", userID)
+		c.Set("tenant", tenant)
+		c.Next()
+	})
+	SetupAuditHandlers(group, auditStore)
+	return router
+}
+
+func TestAuditLogsTenantIsolation(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	auditStore := storage.NewMemoryAuditStore()
+
+	// The same user ID exists in both tenants
+	acme, err := auditStore.Add(ctx, storage.AuditEntry{Tenant: "acme", UserID: "user-123", ActionType: "llm_chat", Timestamp: now})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	globex, err := auditStore.Add(ctx, storage.AuditEntry{Tenant: "globex", UserID: "user-123", ActionType: "llm_completion", Timestamp: now})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	colleague, err := auditStore.Add(ctx, storage.AuditEntry{Tenant: "acme", UserID: "user-456", ActionType: "llm_chat", Timestamp: now})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	router := newAuditRouter(auditStore, "acme", "user-123")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit/logs", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /audit/logs status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var listed struct {
+		Logs  []storage.AuditEntry `json:"logs"`
+		Total int                  `json:"total"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if listed.Total != 1 || len(listed.Logs) != 1 || listed.Logs[0].ID != acme.ID || listed.Logs[0].Tenant != "acme" {
+		t.Errorf("GET /audit/logs = %+v, want only the caller's acme entry", listed)
+	}
+
+	tests := []struct {
+		name       string
+		id         string
+		wantStatus int
+	}{
+		{name: "own entry", id: acme.ID, wantStatus: http.StatusOK},
+		{name: "entry of another tenant", id: globex.ID, wantStatus: http.StatusNotFound},
+		{name: "entry of another user", id: colleague.ID, wantStatus: http.StatusNotFound},
+		{name: "unknown entry", id: "missing", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit/logs/"+tt.id, nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("GET /audit/logs/%s status = %d, want %d: %s", tt.id, w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+
+	// A tenant without entries for the user sees none, not another tenant's
+	w = httptest.NewRecorder()
+	newAuditRouter(auditStore, "initech", "user-123").ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit/logs", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /audit/logs status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if listed.Total != 0 || listed.Logs == nil || len(listed.Logs) != 0 {
+		t.Errorf("GET /audit/logs in another tenant = %s, want an empty list", w.Body.String())
+	}
+}
@@ -1,42 +1,72 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/middlewares"
 	"github.com/secura/api/internal/models"
+	"github.com/secura/api/internal/storage"
 )
 
 // LoginRequest represents the login request body
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	Tenant   string `json:"tenant,omitempty"`
 }
 
-// Login returns a handler for the login endpoint
-func Login(cfg *config.Config) gin.HandlerFunc {
+// Login returns a handler for the login endpoint. Users are added to the user
+// store on their first login.
+func Login(cfgStore *config.Store, userStore storage.UserStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		cfg := cfgStore.Current()
+
 		var req LoginRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Invalid request body",
-			})
+			middlewares.RespondError(c, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
 		// TODO: Replace with actual authentication logic
 		if req.Username != "admin" || req.Password != "password" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid credentials",
+			middlewares.RespondError(c, http.StatusUnauthorized, "Invalid credentials")
+			return
+		}
+
+		// Check tenant membership when tenants are configured
+		if cfg.MultiTenant() {
+			tenant, ok := cfg.Tenants[req.Tenant]
+			if req.Tenant == "" || !ok || !tenant.HasMember("user-123") {
+				middlewares.RespondError(c, http.StatusForbidden, "User is not a member of the tenant")
+				return
+			}
+		} else if req.Tenant != "" {
+			middlewares.RespondError(c, http.StatusForbidden, "Unknown tenant")
+			return
+		}
+
+		// Register the user on first login
+		now := time.Now()
+		_, err := userStore.Get(c.Request.Context(), "user-123")
+		if errors.Is(err, storage.ErrUserNotFound) {
+			err = userStore.Put(c.Request.Context(), storage.UserRecord{
+				ID:        "user-123",
+				Username:  req.Username,
+				Role:      "admin",
+				CreatedAt: now.UTC(),
 			})
+		}
+		if err != nil {
+			middlewares.RespondError(c, http.StatusInternalServerError, "Failed to log in")
 			return
 		}
 
 		// Create a new token
-		now := time.Now()
 		expirationTime := now.Add(time.Duration(cfg.JWTExpiryHours) * time.Hour)
 
 		claims := jwt.MapClaims{
@@ -46,13 +76,17 @@ func Login(cfg *config.Config) gin.HandlerFunc {
 			"iat":  now.Unix(),
 			"exp":  expirationTime.Unix(),
 		}
+		if req.Tenant != "" {
+			claims["tenant"] = req.Tenant
+		}
+		if team := cfg.TeamOf("user-123"); team != "" {
+			claims["team"] = team
+		}
 
 		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 		tokenString, err := token.SignedString([]byte(cfg.JWTSecret))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to generate token",
-			})
+			middlewares.RespondError(c, http.StatusInternalServerError, "Failed to generate token")
 			return
 		}
 
@@ -64,6 +98,7 @@ func Login(cfg *config.Config) gin.HandlerFunc {
 				ID:       "user-123",
 				Username: req.Username,
 				Role:     "admin",
+				Tenant:   req.Tenant,
 			},
 		})
 	}
@@ -0,0 +1,403 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/secura/api/internal/services"
+	"github.com/secura/api/internal/storage"
+)
+
+// Message represents a chat message
+type Message struct {
+	Role       string         `json:"role" binding:"required,oneof=system developer user assistant tool function"`
+	Content    MessageContent `json:"content"`
+	Name       string         `json:"name,omitempty"`
+	ToolCalls  []ToolCall     `json:"tool_calls,omitempty"`
+	ToolCallID string         `json:"tool_call_id,omitempty"` // Tool call a tool message answers
+
+	// FunctionCall is the legacy form of a single tool call
+	FunctionCall *FunctionCall `json:"function_call,omitempty"`
+}
+
+// MessageContent is the content of a chat message: a string, an array of
+// content parts or null
+type MessageContent struct {
+	Text  *string
+	Parts []ContentPart
+}
+
+// ContentPart is one part of multi-part message content
+type ContentPart struct {
+	Type       string      `json:"type"`
+	Text       string      `json:"text,omitempty"`
+	Refusal    string      `json:"refusal,omitempty"`
+	ImageURL   *ImageURL   `json:"image_url,omitempty"`
+	InputAudio *InputAudio `json:"input_audio,omitempty"`
+}
+
+// ImageURL references an image in a content part
+type ImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// InputAudio carries base64-encoded audio in a content part
+type InputAudio struct {
+	Data   string `json:"data"`
+	Format string `json:"format"`
+}
+
+// ToolCall is a call to a tool requested by the model
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall names a function and carries its arguments as a JSON document
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// Tool is a tool the model may call
+type Tool struct {
+	Type     string             `json:"type" binding:"required"`
+	Function FunctionDefinition `json:"function"`
+}
+
+// FunctionDefinition describes a function tool
+type FunctionDefinition struct {
+	Name        string          `json:"name" binding:"required"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+	Strict      *bool           `json:"strict,omitempty"`
+}
+
+// MarshalJSON encodes the content as a string, an array of parts or null
+func (c MessageContent) MarshalJSON() ([]byte, error) {
+	switch {
+	case c.Parts != nil:
+		return json.Marshal(c.Parts)
+	case c.Text != nil:
+		return json.Marshal(*c.Text)
+	default:
+		return []byte("null"), nil
+	}
+}
+
+// UnmarshalJSON decodes content given as a string, an array of parts or null
+func (c *MessageContent) UnmarshalJSON(data []byte) error {
+	*c = MessageContent{}
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		c.Text = &text
+		return nil
+	case len(data) > 0 && data[0] == '[':
+		c.Parts = []ContentPart{}
+		return json.Unmarshal(data, &c.Parts)
+	default:
+		return errors.New("content must be a string, an array of content parts or null")
+	}
+}
+
+// IsEmpty reports whether the content is null
+func (c MessageContent) IsEmpty() bool {
+	return c.Text == nil && c.Parts == nil
+}
+
+// validateMessages checks the rules of the chat schema that struct tags cannot express
+func validateMessages(messages []Message) error {
+	for i, msg := range messages {
+		switch msg.Role {
+		case "assistant":
+			if msg.Content.IsEmpty() && len(msg.ToolCalls) == 0 && msg.FunctionCall == nil {
+				return fmt.Errorf("messages[%d]: assistant messages need content or tool_calls", i)
+			}
+		case "tool":
+			if msg.ToolCallID == "" {
+				return fmt.Errorf("messages[%d]: tool messages need a tool_call_id", i)
+			}
+			fallthrough
+		default:
+			if msg.Content.IsEmpty() {
+				return fmt.Errorf("messages[%d]: content is required", i)
+			}
+		}
+		for j, part := range msg.Content.Parts {
+			if part.Type == "" {
+				return fmt.Errorf("messages[%d].content[%d]: type is required", i, j)
+			}
+		}
+		for j, call := range msg.ToolCalls {
+			if call.ID == "" || call.Function.Name == "" {
+				return fmt.Errorf("messages[%d].tool_calls[%d]: id and function name are required", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// textField is a text in a request that the gateway checks and anonymizes.
+// set writes the anonymized text back into the request.
+type textField struct {
+	role string
+	text string
+	set  func(string)
+}
+
+// messageFields returns every text of a list of messages: string content,
+// text parts, and the string values of tool call arguments. Roles, names,
+// IDs and other structural fields are left out.
+func messageFields(messages []Message) []textField {
+	var fields []textField
+	for i := range messages {
+		msg := &messages[i]
+
+		if msg.Content.Text != nil {
+			fields = append(fields, textField{role: msg.Role, text: *msg.Content.Text, set: func(text string) {
+				msg.Content.Text = &text
+			}})
+		}
+		for j := range msg.Content.Parts {
+			part := &msg.Content.Parts[j]
+			switch part.Type {
+			case "text":
+				fields = append(fields, textField{role: msg.Role, text: part.Text, set: func(text string) {
+					part.Text = text
+				}})
+			case "refusal":
+				fields = append(fields, textField{role: msg.Role, text: part.Refusal, set: func(text string) {
+					part.Refusal = text
+				}})
+			}
+		}
+
+		for j := range msg.ToolCalls {
+			function := &msg.ToolCalls[j].Function
+			fields = append(fields, argumentFields(msg.Role, function.Arguments, func(arguments string) {
+				function.Arguments = arguments
+			})...)
+		}
+		if msg.FunctionCall != nil {
+			function := msg.FunctionCall
+			fields = append(fields, argumentFields(msg.Role, function.Arguments, func(arguments string) {
+				function.Arguments = arguments
+			})...)
+		}
+	}
+	return fields
+}
+
+// chatTextFields returns the texts of messages decoded into a generic JSON
+// body: string content, text parts and tool call arguments. Image and audio
+// parts and other structure are left as they are.
+func chatTextFields(messages []interface{}) ([]textField, error) {
+	var fields []textField
+	for _, raw := range messages {
+		message, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, errInvalidMessages
+		}
+		role, _ := message["role"].(string)
+		if role == "" {
+			return nil, errInvalidMessages
+		}
+
+		switch content := message["content"].(type) {
+		case string:
+			fields = append(fields, textField{role: role, text: content, set: func(text string) {
+				message["content"] = text
+			}})
+		case []interface{}:
+			for _, rawPart := range content {
+				part, ok := rawPart.(map[string]interface{})
+				if !ok {
+					return nil, errInvalidMessages
+				}
+				partType, _ := part["type"].(string)
+				if partType != "text" && partType != "refusal" {
+					continue
+				}
+				text, _ := part[partType].(string)
+				fields = append(fields, textField{role: role, text: text, set: func(text string) {
+					part[partType] = text
+				}})
+			}
+		case nil:
+			// Assistant messages that only carry tool calls have no content
+		default:
+			return nil, errInvalidMessages
+		}
+
+		// Tool call arguments may repeat personal data from earlier messages
+		var functions []map[string]interface{}
+		calls, _ := message["tool_calls"].([]interface{})
+		for _, rawCall := range calls {
+			call, _ := rawCall.(map[string]interface{})
+			if function, ok := call["function"].(map[string]interface{}); ok {
+				functions = append(functions, function)
+			}
+		}
+		if function, ok := message["function_call"].(map[string]interface{}); ok {
+			functions = append(functions, function)
+		}
+		for _, function := range functions {
+			function := function
+			if arguments, ok := function["arguments"].(string); ok {
+				fields = append(fields, argumentFields(role, arguments, func(arguments string) {
+					function["arguments"] = arguments
+				})...)
+			}
+		}
+	}
+	return fields, nil
+}
+
+// errInvalidMessages is returned for messages that do not follow the chat schema
+var errInvalidMessages = errors.New("messages must be objects with a role and string or array content")
+
+// argumentFields returns the string values of a JSON arguments document as
+// text fields, leaving keys, numbers and structure intact. Setting a field
+// re-encodes the document and passes it to set. Arguments that are not valid
+// JSON are returned as a single text field.
+func argumentFields(role string, arguments string, set func(string)) []textField {
+	if strings.TrimSpace(arguments) == "" {
+		return nil
+	}
+
+	var doc interface{}
+	decoder := json.NewDecoder(strings.NewReader(arguments))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return []textField{{role: role, text: arguments, set: set}}
+	}
+
+	var fields []textField
+	encode := func() {
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(doc); err == nil {
+			set(strings.TrimSuffix(buf.String(), "\n"))
+		}
+	}
+
+	var walk func(value interface{}, assign func(interface{}))
+	walk = func(value interface{}, assign func(interface{})) {
+		switch v := value.(type) {
+		case string:
+			fields = append(fields, textField{role: role, text: v, set: func(text string) {
+				assign(text)
+				encode()
+			}})
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				key := key
+				walk(v[key], func(item interface{}) { v[key] = item })
+			}
+		case []interface{}:
+			for i := range v {
+				i := i
+				walk(v[i], func(item interface{}) { v[i] = item })
+			}
+		}
+	}
+	walk(doc, func(item interface{}) { doc = item })
+	return fields
+}
+
+// anonymizeConcurrency bounds the anonymization calls made in parallel for one request
+const anonymizeConcurrency = 8
+
+// anonymizeFields anonymizes text fields in parallel and stores the personal
+// data detected in the data subject's pseudonym vault. Results are applied in
+// order once all calls are done, since setters may share state such as a tool
+// call arguments document. Entity types in keep are left in clear.
+func anonymizeFields(ctx context.Context, anonService *services.AnonymizationService, vault storage.PseudonymVault, tenant string, subjectID string, fields []textField, keep []string) error {
+	type result struct {
+		text     string
+		entities []services.DetectedEntity
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]result, len(fields))
+	slots := make(chan struct{}, anonymizeConcurrency)
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i, field := range fields {
+		if field.text == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, text string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			anonymized, entities, err := anonService.AnonymizeExcept(ctx, text, keep)
+			if err != nil {
+				// Stop the remaining calls, the request fails anyway
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			results[i] = result{text: anonymized, entities: entities}
+		}(i, field.text)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return fmt.Errorf("failed to anonymize text: %w", firstErr)
+	}
+
+	for i, field := range fields {
+		if field.text == "" {
+			continue
+		}
+		if err := storePseudonyms(ctx, vault, tenant, subjectID, results[i].entities); err != nil {
+			return fmt.Errorf("failed to store pseudonyms: %w", err)
+		}
+		field.set(results[i].text)
+		fields[i].text = results[i].text
+	}
+	return nil
+}
+
+// guardedTexts returns the texts checked by the prompt injection guardrail.
+// System messages are written by the calling application rather than
+// supplied by its users, so they are not checked.
+func guardedTexts(fields []textField) []string {
+	texts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field.role != "system" && field.role != "developer" {
+			texts = append(texts, field.text)
+		}
+	}
+	return texts
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/secura/api/internal/blockchain"
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/middlewares"
+	"github.com/secura/api/internal/services"
+	"github.com/secura/api/internal/storage"
+)
+
+// GrantConsentRequest represents a request to record a data subject's consent
+type GrantConsentRequest struct {
+	SubjectID string     `json:"subject_id" binding:"required"`
+	Purpose   string     `json:"purpose" binding:"required"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// WithdrawConsentRequest represents a request to withdraw a data subject's consent
+type WithdrawConsentRequest struct {
+	SubjectID string `json:"subject_id" binding:"required"`
+	Purpose   string `json:"purpose" binding:"required"`
+}
+
+// GrantConsent returns a handler that records a data subject's consent for a purpose
+func GrantConsent(consentService *services.ConsentService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req GrantConsentRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middlewares.RespondError(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		record, err := consentService.Grant(c.Request.Context(), c.GetString("tenant"), req.SubjectID, req.Purpose, c.GetString("userID"), req.ExpiresAt)
+		if errors.Is(err, services.ErrConsentExpiryPassed) {
+			middlewares.RespondError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrAttestationFailed) {
+			middlewares.RespondError(c, http.StatusServiceUnavailable, "Failed to attest consent")
+			return
+		}
+		if err != nil {
+			middlewares.RespondError(c, http.StatusInternalServerError, "Failed to grant consent")
+			return
+		}
+
+		c.JSON(http.StatusCreated, record)
+	}
+}
+
+// WithdrawConsent returns a handler that withdraws a data subject's consent for a purpose
+func WithdrawConsent(consentService *services.ConsentService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req WithdrawConsentRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middlewares.RespondError(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		record, err := consentService.Withdraw(c.Request.Context(), c.GetString("tenant"), req.SubjectID, req.Purpose, c.GetString("userID"))
+		if errors.Is(err, storage.ErrConsentNotFound) {
+			middlewares.RespondError(c, http.StatusNotFound, "No active consent found")
+			return
+		}
+		if errors.Is(err, services.ErrAttestationFailed) {
+			middlewares.RespondError(c, http.StatusServiceUnavailable, "Failed to attest consent withdrawal")
+			return
+		}
+		if err != nil {
+			middlewares.RespondError(c, http.StatusInternalServerError, "Failed to withdraw consent")
+			return
+		}
+
+		c.JSON(http.StatusOK, record)
+	}
+}
+
+// ListConsents returns a handler for a data subject's consent history
+func ListConsents(consentService *services.ConsentService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subjectID := c.Param("subject_id")
+		records, err := consentService.List(c.Request.Context(), c.GetString("tenant"), subjectID)
+		if err != nil {
+			middlewares.RespondError(c, http.StatusInternalServerError, "Failed to list consents")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"subject_id": subjectID,
+			"consents":   records,
+			"total":      len(records),
+		})
+	}
+}
+
+// GetConsentAttestation returns a handler for the on-chain consent state of a
+// data subject for a purpose. With an "at" RFC 3339 timestamp it reports
+// whether the chain proves consent was valid at that time.
+func GetConsentAttestation(blockchainService *services.BlockchainService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if blockchainService == nil {
+			middlewares.RespondError(c, http.StatusServiceUnavailable, "Consent registry is not configured")
+			return
+		}
+
+		subjectID := c.Param("subject_id")
+		purpose := c.Query("purpose")
+		if purpose == "" {
+			middlewares.RespondError(c, http.StatusBadRequest, "purpose is required")
+			return
+		}
+		tenant := c.GetString("tenant")
+
+		if raw := c.Query("at"); raw != "" {
+			at, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				middlewares.RespondError(c, http.StatusBadRequest, "at must be an RFC 3339 timestamp")
+				return
+			}
+
+			valid, consentID, err := blockchainService.WasConsentValidAt(c.Request.Context(), tenant, subjectID, purpose, at)
+			if err != nil {
+				respondAttestationError(c, err)
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"subject_id": subjectID,
+				"purpose":    purpose,
+				"at":         at,
+				"valid":      valid,
+				"consent_id": consentID,
+			})
+			return
+		}
+
+		attestation, err := blockchainService.GetConsentAttestation(c.Request.Context(), tenant, subjectID, purpose)
+		if err != nil {
+			respondAttestationError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"subject_id":  subjectID,
+			"purpose":     purpose,
+			"attestation": attestation,
+		})
+	}
+}
+
+// respondAttestationError writes the error response for a failed consent registry query
+func respondAttestationError(c *gin.Context, err error) {
+	if errors.Is(err, blockchain.ErrConsentRegistryDisabled) {
+		middlewares.RespondError(c, http.StatusServiceUnavailable, "Consent registry is not configured")
+		return
+	}
+	middlewares.RespondError(c, http.StatusBadGateway, "Failed to query consent registry")
+}
+
+// checkConsent enforces the consent policy for a request. It returns the
+// consent record the request relies on, or nil if none is needed, and writes
+// an error response and returns false if the request must not be forwarded.
+func checkConsent(c *gin.Context, consentService *services.ConsentService, policy config.ConsentPolicy, subjectID string, purpose string) (*storage.ConsentRecord, bool) {
+	if subjectID == "" && purpose == "" && !policy.Required {
+		return nil, true
+	}
+	if subjectID == "" || purpose == "" {
+		middlewares.RespondError(c, http.StatusBadRequest, "subject_id and purpose are required")
+		return nil, false
+	}
+
+	record, err := consentService.Check(c.Request.Context(), c.GetString("tenant"), subjectID, purpose)
+	var consentErr *services.ConsentError
+	if errors.As(err, &consentErr) {
+		middlewares.RespondError(c, http.StatusForbidden, err.Error())
+		return nil, false
+	}
+	if err != nil {
+		middlewares.RespondError(c, http.StatusInternalServerError, "Failed to check consent")
+		return nil, false
+	}
+	return &record, true
+}
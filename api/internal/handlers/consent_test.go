@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/services"
+	"github.com/secura/api/internal/storage"
+)
+
+// testAttester is a ConsentAttester that accepts every attestation
+type testAttester struct{}
+
+func (testAttester) AttestConsentGrant(context.Context, string, string, string, string, *time.Time) (string, error) {
+	return "0xgrant", nil
+}
+
+func (testAttester) AttestConsentRevocation(context.Context, string, string, string) (string, error) {
+	return "0xrevocation", nil
+}
+
+// runCheckConsent calls checkConsent for a request in a tenant and returns
+// the consent relied on, whether the request may proceed and the response
+func runCheckConsent(consentService *services.ConsentService, policy config.ConsentPolicy, tenant string, subjectID string, purpose string) (*storage.ConsentRecord, bool, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/llm/completion", nil)
+	if tenant != "" {
+		c.Set("tenant", tenant)
+	}
+
+	record, ok := checkConsent(c, consentService, policy, subjectID, purpose)
+	return record, ok, w
+}
+
+func TestCheckConsent(t *testing.T) {
+	ctx := context.Background()
+	consentService := services.NewConsentService(storage.NewMemoryConsentStore(), testAttester{})
+	granted, err := consentService.Grant(ctx, "acme", "subject-1", "summarization", "user-123", nil)
+	if err != nil {
+		t.Fatalf("Grant() error = %v", err)
+	}
+	if _, err := consentService.Grant(ctx, "acme", "subject-2", "summarization", "user-123", nil); err != nil {
+		t.Fatalf("Grant() error = %v", err)
+	}
+	if _, err := consentService.Withdraw(ctx, "acme", "subject-2", "summarization", "user-123"); err != nil {
+		t.Fatalf("Withdraw() error = %v", err)
+	}
+
+	optional := config.ConsentPolicy{}
+	required := config.ConsentPolicy{Required: true}
+
+	tests := []struct {
+		name       string
+		policy     config.ConsentPolicy
+		tenant     string
+		subjectID  string
+		purpose    string
+		wantOK     bool
+		wantStatus int
+		wantRecord string
+	}{
+		{name: "not required and not given", policy: optional, tenant: "acme", wantOK: true},
+		{name: "required and not given", policy: required, tenant: "acme", wantStatus: http.StatusBadRequest},
+		{name: "subject without purpose", policy: optional, tenant: "acme", subjectID: "subject-1", wantStatus: http.StatusBadRequest},
+		{name: "purpose without subject", policy: optional, tenant: "acme", purpose: "summarization", wantStatus: http.StatusBadRequest},
+		{name: "granted", policy: required, tenant: "acme", subjectID: "subject-1", purpose: "summarization", wantOK: true, wantRecord: granted.ID},
+		{name: "granted and not required", policy: optional, tenant: "acme", subjectID: "subject-1", purpose: "summarization", wantOK: true, wantRecord: granted.ID},
+		{name: "withdrawn", policy: required, tenant: "acme", subjectID: "subject-2", purpose: "summarization", wantStatus: http.StatusForbidden},
+		{name: "other purpose", policy: required, tenant: "acme", subjectID: "subject-1", purpose: "marketing", wantStatus: http.StatusForbidden},
+		{name: "other tenant", policy: required, tenant: "globex", subjectID: "subject-1", purpose: "summarization", wantStatus: http.StatusForbidden},
+		{name: "never granted", policy: required, tenant: "acme", subjectID: "subject-3", purpose: "summarization", wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record, ok, w := runCheckConsent(consentService, tt.policy, tt.tenant, tt.subjectID, tt.purpose)
+			if ok != tt.wantOK {
+				t.Fatalf("checkConsent() ok = %v, want %v (status %d, body %s)", ok, tt.wantOK, w.Code, w.Body.String())
+			}
+			if !tt.wantOK {
+				if w.Code != tt.wantStatus {
+					t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+				}
+				if record != nil {
+					t.Errorf("checkConsent() record = %+v, want nil", record)
+				}
+				return
+			}
+
+			if w.Body.Len() != 0 {
+				t.Errorf("checkConsent() wrote a response: %s", w.Body.String())
+			}
+			switch {
+			case tt.wantRecord == "" && record != nil:
+				t.Errorf("checkConsent() record = %+v, want nil", record)
+			case tt.wantRecord != "" && (record == nil || record.ID != tt.wantRecord):
+				t.Errorf("checkConsent() record = %+v, want consent %s", record, tt.wantRecord)
+			case record != nil && record.AttestationTx == "":
+				t.Error("checkConsent() record has no attestation transaction")
+			}
+		})
+	}
+}
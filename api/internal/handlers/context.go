@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/secura/api/internal/middlewares"
+)
+
+// anonymizationTimeoutMessage is returned when anonymizing a request or
+// scanning a response runs out of time
+const anonymizationTimeoutMessage = "The anonymization service did not respond in time"
+
+// stageContext bounds a stage of the request pipeline, such as
+// anonymization, by its configured timeout. The stage is also cancelled when
+// the request is.
+func stageContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// auditContext returns the context for recording an interaction in the audit
+// trail and settling its usage. It keeps the request's values but not its cancellation, so
+// interactions are recorded even when the client disconnects or the request
+// deadline has passed.
+func auditContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return stageContext(detachedContext{ctx}, timeout)
+}
+
+// detachedContext is a context that carries the values of its parent but is
+// never cancelled
+type detachedContext struct {
+	context.Context
+}
+
+// Deadline implements context.Context
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+// Done implements context.Context
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+// Err implements context.Context
+func (detachedContext) Err() error {
+	return nil
+}
+
+// stageError maps the error of a failed pipeline stage to the error returned
+// to the client. Stages that ran out of time are reported as a gateway
+// timeout, and stages stopped by a client disconnect as a closed request.
+func stageError(err error, timeoutMessage string) *middlewares.APIError {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return middlewares.NewAPIError(middlewares.StatusClientClosedRequest, "The client closed the request")
+	case errors.Is(err, context.DeadlineExceeded):
+		apiErr := middlewares.NewAPIError(http.StatusGatewayTimeout, timeoutMessage)
+		apiErr.Retryable = true
+		return apiErr
+	default:
+		return middlewares.NewAPIError(http.StatusInternalServerError, "Failed to process request")
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/secura/api/internal/middlewares"
+	"github.com/secura/api/internal/services"
+	"github.com/secura/api/internal/storage"
+)
+
+// DSARRequest names the user and/or data subject a data subject access or
+// erasure request covers
+type DSARRequest struct {
+	UserID    string `json:"user_id,omitempty"`
+	SubjectID string `json:"subject_id,omitempty"`
+}
+
+// ExportSubjectData returns a handler that exports everything the gateway
+// holds about a user or data subject as a JSON archive
+func ExportSubjectData(dsarService *services.DSARService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req DSARRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middlewares.RespondError(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		export, err := dsarService.Export(c.Request.Context(), c.GetString("tenant"), req.UserID, req.SubjectID)
+		if errors.Is(err, services.ErrDSARTargetRequired) {
+			middlewares.RespondError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, storage.ErrUserNotFound) {
+			middlewares.RespondError(c, http.StatusNotFound, "User not found in the tenant")
+			return
+		}
+		if err != nil {
+			middlewares.RespondError(c, http.StatusInternalServerError, "Failed to export data")
+			return
+		}
+
+		filename := "dsar-" + export.GeneratedAt.Format("20060102T150405Z") + ".json"
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+		c.JSON(http.StatusOK, export)
+	}
+}
+
+// EraseSubjectData returns a handler that erases the personal data held about
+// a user or data subject and returns the erasure receipt
+func EraseSubjectData(dsarService *services.DSARService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req DSARRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middlewares.RespondError(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		receipt, err := dsarService.Erase(c.Request.Context(), c.GetString("tenant"), req.UserID, req.SubjectID, c.GetString("userID"))
+		if errors.Is(err, services.ErrDSARTargetRequired) {
+			middlewares.RespondError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, storage.ErrUserNotFound) {
+			middlewares.RespondError(c, http.StatusNotFound, "User not found in the tenant")
+			return
+		}
+		if err != nil {
+			middlewares.RespondError(c, http.StatusInternalServerError, "Failed to erase data")
+			return
+		}
+
+		c.JSON(http.StatusOK, receipt)
+	}
+}
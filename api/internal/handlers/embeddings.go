@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/secura/api/internal/middlewares"
+)
+
+// maxEmbeddingInputs is the largest batch accepted by the embeddings endpoint
+const maxEmbeddingInputs = 2048
+
+// EmbeddingRequest represents a request to the embeddings endpoint
+type EmbeddingRequest struct {
+	Input          EmbeddingInput `json:"input" binding:"required"`
+	Model          string         `json:"model" binding:"required"`
+	Dimensions     int            `json:"dimensions,omitempty" binding:"min=0"`
+	EncodingFormat string         `json:"encoding_format,omitempty" binding:"omitempty,oneof=float base64"`
+	SubjectID      string         `json:"subject_id,omitempty"` // Data subject whose data the inputs contain
+	Purpose        string         `json:"purpose,omitempty"`    // Processing purpose the subject consented to
+}
+
+// EmbeddingInput is the input of an embeddings request, given as a single
+// string or a batch of strings
+type EmbeddingInput []string
+
+// UnmarshalJSON decodes a string or an array of strings. Token arrays are
+// rejected because they cannot be anonymized.
+func (in *EmbeddingInput) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		*in = EmbeddingInput{text}
+		return nil
+	}
+
+	var texts []string
+	if err := json.Unmarshal(data, &texts); err != nil {
+		return errors.New("input must be a string or an array of strings")
+	}
+	*in = texts
+	return nil
+}
+
+// LLMEmbeddings handles embedding requests
+func LLMEmbeddings(pipeline *Pipeline) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req EmbeddingRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middlewares.RespondError(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if len(req.Input) == 0 || len(req.Input) > maxEmbeddingInputs {
+			middlewares.RespondError(c, http.StatusBadRequest, "input must contain between 1 and 2048 texts")
+			return
+		}
+		for _, text := range req.Input {
+			if text == "" {
+				middlewares.RespondError(c, http.StatusBadRequest, "input must not contain empty texts")
+				return
+			}
+		}
+
+		// Prepare OpenAI request; the inputs are replaced once anonymized
+		inputs := append([]string(nil), req.Input...)
+		fields := make([]textField, len(inputs))
+		for i := range inputs {
+			i := i
+			fields[i] = textField{text: inputs[i], set: func(text string) {
+				inputs[i] = text
+			}}
+		}
+		openaiReq := map[string]interface{}{
+			"input": inputs,
+			"model": req.Model,
+		}
+		if req.Dimensions > 0 {
+			openaiReq["dimensions"] = req.Dimensions
+		}
+		if req.EncodingFormat != "" {
+			openaiReq["encoding_format"] = req.EncodingFormat
+		}
+
+		pipeline.run(c, embeddingRequest(llmRequest{
+			model:     req.Model,
+			subjectID: req.SubjectID,
+			purpose:   req.Purpose,
+			body:      openaiReq,
+			fields:    fields,
+			metadata: map[string]interface{}{
+				"inputs": len(inputs),
+			},
+		}))
+	}
+}
+
+// embeddingRequest completes a pipeline request for embedding inputs. The
+// inputs are not instructions to a model, so they skip the guardrail, the
+// context window and the output policy, and a single audit entry is recorded
+// for the batch without the vectors.
+func embeddingRequest(req llmRequest) llmRequest {
+	req.action = "embedding"
+	req.endpoint = "/embeddings"
+	req.prompt = false
+	req.countTokens = func() (int, error) {
+		return countFieldTokens(req.model, req.fields, false)
+	}
+	req.responseMetadata = func(metadata map[string]interface{}, resp map[string]interface{}) {
+		metadata["dimensions"] = embeddingDimensions(resp)
+	}
+	req.auditResponse = embeddingAuditResponse
+	return req
+}
+
+// embeddingDimensions returns the length of the first vector of an embeddings
+// response, decoding base64-encoded vectors of float32 values
+func embeddingDimensions(resp map[string]interface{}) int {
+	data, _ := resp["data"].([]interface{})
+	if len(data) == 0 {
+		return 0
+	}
+	item, _ := data[0].(map[string]interface{})
+	switch vector := item["embedding"].(type) {
+	case []interface{}:
+		return len(vector)
+	case string:
+		return base64.StdEncoding.DecodedLen(len(vector)) / 4
+	default:
+		return 0
+	}
+}
+
+// embeddingAuditResponse returns the part of an embeddings response recorded
+// in the audit trail. The vectors are left out: they can be inverted to
+// recover the embedded text.
+func embeddingAuditResponse(resp map[string]interface{}) map[string]interface{} {
+	data, _ := resp["data"].([]interface{})
+	return map[string]interface{}{
+		"object":     resp["object"],
+		"model":      resp["model"],
+		"usage":      resp["usage"],
+		"embeddings": len(data),
+		"dimensions": embeddingDimensions(resp),
+	}
+}
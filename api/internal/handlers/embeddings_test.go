@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEmbeddingAuditResponse(t *testing.T) {
+	vector := base64.StdEncoding.EncodeToString(make([]byte, 4*256))
+	tests := []struct {
+		name           string
+		embedding      interface{}
+		wantDimensions int
+	}{
+		{name: "float vectors", embedding: []interface{}{0.0123, -0.0456, 0.0789}, wantDimensions: 3},
+		{name: "base64 vectors", embedding: vector, wantDimensions: 256},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := map[string]interface{}{
+				"object": "list",
+				"model":  "text-embedding-3-small",
+				"data": []interface{}{
+					map[string]interface{}{"object": "embedding", "index": 0, "embedding": tt.embedding},
+					map[string]interface{}{"object": "embedding", "index": 1, "embedding": tt.embedding},
+				},
+				"usage": map[string]interface{}{"prompt_tokens": 8, "total_tokens": 8},
+			}
+
+			audited := embeddingAuditResponse(resp)
+			if audited["embeddings"] != 2 || audited["dimensions"] != tt.wantDimensions {
+				t.Errorf("embeddingAuditResponse() = %v, want 2 embeddings of %d dimensions", audited, tt.wantDimensions)
+			}
+
+			encoded, err := json.Marshal(audited)
+			if err != nil {
+				t.Fatalf("failed to encode audit response: %v", err)
+			}
+			if _, ok := audited["data"]; ok || strings.Contains(string(encoded), "0.0123") || strings.Contains(string(encoded), vector) {
+				t.Errorf("embeddingAuditResponse() = %s, want no vectors", encoded)
+			}
+		})
+	}
+}
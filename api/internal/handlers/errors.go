@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/logging"
+	"github.com/secura/api/internal/middlewares"
+	"github.com/secura/api/internal/providers"
+	"github.com/secura/api/internal/services"
+	"github.com/secura/api/internal/storage"
+)
+
+// Outcomes of audited LLM interactions
+const (
+	auditStatusSuccess = "success"
+	auditStatusError   = "error"
+	auditStatusBlocked = "blocked"
+)
+
+// upstreamError maps an error routing a request to the model providers to
+// the error returned to the client. Provider rate limits and request errors
+// keep their status; other provider failures are reported as a bad gateway
+// so clients can tell them from gateway errors.
+func upstreamError(err error) *middlewares.APIError {
+	var providerErr *providers.Error
+	switch {
+	case errors.Is(err, config.ErrResidencyViolation):
+		return &middlewares.APIError{
+			Status:  http.StatusForbidden,
+			Code:    middlewares.ErrorCodeResidencyViolation,
+			Message: "The model is not available in the tenant's data residency region",
+		}
+	case errors.Is(err, providers.ErrNoHealthyDeployment):
+		return &middlewares.APIError{
+			Status:    http.StatusServiceUnavailable,
+			Code:      middlewares.ErrorCodeNoHealthyDeployment,
+			Message:   "No healthy deployment is available for the model",
+			Retryable: true,
+		}
+	case errors.Is(err, context.Canceled):
+		return middlewares.NewAPIError(middlewares.StatusClientClosedRequest, "The client closed the request")
+	case errors.Is(err, context.DeadlineExceeded):
+		return &middlewares.APIError{
+			Status:    http.StatusGatewayTimeout,
+			Code:      middlewares.ErrorCodeUpstreamTimeout,
+			Message:   "The model provider did not respond in time",
+			Retryable: true,
+		}
+	case errors.As(err, &providerErr):
+		return providerError(providerErr)
+	default:
+		return middlewares.NewAPIError(http.StatusInternalServerError, "Failed to process request")
+	}
+}
+
+// providerError maps a failed provider request to the error returned to the
+// client. Provider messages are only passed on for rejected requests, since
+// other failures concern the gateway's configuration rather than the caller.
+func providerError(err *providers.Error) *middlewares.APIError {
+	apiErr := &middlewares.APIError{
+		Status:         http.StatusBadGateway,
+		Code:           middlewares.ErrorCodeUpstreamError,
+		Message:        "The model provider failed to process the request",
+		Retryable:      err.Retryable(),
+		UpstreamStatus: err.StatusCode,
+	}
+
+	var netErr net.Error
+	switch status := err.StatusCode; {
+	case status == 0 && errors.As(err.Err, &netErr) && netErr.Timeout():
+		apiErr.Status = http.StatusGatewayTimeout
+		apiErr.Code = middlewares.ErrorCodeUpstreamTimeout
+		apiErr.Message = "The model provider did not respond in time"
+	case status == 0:
+		apiErr.Code = middlewares.ErrorCodeUpstreamUnavailable
+		apiErr.Message = "The model provider could not be reached"
+	case status == http.StatusBadRequest || status == http.StatusRequestEntityTooLarge || status == http.StatusUnprocessableEntity:
+		apiErr.Status = status
+		apiErr.Code = middlewares.ErrorCodeUpstreamInvalidRequest
+		apiErr.Message = "The model provider rejected the request"
+		if err.Message != "" {
+			apiErr.Message += ": " + err.Message
+		}
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		apiErr.Code = middlewares.ErrorCodeUpstreamAuthentication
+		apiErr.Message = "The gateway could not authenticate with the model provider"
+	case status == http.StatusNotFound:
+		apiErr.Code = middlewares.ErrorCodeUpstreamNotFound
+		apiErr.Message = "The model was not found at the provider"
+	case status == http.StatusTooManyRequests:
+		apiErr.Status = http.StatusTooManyRequests
+		apiErr.Code = middlewares.ErrorCodeUpstreamRateLimited
+		apiErr.Message = "The model provider is rate limiting requests"
+		apiErr.RetryAfter = err.RetryAfter
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		apiErr.Status = http.StatusGatewayTimeout
+		apiErr.Code = middlewares.ErrorCodeUpstreamTimeout
+		apiErr.Message = "The model provider did not respond in time"
+	case status == http.StatusServiceUnavailable:
+		apiErr.Status = http.StatusServiceUnavailable
+		apiErr.Code = middlewares.ErrorCodeUpstreamUnavailable
+		apiErr.Message = "The model provider is unavailable"
+		apiErr.RetryAfter = err.RetryAfter
+	}
+	return apiErr
+}
+
+// respondUpstreamError logs a failed routed request, records it in the audit
+// trail and returns the mapped error to the client. Requests abandoned by the
+// client are logged as warnings, since no provider failed.
+func respondUpstreamError(c *gin.Context, logger *zap.Logger, blockchainService *services.BlockchainService, auditStore storage.AuditStore, auditTimeout time.Duration, interaction failedInteraction, err error) {
+	apiErr := upstreamError(err)
+	fields := []zap.Field{
+		zap.String("deployment", interaction.routed.Deployment),
+		zap.Int("attempts", interaction.routed.Attempts),
+		zap.String("code", apiErr.Code),
+		zap.Error(err),
+	}
+	reqLogger := logging.FromContext(c.Request.Context(), logger)
+	if apiErr.Status == middlewares.StatusClientClosedRequest {
+		reqLogger.Warn("Client disconnected before the provider responded", fields...)
+	} else {
+		reqLogger.Error("Failed to call provider", fields...)
+	}
+	recordFailedInteraction(c, logger, blockchainService, auditStore, auditTimeout, interaction, apiErr)
+	middlewares.RespondAPIError(c, apiErr)
+}
+
+// failedInteraction describes an LLM request that failed upstream
+type failedInteraction struct {
+	userID     string
+	subjectID  string
+	actionType string
+	request    map[string]interface{}
+	routed     providers.Result
+
+	// metadata holds the request's audit metadata, such as the model and
+	// how it was anonymized
+	metadata map[string]interface{}
+}
+
+// recordFailedInteraction records an LLM request that failed upstream in the
+// audit trail with status "error". The request sent upstream is recorded
+// with the error in place of a response.
+func recordFailedInteraction(c *gin.Context, logger *zap.Logger, blockchainService *services.BlockchainService, auditStore storage.AuditStore, auditTimeout time.Duration, interaction failedInteraction, apiErr *middlewares.APIError) {
+	tenant := c.GetString("tenant")
+	metadata := interaction.metadata
+	metadata["status"] = auditStatusError
+	metadata["error_code"] = apiErr.Code
+	metadata["retryable"] = apiErr.Retryable
+	if apiErr.UpstreamStatus != 0 {
+		metadata["upstream_status"] = apiErr.UpstreamStatus
+	}
+	metadata["ip_address"] = c.ClientIP()
+	metadata["user_agent"] = c.Request.UserAgent()
+	metadata["request_id"] = c.GetString("requestID")
+	metadata["tenant"] = tenant
+	if interaction.routed.Deployment != "" {
+		addDeploymentMetadata(metadata, interaction.routed)
+	}
+
+	var txHash string
+	if blockchainService != nil {
+		response := map[string]interface{}{
+			"error": map[string]interface{}{
+				"code":    apiErr.Code,
+				"message": apiErr.Message,
+			},
+		}
+		ctx, cancel := auditContext(c.Request.Context(), auditTimeout)
+		defer cancel()
+		var err error
+		txHash, err = blockchainService.RecordLLMInteraction(ctx, interaction.userID, interaction.actionType, interaction.request, response, metadata)
+		if err != nil {
+			logging.FromContext(ctx, logger).Error("Failed to record audit log", zap.Error(err))
+		}
+	}
+
+	indexAuditEntry(c, logger, auditStore, auditTimeout, storage.AuditEntry{
+		Tenant:     tenant,
+		UserID:     interaction.userID,
+		SubjectID:  interaction.subjectID,
+		ActionType: interaction.actionType,
+		TxHash:     txHash,
+		Timestamp:  time.Now().UTC(),
+		Metadata:   metadata,
+	})
+}
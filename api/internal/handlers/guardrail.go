@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/guardrails"
+	"github.com/secura/api/internal/logging"
+	"github.com/secura/api/internal/metrics"
+	"github.com/secura/api/internal/middlewares"
+	"github.com/secura/api/internal/services"
+	"github.com/secura/api/internal/storage"
+)
+
+// Guardrail verdicts
+const (
+	guardrailPass  = "pass"
+	guardrailFlag  = "flag"
+	guardrailBlock = "block"
+)
+
+// promptCheck is the result of checking a request's prompts for injection
+type promptCheck struct {
+	Verdict   guardrails.Verdict
+	Threshold float64
+	// Action is "pass", "flag" or "block"
+	Action string
+}
+
+// checkPrompts scores the texts of a request for prompt injection and decides
+// the guardrail action. It runs on the original texts, before anonymization
+// can rewrite the phrases the detector looks for.
+func checkPrompts(policy config.GuardrailPolicy, texts ...string) promptCheck {
+	check := promptCheck{Action: guardrailPass, Threshold: policy.EffectiveThreshold()}
+	if !policy.Enabled() {
+		return check
+	}
+
+	verdicts := make([]guardrails.Verdict, 0, len(texts))
+	for _, text := range texts {
+		verdicts = append(verdicts, guardrails.Detect(text))
+	}
+	check.Verdict = guardrails.Combine(verdicts...)
+
+	if check.Verdict.Score >= check.Threshold {
+		check.Action = guardrailFlag
+		if policy.Action == config.GuardrailActionBlock {
+			check.Action = guardrailBlock
+		}
+	}
+
+	metrics.GuardrailVerdictsTotal.WithLabelValues(check.Action).Inc()
+	for _, signal := range check.Verdict.Signals {
+		metrics.GuardrailSignalsTotal.WithLabelValues(signal.Category).Inc()
+	}
+	return check
+}
+
+// guardrailError is the error returned for a request the guardrail blocks
+func guardrailError() *middlewares.APIError {
+	apiErr := middlewares.NewAPIError(http.StatusUnprocessableEntity, "Request blocked by prompt injection guardrail")
+	apiErr.Code = middlewares.ErrorCodePromptInjection
+	return apiErr
+}
+
+// addMetadata records the guardrail verdict in audit metadata
+func (p promptCheck) addMetadata(metadata map[string]interface{}) {
+	metadata["guardrail_verdict"] = p.Action
+	metadata["guardrail_score"] = p.Verdict.Score
+	metadata["guardrail_threshold"] = p.Threshold
+	if len(p.Verdict.Signals) > 0 {
+		metadata["guardrail_signals"] = p.Verdict.Rules()
+	}
+}
+
+// setGuardrailHeaders tells the caller that the request was flagged or blocked
+// by the prompt injection guardrail
+func setGuardrailHeaders(c *gin.Context, check promptCheck) {
+	if check.Action == guardrailPass {
+		return
+	}
+	c.Header("X-Guardrail-Verdict", check.Action)
+	c.Header("X-Guardrail-Score", strconv.FormatFloat(check.Verdict.Score, 'f', 2, 64))
+}
+
+// recordGuardrailBlock records a request blocked by the guardrail in the audit
+// trail. Only the verdict is recorded, never the prompt itself.
+func recordGuardrailBlock(c *gin.Context, logger *zap.Logger, blockchainService *services.BlockchainService, auditStore storage.AuditStore, auditTimeout time.Duration, userID string, subjectID string, model string, check promptCheck) {
+	tenant := c.GetString("tenant")
+	metadata := map[string]interface{}{
+		"model":      model,
+		"ip_address": c.ClientIP(),
+		"user_agent": c.Request.UserAgent(),
+		"request_id": c.GetString("requestID"),
+		"tenant":     tenant,
+	}
+	check.addMetadata(metadata)
+
+	var txHash string
+	if blockchainService != nil {
+		data := map[string]interface{}{
+			"request_id": c.GetString("requestID"),
+		}
+		ctx, cancel := auditContext(c.Request.Context(), auditTimeout)
+		defer cancel()
+		var err error
+		txHash, err = blockchainService.RecordEvent(ctx, userID, "guardrail_block", data, metadata)
+		if err != nil {
+			logging.FromContext(ctx, logger).Error("Failed to record guardrail block", zap.Error(err))
+		}
+	}
+
+	indexAuditEntry(c, logger, auditStore, auditTimeout, storage.AuditEntry{
+		Tenant:     tenant,
+		UserID:     userID,
+		SubjectID:  subjectID,
+		ActionType: "guardrail_block",
+		TxHash:     txHash,
+		Timestamp:  time.Now().UTC(),
+		Metadata:   metadata,
+	})
+}
@@ -1,11 +1,23 @@
 package handlers
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/health"
+	"github.com/secura/api/internal/httpclient"
+	"github.com/secura/api/internal/services"
+	"github.com/secura/api/internal/version"
 )
 
 // HealthCheck returns a handler for the health endpoint
@@ -13,9 +25,208 @@ func HealthCheck(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
 			"status":      "ok",
-			"version":     "0.1.0",
+			"version":     version.Version,
+			"commit":      version.Commit,
 			"environment": cfg.Environment,
 			"timestamp":   time.Now().Format(time.RFC3339),
 		})
 	}
 }
+
+// Liveness returns a handler reporting that the process is running.
+// It performs no dependency checks so a slow dependency never restarts the pod.
+func Liveness() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status":     health.StatusOK,
+			"version":    version.Version,
+			"commit":     version.Commit,
+			"build_time": version.BuildTime,
+			"timestamp":  time.Now().Format(time.RFC3339),
+		})
+	}
+}
+
+// Readiness returns a handler that runs all dependency checks and reports
+// per-component status. It returns 503 only when a critical dependency is down.
+func Readiness(checks *ReadinessChecks) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := checks.Checker().Run(c.Request.Context())
+
+		status := http.StatusOK
+		if report.Status == health.StatusDown {
+			status = http.StatusServiceUnavailable
+		}
+
+		c.JSON(status, gin.H{
+			"status":     report.Status,
+			"components": report.Components,
+			"version":    version.Version,
+			"commit":     version.Commit,
+			"timestamp":  time.Now().Format(time.RFC3339),
+		})
+	}
+}
+
+// ReadinessChecks holds the dependency checks for the current configuration.
+// They are derived again after a reload or secret refresh, so added or
+// removed deployments and rotated keys are picked up without a restart.
+type ReadinessChecks struct {
+	cfgStore          *config.Store
+	blockchainService *services.BlockchainService
+	db                *sql.DB
+
+	mu      sync.Mutex
+	cfg     *config.Config
+	checker *health.Checker
+}
+
+// NewReadinessChecks creates the readiness checks for the dependencies of the
+// gateway. db is nil when state is kept in memory.
+func NewReadinessChecks(cfgStore *config.Store, blockchainService *services.BlockchainService, db *sql.DB) *ReadinessChecks {
+	return &ReadinessChecks{
+		cfgStore:          cfgStore,
+		blockchainService: blockchainService,
+		db:                db,
+	}
+}
+
+// Checker returns the checker for the current configuration snapshot
+func (r *ReadinessChecks) Checker() *health.Checker {
+	cfg := r.cfgStore.Current()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cfg != cfg {
+		r.checker = newHealthChecker(cfg, r.blockchainService, r.db)
+		r.cfg = cfg
+	}
+	return r.checker
+}
+
+// newHealthChecker registers a check for each dependency of a configuration.
+// The NLP service is critical. A model deployment is critical when it is the
+// only deployment of a model, since there is nothing to fail over to; the
+// database, blockchain and deployments with a fallback only degrade the
+// gateway. Probes share the pooled services client; each is bounded by the
+// checker's context deadline of cfg.HealthCheckTimeout.
+func newHealthChecker(cfg *config.Config, blockchainService *services.BlockchainService, db *sql.DB) *health.Checker {
+	checker := health.NewChecker(cfg.HealthCheckTimeout)
+	client := httpclient.Services()
+
+	if db != nil {
+		checker.Register("database", false, db.PingContext)
+	}
+
+	if cfg.NLPServiceURL != "" {
+		checker.Register("anonymization", true, health.HTTPCheck(client, cfg.NLPServiceURL+"/health", nil))
+	}
+
+	if cfg.BlockchainNodeURL != "" {
+		if blockchainService != nil {
+			checker.Register("blockchain", false, blockchainService.CheckHealth)
+		} else {
+			checker.Register("blockchain", false, func(_ context.Context) error {
+				return errors.New("blockchain service failed to initialize")
+			})
+		}
+	}
+
+	for _, deployment := range healthDeployments(cfg) {
+		checker.Register("deployment:"+deployment.config.Name, deployment.critical, deploymentCheck(client, deployment.config))
+	}
+
+	return checker
+}
+
+// healthDeployment is a model deployment probed by the readiness checks
+type healthDeployment struct {
+	config   config.DeploymentConfig
+	critical bool
+}
+
+// healthDeployments returns the deployments of the configured routes, with
+// the default OpenAI credentials filled in. The default OpenAI deployment
+// serving unrouted models is included when an OpenAI API key is configured.
+func healthDeployments(cfg *config.Config) []healthDeployment {
+	models := make([]string, 0, len(cfg.Routing.Routes)+1)
+	for model := range cfg.Routing.Routes {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	if cfg.OpenAIAPIKey != "" {
+		// A model without a route is served by the default deployment alone
+		models = append(models, "")
+	}
+
+	var deployments []healthDeployment
+	index := make(map[string]int)
+	for _, model := range models {
+		routed, err := cfg.DeploymentsFor(model, "")
+		if err != nil {
+			continue
+		}
+		for _, deployment := range routed {
+			critical := len(routed) == 1
+			if i, ok := index[deployment.Name]; ok {
+				deployments[i].critical = deployments[i].critical || critical
+				continue
+			}
+			index[deployment.Name] = len(deployments)
+			deployments = append(deployments, healthDeployment{config: deployment, critical: critical})
+		}
+	}
+	return deployments
+}
+
+// deploymentCheck returns a check probing a deployment with a read-only
+// request to its provider API. Cohere has no read-only endpoint under its
+// chat API base URL, so only the connection to it is checked.
+func deploymentCheck(client *http.Client, deployment config.DeploymentConfig) health.CheckFunc {
+	switch deployment.Provider {
+	case config.ProviderOllama:
+		return health.HTTPCheck(client, deployment.BaseURL+"/api/tags", nil)
+	case config.ProviderAzure:
+		apiVersion := deployment.APIVersion
+		if apiVersion == "" {
+			apiVersion = config.DefaultAzureAPIVersion
+		}
+		return health.HTTPCheck(client, deployment.BaseURL+"/openai/models?api-version="+url.QueryEscape(apiVersion), map[string]string{
+			"api-key": deployment.APIKey,
+		})
+	case config.ProviderGemini:
+		return health.HTTPCheck(client, deployment.BaseURL+"/models/"+url.PathEscape(deployment.Model), map[string]string{
+			"x-goog-api-key": deployment.APIKey,
+		})
+	case config.ProviderCohere:
+		return func(ctx context.Context) error {
+			addr, err := hostAddress(deployment.BaseURL)
+			if err != nil {
+				return err
+			}
+			return health.TCPCheck(addr)(ctx)
+		}
+	default:
+		headers := map[string]string{}
+		if deployment.APIKey != "" {
+			headers["Authorization"] = "Bearer " + deployment.APIKey
+		}
+		return health.HTTPCheck(client, deployment.BaseURL+"/models", headers)
+	}
+}
+
+// hostAddress returns the host and port a base URL connects to
+func hostAddress(baseURL string) (string, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Host == "" {
+		return "", fmt.Errorf("invalid base URL %q", baseURL)
+	}
+	if parsed.Port() != "" {
+		return parsed.Host, nil
+	}
+	port := "443"
+	if parsed.Scheme == "http" {
+		port = "80"
+	}
+	return net.JoinHostPort(parsed.Hostname(), port), nil
+}
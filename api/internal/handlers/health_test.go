@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/health"
+)
+
+func TestHealthCheckerTimeout(t *testing.T) {
+	// The anonymization service hangs until the probe gives up
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(slow.Close)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(fast.Close)
+
+	cfg := config.Defaults()
+	cfg.HealthCheckTimeout = 50 * time.Millisecond
+	cfg.NLPServiceURL = slow.URL
+	cfg.BlockchainNodeURL = ""
+	cfg.OpenAIAPIKey = ""
+	cfg.Routing.Routes = map[string]config.ModelRoute{
+		"llama3": {Deployments: []config.DeploymentConfig{{Name: "local", Provider: config.ProviderOpenAICompatible, Model: "llama3", BaseURL: fast.URL}}},
+	}
+
+	start := time.Now()
+	report := newHealthChecker(cfg, nil, nil).Run(context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Run() took %s, want it bounded by the health check timeout", elapsed)
+	}
+
+	if got := report.Components["anonymization"]; got.Status != health.StatusDown || !got.Critical {
+		t.Errorf("anonymization = %+v, want a critical component down", got)
+	}
+	if got := report.Components["deployment:local"]; got.Status != health.StatusUp {
+		t.Errorf("deployment:local = %+v, want up", got)
+	}
+	if report.Status != health.StatusDown {
+		t.Errorf("report status = %q, want %q", report.Status, health.StatusDown)
+	}
+}
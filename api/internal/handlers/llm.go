@@ -1,17 +1,20 @@
 package handlers
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.uber.org/zap"
 
 	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/middlewares"
+	"github.com/secura/api/internal/providers"
 	"github.com/secura/api/internal/services"
+	"github.com/secura/api/internal/storage"
+	"github.com/secura/api/internal/tokenizer"
 )
 
 // CompletionRequest represents a request to the completion endpoint
@@ -20,264 +23,173 @@ type CompletionRequest struct {
 	Model       string  `json:"model" binding:"required"`
 	MaxTokens   int     `json:"max_tokens,omitempty"`
 	Temperature float64 `json:"temperature,omitempty"`
+	SubjectID   string  `json:"subject_id,omitempty"` // Data subject whose data the prompt contains
+	Purpose     string  `json:"purpose,omitempty"`    // Processing purpose the subject consented to
 }
 
 // ChatRequest represents a request to the chat endpoint
 type ChatRequest struct {
-	Messages    []Message `json:"messages" binding:"required"`
+	Messages    []Message `json:"messages" binding:"required,min=1,dive"`
 	Model       string    `json:"model" binding:"required"`
 	MaxTokens   int       `json:"max_tokens,omitempty"`
 	Temperature float64   `json:"temperature,omitempty"`
-}
+	SubjectID   string    `json:"subject_id,omitempty"` // Data subject whose data the messages contain
+	Purpose     string    `json:"purpose,omitempty"`    // Processing purpose the subject consented to
 
-// Message represents a chat message
-type Message struct {
-	Role    string `json:"role" binding:"required"`
-	Content string `json:"content" binding:"required"`
+	// Tool calling
+	Tools             []Tool          `json:"tools,omitempty" binding:"omitempty,dive"`
+	ToolChoice        json.RawMessage `json:"tool_choice,omitempty"` // "none", "auto", "required" or a named tool
+	ParallelToolCalls *bool           `json:"parallel_tool_calls,omitempty"`
 }
 
 // LLMCompletion handles completion requests
-func LLMCompletion(cfg *config.Config, logger *zap.Logger) gin.HandlerFunc {
-	// Create services
-	anonService := services.NewAnonymizationService(cfg.NLPServiceURL)
-
-	// Initialize blockchain service if enabled
-	var blockchainService *services.BlockchainService
-	if cfg.BlockchainNodeURL != "" {
-		var err error
-		blockchainService, err = services.NewBlockchainService(
-			cfg.BlockchainNodeURL,
-			cfg.BlockchainContractAddress,
-			logger,
-		)
-		if err != nil {
-			logger.Error("Failed to initialize blockchain service", zap.Error(err))
-		}
-	}
-
+func LLMCompletion(pipeline *Pipeline) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req CompletionRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Invalid request body",
-			})
+			middlewares.RespondError(c, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
-		// Get user ID from context
-		userID, _ := c.Get("userID")
-		logger.Info("Processing completion request", zap.String("user_id", userID.(string)), zap.String("model", req.Model))
-
-		// Anonymize the prompt
-		anonymizedPrompt, err := anonService.AnonymizeText(req.Prompt)
-		if err != nil {
-			logger.Error("Failed to anonymize prompt", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to process request",
-			})
-			return
-		}
-
-		// Prepare OpenAI request
+		// Prepare OpenAI request; the prompt is replaced once anonymized
 		openaiReq := map[string]interface{}{
-			"prompt":      anonymizedPrompt,
+			"prompt":      req.Prompt,
 			"model":       req.Model,
 			"max_tokens":  req.MaxTokens,
 			"temperature": req.Temperature,
 		}
-
-		// Forward to OpenAI
-		resp, err := forwardToOpenAI(cfg.OpenAIAPIKey, "https://api.openai.com/v1/completions", openaiReq)
-		if err != nil {
-			logger.Error("Failed to call OpenAI", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to process request",
-			})
-			return
-		}
-
-		// Record in blockchain audit trail if enabled
-		if blockchainService != nil {
-			// Create metadata
-			metadata := map[string]interface{}{
-				"model":          req.Model,
-				"anonymized":     true,
-				"request_tokens": len(req.Prompt),
-				"ip_address":     c.ClientIP(),
-				"user_agent":     c.Request.UserAgent(),
-			}
-
-			// Add response tokens if available
-			if usage, ok := resp["usage"].(map[string]interface{}); ok {
-				if totalTokens, ok := usage["total_tokens"].(float64); ok {
-					metadata["total_tokens"] = totalTokens
-				}
-			}
-
-			// Record interaction in blockchain
-			ctx := context.Background()
-			txHash, err := blockchainService.RecordLLMInteraction(
-				ctx,
-				userID.(string),
-				"completion",
-				openaiReq,
-				resp,
-				metadata,
-			)
-
-			if err != nil {
-				logger.Error("Failed to record audit log", zap.Error(err))
-			} else {
-				logger.Info("Recorded audit log", zap.String("tx_hash", txHash))
-			}
-		}
-
-		// Return response to client
-		c.JSON(http.StatusOK, resp)
+		fields := []textField{{text: req.Prompt, set: func(text string) {
+			openaiReq["prompt"] = text
+		}}}
+
+		pipeline.run(c, llmRequest{
+			action:    "completion",
+			endpoint:  "/completions",
+			model:     req.Model,
+			subjectID: req.SubjectID,
+			purpose:   req.Purpose,
+			maxTokens: req.MaxTokens,
+			body:      openaiReq,
+			fields:    fields,
+			prompt:    true,
+			countTokens: func() (int, error) {
+				return countFieldTokens(req.Model, fields, false)
+			},
+		})
 	}
 }
 
 // LLMChat handles chat requests
-func LLMChat(cfg *config.Config, logger *zap.Logger) gin.HandlerFunc {
-	// Create services
-	anonService := services.NewAnonymizationService(cfg.NLPServiceURL)
-
-	// Initialize blockchain service if enabled
-	var blockchainService *services.BlockchainService
-	if cfg.BlockchainNodeURL != "" {
-		var err error
-		blockchainService, err = services.NewBlockchainService(
-			cfg.BlockchainNodeURL,
-			cfg.BlockchainContractAddress,
-			logger,
-		)
-		if err != nil {
-			logger.Error("Failed to initialize blockchain service", zap.Error(err))
-		}
-	}
-
+func LLMChat(pipeline *Pipeline) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req ChatRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Invalid request body",
-			})
+			middlewares.RespondError(c, http.StatusBadRequest, "Invalid request body")
 			return
 		}
-
-		// Get user ID from context
-		userID, _ := c.Get("userID")
-		logger.Info("Processing chat request", zap.String("user_id", userID.(string)), zap.String("model", req.Model))
-
-		// Anonymize the messages
-		for i, msg := range req.Messages {
-			anonymizedContent, err := anonService.AnonymizeText(msg.Content)
-			if err != nil {
-				logger.Error("Failed to anonymize message", zap.Error(err))
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": "Failed to process request",
-				})
-				return
-			}
-			req.Messages[i].Content = anonymizedContent
+		if err := validateMessages(req.Messages); err != nil {
+			middlewares.RespondError(c, http.StatusBadRequest, err.Error())
+			return
 		}
 
-		// Prepare OpenAI request
+		// Prepare OpenAI request. The fields update the messages in place, so
+		// the anonymized messages are forwarded.
 		openaiReq := map[string]interface{}{
 			"messages":    req.Messages,
 			"model":       req.Model,
 			"max_tokens":  req.MaxTokens,
 			"temperature": req.Temperature,
 		}
-
-		// Forward to OpenAI
-		resp, err := forwardToOpenAI(cfg.OpenAIAPIKey, "https://api.openai.com/v1/chat/completions", openaiReq)
-		if err != nil {
-			logger.Error("Failed to call OpenAI", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to process request",
-			})
-			return
+		if len(req.Tools) > 0 {
+			openaiReq["tools"] = req.Tools
+		}
+		if len(req.ToolChoice) > 0 {
+			openaiReq["tool_choice"] = req.ToolChoice
+		}
+		if req.ParallelToolCalls != nil {
+			openaiReq["parallel_tool_calls"] = *req.ParallelToolCalls
 		}
 
-		// Record in blockchain audit trail if enabled
-		if blockchainService != nil {
-			// Create metadata
-			metadata := map[string]interface{}{
-				"model":      req.Model,
-				"anonymized": true,
-				"messages":   len(req.Messages),
-				"ip_address": c.ClientIP(),
-				"user_agent": c.Request.UserAgent(),
-			}
-
-			// Add response tokens if available
-			if usage, ok := resp["usage"].(map[string]interface{}); ok {
-				if totalTokens, ok := usage["total_tokens"].(float64); ok {
-					metadata["total_tokens"] = totalTokens
+		pipeline.run(c, llmRequest{
+			action:    "chat",
+			endpoint:  "/chat/completions",
+			model:     req.Model,
+			subjectID: req.SubjectID,
+			purpose:   req.Purpose,
+			maxTokens: req.MaxTokens,
+			body:      openaiReq,
+			fields:    messageFields(req.Messages),
+			prompt:    true,
+			countTokens: func() (int, error) {
+				tokenMessages := make([]tokenizer.Message, len(req.Messages))
+				for i, msg := range req.Messages {
+					tokenMessages[i] = tokenizer.Message{Role: msg.Role, Name: msg.Name, Content: messageTokenText(msg)}
 				}
-			}
+				return tokenizer.CountChatTokens(req.Model, tokenMessages)
+			},
+			metadata: map[string]interface{}{
+				"messages": len(req.Messages),
+				"tools":    len(req.Tools),
+			},
+		})
+	}
+}
 
-			// Record interaction in blockchain
-			ctx := context.Background()
-			txHash, err := blockchainService.RecordLLMInteraction(
-				ctx,
-				userID.(string),
-				"chat",
-				openaiReq,
-				resp,
-				metadata,
-			)
+// addDeploymentMetadata records which deployment served a request, the
+// region it processed the data in and provider details such as safety
+// ratings in audit metadata
+func addDeploymentMetadata(metadata map[string]interface{}, routed providers.Result) {
+	metadata["deployment"] = routed.Deployment
+	metadata["provider"] = routed.Provider
+	metadata["provider_model"] = routed.Model
+	metadata["provider_attempts"] = routed.Attempts
+	if routed.Region != "" {
+		metadata["region"] = routed.Region
+	}
+	for key, value := range routed.Metadata {
+		metadata[key] = value
+	}
+}
 
-			if err != nil {
-				logger.Error("Failed to record audit log", zap.Error(err))
-			} else {
-				logger.Info("Recorded audit log", zap.String("tx_hash", txHash))
-			}
+// storePseudonyms stores the personal data detected in a request in the data
+// subject's pseudonym vault so it can be exported or crypto-shredded later
+func storePseudonyms(ctx context.Context, vault storage.PseudonymVault, tenant string, subjectID string, entities []services.DetectedEntity) error {
+	if subjectID == "" {
+		return nil
+	}
+	now := time.Now().UTC()
+	for _, entity := range entities {
+		if _, err := vault.Put(ctx, tenant, subjectID, entity.Type, entity.Text, now); err != nil {
+			return err
 		}
-
-		// Return response to client
-		c.JSON(http.StatusOK, resp)
 	}
+	return nil
 }
 
-// Helper function to forward requests to OpenAI
-func forwardToOpenAI(apiKey string, url string, reqBody map[string]interface{}) (map[string]interface{}, error) {
-	// Marshal request body
-	reqBytes, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, err
+// messageTokenText returns the text of a message that counts towards the
+// prompt: its content and the names and arguments of its tool calls
+func messageTokenText(msg Message) string {
+	var parts []string
+	if msg.Content.Text != nil {
+		parts = append(parts, *msg.Content.Text)
 	}
-
-	// Create request
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return nil, err
+	for _, part := range msg.Content.Parts {
+		parts = append(parts, part.Text, part.Refusal)
 	}
-
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+apiKey)
-
-	// Send request
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+	for _, call := range msg.ToolCalls {
+		parts = append(parts, call.Function.Name, call.Function.Arguments)
 	}
-	defer resp.Body.Close()
-
-	// Read response
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	if msg.FunctionCall != nil {
+		parts = append(parts, msg.FunctionCall.Name, msg.FunctionCall.Arguments)
 	}
+	return strings.Join(parts, "\n")
+}
 
-	// Parse response
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, err
+// checkContextWindow checks a request against the model's context window,
+// preferring a window configured for the model over the built-in table
+func checkContextWindow(cfg *config.Config, model string, promptTokens int, maxTokens int) error {
+	if modelConfig, ok := cfg.Models[model]; ok && modelConfig.ContextWindow > 0 {
+		return tokenizer.CheckContextLimit(model, promptTokens, maxTokens, modelConfig.ContextWindow)
 	}
-
-	return result, nil
+	return tokenizer.CheckContextWindow(model, promptTokens, maxTokens)
 }
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/storage"
+	"github.com/secura/api/internal/tokenizer"
+)
+
+func TestLLMRequestTokens(t *testing.T) {
+	// "hello world" is 2 tokens for gpt-4
+	chatTokens, err := tokenizer.CountChatTokens("gpt-4", []tokenizer.Message{{Role: "user", Content: "hello world"}})
+	if err != nil {
+		t.Fatalf("CountChatTokens() error = %v", err)
+	}
+
+	tests := []struct {
+		name              string
+		path              string
+		fixture           string
+		body              map[string]interface{}
+		contextWindow     int
+		wantStatus        int
+		wantRequestTokens int
+	}{
+		{
+			name:              "completion",
+			path:              "/llm/completion",
+			fixture:           completionFixture,
+			body:              map[string]interface{}{"model": "gpt-4", "prompt": "hello world"},
+			wantStatus:        http.StatusOK,
+			wantRequestTokens: 2,
+		},
+		{
+			name:              "chat",
+			path:              "/llm/chat",
+			fixture:           chatFixture,
+			body:              map[string]interface{}{"model": "gpt-4", "messages": []map[string]string{{"role": "user", "content": "hello world"}}},
+			wantStatus:        http.StatusOK,
+			wantRequestTokens: chatTokens,
+		},
+		{
+			name:          "chat overrunning the configured context window",
+			path:          "/llm/chat",
+			fixture:       chatFixture,
+			body:          map[string]interface{}{"model": "gpt-4", "max_tokens": 100, "messages": []map[string]string{{"role": "user", "content": "hello world"}}},
+			contextWindow: chatTokens + 99,
+			wantStatus:    http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newProviderServer(t, tt.fixture)
+			cfg := newTestConfig(server.URL)
+			if tt.contextWindow > 0 {
+				model := cfg.Models["gpt-4"]
+				model.ContextWindow = tt.contextWindow
+				cfg.Models = map[string]config.ModelConfig{"gpt-4": model}
+			}
+			auditStore := storage.NewMemoryAuditStore()
+			router := newLLMRouter(cfg, storage.NewMemoryUsageStore(), auditStore)
+
+			body, _ := json.Marshal(tt.body)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(string(body))))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("POST %s status = %d, want %d: %s", tt.path, w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			entries, err := auditStore.ListByUser(context.Background(), "acme", "user-123")
+			if err != nil {
+				t.Fatalf("ListByUser() error = %v", err)
+			}
+			if len(entries) != 1 {
+				t.Fatalf("%d audit entries, want 1", len(entries))
+			}
+			if got := entries[0].Metadata["request_tokens"]; got != tt.wantRequestTokens {
+				t.Errorf("audit request_tokens = %v, want %d", got, tt.wantRequestTokens)
+			}
+		})
+	}
+}
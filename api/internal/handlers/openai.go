@@ -0,0 +1,310 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/middlewares"
+	"github.com/secura/api/internal/tokenizer"
+)
+
+// Headers that carry the data subject and processing purpose of a request to
+// the OpenAI-compatible endpoints. They may also be sent as subject_id and
+// purpose body fields, which are removed before forwarding.
+const (
+	SubjectIDHeader = "X-Secura-Subject-ID"
+	PurposeHeader   = "X-Secura-Purpose"
+)
+
+// proxyRequest is an OpenAI request passed through the gateway pipeline. The
+// body is forwarded as received apart from the anonymized text fields, so
+// parameters the gateway does not inspect (tools, response_format, n, stop,
+// logprobs, ...) reach the provider unchanged.
+type proxyRequest struct {
+	action   string
+	endpoint string
+	model    string
+	body     map[string]interface{}
+	fields   []textField
+	chat     bool
+}
+
+// openAIHandler serves the OpenAI-compatible endpoints
+type openAIHandler struct {
+	cfgStore *config.Store
+	pipeline *Pipeline
+}
+
+// SetupOpenAIHandlers registers the OpenAI-compatible endpoints, so OpenAI
+// SDKs pointed at the gateway get anonymization, policies and auditing
+func SetupOpenAIHandlers(router *gin.RouterGroup, cfgStore *config.Store, pipeline *Pipeline) {
+	h := &openAIHandler{
+		cfgStore: cfgStore,
+		pipeline: pipeline,
+	}
+
+	// Set up routes
+	router.GET("/models", h.listModels)
+	router.GET("/models/*model", h.getModel)
+	router.POST("/chat/completions", middlewares.RequirePermission(cfgStore, middlewares.PermissionLLMChat), h.chatCompletions)
+	router.POST("/completions", middlewares.RequirePermission(cfgStore, middlewares.PermissionLLMCompletion), h.completions)
+	router.POST("/embeddings", middlewares.RequirePermission(cfgStore, middlewares.PermissionLLMEmbeddings), h.embeddings)
+}
+
+// chatCompletions handles POST /v1/chat/completions
+func (h *openAIHandler) chatCompletions(c *gin.Context) {
+	body, model, ok := h.bindBody(c)
+	if !ok {
+		return
+	}
+
+	messages, ok := body["messages"].([]interface{})
+	if !ok || len(messages) == 0 {
+		middlewares.RespondError(c, http.StatusBadRequest, "messages must be a non-empty array")
+		return
+	}
+	fields, err := chatTextFields(messages)
+	if err != nil {
+		middlewares.RespondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.proxy(c, proxyRequest{action: "chat", endpoint: "/chat/completions", model: model, body: body, fields: fields, chat: true})
+}
+
+// completions handles POST /v1/completions
+func (h *openAIHandler) completions(c *gin.Context) {
+	body, model, ok := h.bindBody(c)
+	if !ok {
+		return
+	}
+
+	fields, err := stringTextFields(body, "prompt")
+	if err != nil {
+		middlewares.RespondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.proxy(c, proxyRequest{action: "completion", endpoint: "/completions", model: model, body: body, fields: fields})
+}
+
+// embeddings handles POST /v1/embeddings
+func (h *openAIHandler) embeddings(c *gin.Context) {
+	body, model, ok := h.bindBody(c)
+	if !ok {
+		return
+	}
+
+	fields, err := stringTextFields(body, "input")
+	if err != nil {
+		middlewares.RespondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.proxy(c, proxyRequest{action: "embedding", endpoint: "/embeddings", model: model, body: body, fields: fields})
+}
+
+// listModels handles GET /v1/models, listing the models the caller's tenant may use
+func (h *openAIHandler) listModels(c *gin.Context) {
+	snapshot := h.cfgStore.Current()
+	tenant := c.GetString("tenant")
+	policies := snapshot.PoliciesFor(tenant)
+
+	names := make(map[string]bool)
+	for name := range snapshot.Models {
+		names[name] = true
+	}
+	for name := range snapshot.Routing.Routes {
+		names[name] = true
+	}
+	for _, name := range policies.AllowedModels {
+		names[name] = true
+	}
+
+	data := []gin.H{}
+	for name := range names {
+		if !policies.IsModelAllowed(name) {
+			continue
+		}
+		// Leave out models the tenant's data residency rules make unavailable
+		if _, err := snapshot.DeploymentsFor(name, tenant); err != nil {
+			continue
+		}
+		data = append(data, openAIModel(snapshot, name))
+	}
+	sort.Slice(data, func(i, j int) bool {
+		return data[i]["id"].(string) < data[j]["id"].(string)
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"object": "list",
+		"data":   data,
+	})
+}
+
+// getModel handles GET /v1/models/{model}
+func (h *openAIHandler) getModel(c *gin.Context) {
+	snapshot := h.cfgStore.Current()
+	tenant := c.GetString("tenant")
+	policies := snapshot.PoliciesFor(tenant)
+	name := strings.TrimPrefix(c.Param("model"), "/")
+
+	_, configured := snapshot.Models[name]
+	if _, routed := snapshot.Routing.Routes[name]; routed {
+		configured = true
+	}
+	_, err := snapshot.DeploymentsFor(name, tenant)
+	if !policies.IsModelAllowed(name) || (!configured && len(policies.AllowedModels) == 0) || err != nil {
+		middlewares.RespondError(c, http.StatusNotFound, "The model '"+name+"' does not exist")
+		return
+	}
+	c.JSON(http.StatusOK, openAIModel(snapshot, name))
+}
+
+// openAIModel describes a model in the OpenAI model object format
+func openAIModel(cfg *config.Config, name string) gin.H {
+	owner := "openai"
+	if model, ok := cfg.Models[name]; ok && model.Provider != "" {
+		owner = model.Provider
+	}
+	return gin.H{
+		"id":       name,
+		"object":   "model",
+		"created":  0,
+		"owned_by": owner,
+	}
+}
+
+// bindBody decodes an OpenAI request body, keeping numbers as written so they
+// are forwarded without loss of precision
+func (h *openAIHandler) bindBody(c *gin.Context) (map[string]interface{}, string, bool) {
+	var body map[string]interface{}
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&body); err != nil || body == nil {
+		middlewares.RespondError(c, http.StatusBadRequest, "Invalid request body")
+		return nil, "", false
+	}
+
+	model, _ := body["model"].(string)
+	if model == "" {
+		middlewares.RespondError(c, http.StatusBadRequest, "model is required")
+		return nil, "", false
+	}
+	if stream, _ := body["stream"].(bool); stream {
+		middlewares.RespondError(c, http.StatusBadRequest, "Streaming is not supported by the gateway")
+		return nil, "", false
+	}
+	return body, model, true
+}
+
+// proxy runs an OpenAI request through the gateway pipeline. The provider
+// response is returned in the OpenAI format.
+func (h *openAIHandler) proxy(c *gin.Context, req proxyRequest) {
+	// Take the data subject and purpose from the headers or the gateway body fields
+	subjectID, purpose := c.GetHeader(SubjectIDHeader), c.GetHeader(PurposeHeader)
+	if value, ok := req.body["subject_id"].(string); ok && subjectID == "" {
+		subjectID = value
+	}
+	if value, ok := req.body["purpose"].(string); ok && purpose == "" {
+		purpose = value
+	}
+	delete(req.body, "subject_id")
+	delete(req.body, "purpose")
+
+	run := llmRequest{
+		action:    req.action,
+		endpoint:  req.endpoint,
+		model:     req.model,
+		subjectID: subjectID,
+		purpose:   purpose,
+		maxTokens: requestedMaxTokens(req.body),
+		body:      req.body,
+		fields:    req.fields,
+		prompt:    true,
+		countTokens: func() (int, error) {
+			return countFieldTokens(req.model, req.fields, req.chat)
+		},
+		metadata: map[string]interface{}{
+			"api":        "openai",
+			"api_key_id": c.GetString("apiKeyID"),
+			"inputs":     len(req.fields),
+		},
+	}
+
+	// Embedding inputs skip the prompt checks and their vectors stay out of the audit trail
+	if req.action == "embedding" {
+		run = embeddingRequest(run)
+	}
+
+	h.pipeline.run(c, run)
+}
+
+// stringTextFields returns the texts of a field that holds a string or an
+// array of strings, such as a completion prompt or embedding input. Token
+// arrays are rejected because they cannot be anonymized.
+func stringTextFields(body map[string]interface{}, key string) ([]textField, error) {
+	switch value := body[key].(type) {
+	case string:
+		return []textField{{text: value, set: func(text string) {
+			body[key] = text
+		}}}, nil
+	case []interface{}:
+		if len(value) == 0 {
+			return nil, fmt.Errorf("%s must not be empty", key)
+		}
+		fields := make([]textField, 0, len(value))
+		for i, item := range value {
+			text, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a string or an array of strings; token arrays are not supported", key)
+			}
+			i := i
+			fields = append(fields, textField{text: text, set: func(text string) {
+				value[i] = text
+			}})
+		}
+		return fields, nil
+	default:
+		return nil, fmt.Errorf("%s is required", key)
+	}
+}
+
+// countFieldTokens counts the prompt tokens of the texts of a request
+func countFieldTokens(model string, fields []textField, chat bool) (int, error) {
+	if chat {
+		messages := make([]tokenizer.Message, len(fields))
+		for i, field := range fields {
+			messages[i] = tokenizer.Message{Role: field.role, Content: field.text}
+		}
+		return tokenizer.CountChatTokens(model, messages)
+	}
+
+	total := 0
+	for _, field := range fields {
+		count, err := tokenizer.CountTokens(model, field.text)
+		if err != nil {
+			return 0, err
+		}
+		total += count
+	}
+	return total, nil
+}
+
+// requestedMaxTokens returns the completion token limit of a request, or 0 if none is set
+func requestedMaxTokens(body map[string]interface{}) int {
+	for _, key := range []string{"max_completion_tokens", "max_tokens"} {
+		if value, ok := body[key].(json.Number); ok {
+			if n, err := value.Int64(); err == nil && n > 0 {
+				return int(n)
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/metrics"
+	"github.com/secura/api/internal/services"
+)
+
+// responseScan is the result of scanning a provider response for sensitive data
+type responseScan struct {
+	// Entities counts the sensitive entities detected, by type
+	Entities map[string]int
+	// Total is the number of sensitive entities detected
+	Total int
+	// Blocked reports whether the output policy rejects the response
+	Blocked bool
+}
+
+// scanResponse runs the anonymizer over the generated text of a provider
+// response and applies the output policy. With the redact action the text is
+// replaced by its anonymized version in place. Scanning errors are returned so
+// the caller can fail closed.
+func scanResponse(ctx context.Context, anonService *services.AnonymizationService, policy config.OutputPolicy, resp map[string]interface{}) (responseScan, error) {
+	scan := responseScan{Entities: map[string]int{}}
+	if !policy.Enabled() {
+		return scan, nil
+	}
+
+	choices, _ := resp["choices"].([]interface{})
+	for _, raw := range choices {
+		choice, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		// Completions carry the text on the choice, chat completions on its message
+		target, key := choice, "text"
+		if message, ok := choice["message"].(map[string]interface{}); ok {
+			target, key = message, "content"
+		}
+		text, ok := target[key].(string)
+		if !ok || text == "" {
+			continue
+		}
+
+		anonymized, entities, err := anonService.Anonymize(ctx, text)
+		if err != nil {
+			return scan, err
+		}
+		for _, entity := range entities {
+			scan.Entities[entity.Type]++
+			scan.Total++
+		}
+		if len(entities) > 0 && policy.Action == config.OutputActionRedact {
+			target[key] = anonymized
+		}
+	}
+
+	if scan.Total > 0 {
+		scan.Blocked = policy.Action == config.OutputActionBlock
+		metrics.OutputPolicyActionsTotal.WithLabelValues(policy.Action).Inc()
+	}
+	return scan, nil
+}
+
+// auditStatus returns the outcome recorded in the audit trail for a response
+// that passed through the output policy
+func (s responseScan) auditStatus() string {
+	if s.Blocked {
+		return auditStatusBlocked
+	}
+	return auditStatusSuccess
+}
+
+// blockedAuditResponse returns the part of a blocked response recorded in the
+// audit trail. The generated text is left out: it holds the sensitive data the
+// output policy kept from the caller.
+func blockedAuditResponse(resp map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"id":    resp["id"],
+		"model": resp["model"],
+		"usage": resp["usage"],
+	}
+}
+
+// setResponseScanHeaders tells the caller that sensitive data was found in the
+// response and which output policy action was applied
+func setResponseScanHeaders(c *gin.Context, policy config.OutputPolicy, scan responseScan) {
+	if scan.Total == 0 {
+		return
+	}
+	c.Header("X-Response-Entities-Detected", strconv.Itoa(scan.Total))
+	c.Header("X-Output-Policy-Action", policy.Action)
+}
@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/logging"
+	"github.com/secura/api/internal/metrics"
+	"github.com/secura/api/internal/middlewares"
+	"github.com/secura/api/internal/providers"
+	"github.com/secura/api/internal/services"
+	"github.com/secura/api/internal/storage"
+)
+
+// Pipeline runs LLM requests through the gateway: model policy, data
+// residency, consent, prompt injection guardrail, quotas, anonymization,
+// forwarding, usage accounting, output policy and auditing. The native and
+// OpenAI-compatible endpoints share one pipeline.
+type Pipeline struct {
+	cfgStore          *config.Store
+	logger            *zap.Logger
+	usageService      *services.UsageService
+	consentService    *services.ConsentService
+	auditStore        storage.AuditStore
+	pseudonymVault    storage.PseudonymVault
+	modelRouter       *providers.Router
+	anonService       *services.AnonymizationService
+	blockchainService *services.BlockchainService
+}
+
+// NewPipeline creates the pipeline serving the LLM endpoints. blockchainService
+// is nil if interactions are not recorded on-chain.
+func NewPipeline(cfgStore *config.Store, logger *zap.Logger, usageService *services.UsageService, consentService *services.ConsentService, auditStore storage.AuditStore, pseudonymVault storage.PseudonymVault, modelRouter *providers.Router, blockchainService *services.BlockchainService) *Pipeline {
+	return &Pipeline{
+		cfgStore:          cfgStore,
+		logger:            logger,
+		usageService:      usageService,
+		consentService:    consentService,
+		auditStore:        auditStore,
+		pseudonymVault:    pseudonymVault,
+		modelRouter:       modelRouter,
+		anonService:       services.NewAnonymizationService(cfgStore.Current().NLPServiceURL),
+		blockchainService: blockchainService,
+	}
+}
+
+// llmRequest is a request run through the pipeline
+type llmRequest struct {
+	action    string // Audit action type: completion, chat or embedding
+	endpoint  string // Provider endpoint the request is forwarded to
+	model     string
+	subjectID string
+	purpose   string
+	maxTokens int
+
+	// body is forwarded to the provider. The setters of fields update it
+	// when the texts are anonymized.
+	body   map[string]interface{}
+	fields []textField
+
+	// prompt reports whether the texts instruct a model. Prompts are checked
+	// by the guardrail and against the context window, and their responses
+	// by the output policy.
+	prompt bool
+
+	// countTokens counts the prompt tokens once the texts are anonymized
+	countTokens func() (int, error)
+
+	// metadata is added to the audit metadata of the request
+	metadata map[string]interface{}
+
+	// responseMetadata adds audit metadata taken from the provider response
+	responseMetadata func(metadata map[string]interface{}, resp map[string]interface{})
+
+	// auditResponse returns the part of the response recorded in the audit
+	// trail. The whole response is recorded if it is nil.
+	auditResponse func(resp map[string]interface{}) map[string]interface{}
+}
+
+// run processes a request and writes the provider response, or the error
+// that stopped the request, to the caller
+func (p *Pipeline) run(c *gin.Context, req llmRequest) {
+	reqLogger := logging.FromContext(c.Request.Context(), p.logger)
+
+	// Read policies, models and limits once so the request uses a single snapshot
+	snapshot := p.cfgStore.Current()
+
+	userID := c.GetString("userID")
+	tenant := c.GetString("tenant")
+	team := c.GetString("team")
+	policies := snapshot.PoliciesFor(tenant)
+	reqLogger.Info("Processing LLM request",
+		zap.String("user_id", userID),
+		zap.String("action", req.action),
+		zap.String("model", req.model),
+		zap.Int("inputs", len(req.fields)),
+	)
+
+	// Enforce the allowed models policy
+	if !policies.IsModelAllowed(req.model) {
+		middlewares.RespondError(c, http.StatusForbidden, "Model "+req.model+" is not allowed")
+		return
+	}
+
+	// Keep the tenant's data within its data residency regions
+	if _, err := snapshot.DeploymentsFor(req.model, tenant); err != nil {
+		reqLogger.Warn("Request blocked by data residency rules", zap.String("user_id", userID), zap.Error(err))
+		middlewares.RespondError(c, http.StatusForbidden, "Model "+req.model+" is not available in the tenant's data residency region")
+		return
+	}
+
+	// Block forwarding without valid data subject consent
+	consent, ok := checkConsent(c, p.consentService, policies.Consent, req.subjectID, req.purpose)
+	if !ok {
+		reqLogger.Warn("Request blocked without valid consent", zap.String("user_id", userID), zap.String("purpose", req.purpose))
+		return
+	}
+
+	// Check prompts for prompt injection and jailbreak attempts
+	guard := promptCheck{Action: guardrailPass}
+	if req.prompt {
+		guard = checkPrompts(policies.Guardrail, guardedTexts(req.fields)...)
+	}
+	setGuardrailHeaders(c, guard)
+	if guard.Action != guardrailPass {
+		reqLogger.Warn("Prompt injection detected",
+			zap.String("user_id", userID),
+			zap.Float64("score", guard.Verdict.Score),
+			zap.Strings("signals", guard.Verdict.Rules()),
+			zap.String("action", guard.Action),
+		)
+	}
+	if guard.Action == guardrailBlock {
+		recordGuardrailBlock(c, p.logger, p.blockchainService, p.auditStore, snapshot.Timeouts.Audit, userID, req.subjectID, req.model, guard)
+		middlewares.RespondAPIError(c, guardrailError())
+		return
+	}
+
+	// Enforce hard usage quotas before doing any work
+	if err := p.usageService.CheckQuota(c.Request.Context(), userID, tenant, team); err != nil {
+		reqLogger.Warn("Usage quota exceeded", zap.String("user_id", userID), zap.Error(err))
+		respondQuotaExceeded(c, err)
+		return
+	}
+
+	// Anonymize every text in place, including tool call arguments and tool
+	// results, if the policy requires it
+	anonymization := snapshot.AnonymizationFor(req.model, tenant)
+	anonymized := anonymization.Mode != config.AnonymizationModeSkip
+	if anonymized {
+		ctx, cancel := stageContext(c.Request.Context(), snapshot.Timeouts.Anonymization)
+		err := anonymizeFields(ctx, p.anonService, p.pseudonymVault, tenant, req.subjectID, req.fields, anonymization.KeepEntities)
+		cancel()
+		if err != nil {
+			reqLogger.Error("Failed to anonymize request", zap.Error(err))
+			middlewares.RespondAPIError(c, stageError(err, anonymizationTimeoutMessage))
+			return
+		}
+	}
+
+	// Count prompt tokens and check the model's context window before forwarding
+	promptTokenCount, err := req.countTokens()
+	if err != nil {
+		reqLogger.Error("Failed to count prompt tokens", zap.Error(err))
+		middlewares.RespondError(c, http.StatusInternalServerError, "Failed to process request")
+		return
+	}
+	if req.prompt {
+		if err := checkContextWindow(snapshot, req.model, promptTokenCount, req.maxTokens); err != nil {
+			middlewares.RespondError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	// Reserve the prompt tokens and the completion tokens the request may
+	// generate, so concurrent requests cannot jointly overrun a hard quota
+	reservation, err := p.usageService.Reserve(c.Request.Context(), userID, tenant, team, req.model, int64(promptTokenCount), int64(req.maxTokens))
+	if err != nil {
+		reqLogger.Warn("Usage quota exceeded", zap.String("user_id", userID), zap.Error(err))
+		respondQuotaExceeded(c, err)
+		return
+	}
+
+	// Route to the deployments serving the model
+	routed, err := p.modelRouter.Forward(c.Request.Context(), snapshot, tenant, req.endpoint, req.body)
+	if err != nil {
+		// Usage is released even if the client has disconnected
+		ctx, cancel := auditContext(c.Request.Context(), snapshot.Timeouts.Audit)
+		if err := p.usageService.Release(ctx, reservation); err != nil {
+			reqLogger.Error("Failed to release reserved usage", zap.String("user_id", userID), zap.Error(err))
+		}
+		cancel()
+
+		metadata := map[string]interface{}{
+			"model":         req.model,
+			"anonymized":    anonymized,
+			"anonymization": anonymization.Mode,
+		}
+		for key, value := range req.metadata {
+			metadata[key] = value
+		}
+		respondUpstreamError(c, p.logger, p.blockchainService, p.auditStore, snapshot.Timeouts.Audit, failedInteraction{
+			userID:     userID,
+			subjectID:  req.subjectID,
+			actionType: req.action,
+			request:    req.body,
+			routed:     routed,
+			metadata:   metadata,
+		}, err)
+		return
+	}
+	resp := routed.Response
+
+	// Settle the reservation with the actual token usage and cost. Responses
+	// the output policy blocks are billed too: the provider has charged for
+	// the tokens.
+	promptTokens, completionTokens, totalTokens := providers.TokenUsage(resp)
+	if promptTokens == 0 {
+		// Fall back to the pre-flight count if the provider did not report usage
+		promptTokens = int64(promptTokenCount)
+		totalTokens = promptTokens + completionTokens
+	}
+	usageCtx, cancelUsage := auditContext(c.Request.Context(), snapshot.Timeouts.Audit)
+	cost, warnings, err := p.usageService.Settle(usageCtx, reservation, promptTokens, completionTokens)
+	cancelUsage()
+	if err != nil {
+		reqLogger.Error("Failed to record usage", zap.String("user_id", userID), zap.Error(err))
+	}
+	for _, warning := range warnings {
+		reqLogger.Warn("Usage soft limit reached", zap.String("user_id", userID), zap.String("warning", warning))
+	}
+	setUsageWarnings(c, warnings)
+	metrics.TokensTotal.WithLabelValues(req.model, "prompt").Add(float64(promptTokens))
+	metrics.TokensTotal.WithLabelValues(req.model, "completion").Add(float64(completionTokens))
+
+	// Scan the model output for sensitive data and apply the output policy
+	var scan responseScan
+	if req.prompt {
+		scanCtx, cancelScan := stageContext(c.Request.Context(), snapshot.Timeouts.Anonymization)
+		scan, err = scanResponse(scanCtx, p.anonService, policies.Output, resp)
+		cancelScan()
+		if err != nil {
+			reqLogger.Error("Failed to scan response", zap.Error(err))
+			middlewares.RespondAPIError(c, stageError(err, anonymizationTimeoutMessage))
+			return
+		}
+		if scan.Total > 0 {
+			reqLogger.Warn("Sensitive data detected in response",
+				zap.Int("entities", scan.Total),
+				zap.String("action", policies.Output.Action),
+			)
+		}
+	}
+
+	// Create the audit metadata
+	metadata := map[string]interface{}{
+		"model":          req.model,
+		"status":         scan.auditStatus(),
+		"anonymized":     anonymized,
+		"anonymization":  anonymization.Mode,
+		"request_tokens": promptTokenCount,
+		"ip_address":     c.ClientIP(),
+		"user_agent":     c.Request.UserAgent(),
+		"request_id":     c.GetString("requestID"),
+		"tenant":         tenant,
+	}
+	for key, value := range req.metadata {
+		metadata[key] = value
+	}
+	if req.responseMetadata != nil {
+		req.responseMetadata(metadata, resp)
+	}
+	addDeploymentMetadata(metadata, routed)
+
+	// Reference the consent the request relied on
+	if consent != nil {
+		metadata["consent_id"] = consent.ID
+		metadata["consent_purpose"] = consent.Purpose
+		if consent.AttestationTx != "" {
+			metadata["consent_attestation_tx"] = consent.AttestationTx
+		}
+	}
+
+	// Add token counts and cost
+	metadata["prompt_tokens"] = promptTokens
+	metadata["completion_tokens"] = completionTokens
+	metadata["total_tokens"] = totalTokens
+	metadata["cost_usd"] = cost
+
+	// Add sensitive data detected in the response and the prompt injection
+	// guardrail verdict
+	if req.prompt {
+		metadata["response_entities_detected"] = scan.Entities
+		if scan.Total > 0 {
+			metadata["response_action"] = policies.Output.Action
+		}
+		guard.addMetadata(metadata)
+	}
+
+	// Record interaction in blockchain if enabled, leaving blocked output out
+	auditResp := resp
+	if req.auditResponse != nil {
+		auditResp = req.auditResponse(resp)
+	}
+	if scan.Blocked {
+		auditResp = blockedAuditResponse(resp)
+	}
+	var txHash string
+	if p.blockchainService != nil {
+		ctx, cancel := auditContext(c.Request.Context(), snapshot.Timeouts.Audit)
+		defer cancel()
+		txHash, err = p.blockchainService.RecordLLMInteraction(
+			ctx,
+			userID,
+			req.action,
+			req.body,
+			auditResp,
+			metadata,
+		)
+		if err != nil {
+			reqLogger.Error("Failed to record audit log", zap.Error(err))
+		} else {
+			reqLogger.Info("Recorded audit log", zap.String("tx_hash", txHash))
+		}
+	}
+
+	// Index the interaction, without a transaction hash if it was not recorded on-chain
+	indexAuditEntry(c, p.logger, p.auditStore, snapshot.Timeouts.Audit, storage.AuditEntry{
+		Tenant:     tenant,
+		UserID:     userID,
+		SubjectID:  req.subjectID,
+		ActionType: req.action,
+		TxHash:     txHash,
+		Timestamp:  time.Now().UTC(),
+		Metadata:   metadata,
+	})
+
+	// Return response to client unless the output policy blocks it
+	setResponseScanHeaders(c, policies.Output, scan)
+	if scan.Blocked {
+		middlewares.RespondError(c, http.StatusUnprocessableEntity, "Response blocked by output policy")
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/secura/api/internal/middlewares"
+	"github.com/secura/api/internal/services"
+	"github.com/secura/api/internal/storage"
+)
+
+// RunRetentionRequest represents a request to run the audit retention purge
+type RunRetentionRequest struct {
+	// DryRun reports what would be purged without deleting anything. Defaults to true.
+	DryRun *bool `json:"dry_run,omitempty"`
+}
+
+// LegalHoldRequest represents a request to place or lift a legal hold on an audit entry
+type LegalHoldRequest struct {
+	Hold   *bool  `json:"hold" binding:"required"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// RunRetention returns a handler that runs the audit retention purge on demand
+func RunRetention(retentionService *services.RetentionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req RunRetentionRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				middlewares.RespondError(c, http.StatusBadRequest, "Invalid request body")
+				return
+			}
+		}
+
+		dryRun := req.DryRun == nil || *req.DryRun
+		report, err := retentionService.Run(c.Request.Context(), dryRun, c.GetString("userID"))
+		if err != nil {
+			middlewares.RespondError(c, http.StatusInternalServerError, "Failed to run audit retention")
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// SetLegalHold returns a handler that places or lifts a legal hold on an audit entry
+func SetLegalHold(auditStore storage.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req LegalHoldRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middlewares.RespondError(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if *req.Hold && req.Reason == "" {
+			middlewares.RespondError(c, http.StatusBadRequest, "reason is required to place a legal hold")
+			return
+		}
+
+		entry, err := auditStore.SetLegalHold(c.Request.Context(), c.GetString("tenant"), c.Param("id"), *req.Hold, req.Reason)
+		if errors.Is(err, storage.ErrAuditEntryNotFound) {
+			middlewares.RespondError(c, http.StatusNotFound, "Audit entry not found")
+			return
+		}
+		if err != nil {
+			middlewares.RespondError(c, http.StatusInternalServerError, "Failed to update legal hold")
+			return
+		}
+
+		c.JSON(http.StatusOK, entry)
+	}
+}
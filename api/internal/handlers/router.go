@@ -1,15 +1,27 @@
 package handlers
 
 import (
+	"database/sql"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
 	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/jobs"
+	"github.com/secura/api/internal/metrics"
 	"github.com/secura/api/internal/middlewares"
+	"github.com/secura/api/internal/providers"
+	"github.com/secura/api/internal/services"
+	"github.com/secura/api/internal/storage"
 )
 
-// SetupRouter configures the Gin router and registers all routes
-func SetupRouter(cfg *config.Config, logger *zap.Logger) *gin.Engine {
+// SetupRouter configures the Gin router, registers all routes and registers
+// background jobs with the scheduler. State is persisted in db, or kept in
+// memory if db is nil.
+func SetupRouter(cfgStore *config.Store, logger *zap.Logger, scheduler *jobs.Scheduler, db *sql.DB) *gin.Engine {
+	cfg := cfgStore.Current()
+
 	// Set Gin mode based on environment
 	if cfg.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -19,7 +31,80 @@ func SetupRouter(cfg *config.Config, logger *zap.Logger) *gin.Engine {
 	router := gin.New()
 
 	// Register global middlewares
-	router.Use(gin.Recovery())
+	router.Use(middlewares.Tracing())
+	router.Use(middlewares.RequestID(logger))
+	router.Use(middlewares.RequestTimeout(cfgStore))
+	router.Use(middlewares.Logger(logger))
+	router.Use(middlewares.Recover())
+	if cfg.MetricsEnabled {
+		router.Use(middlewares.Metrics())
+
+		// Serve metrics on the main port unless a separate admin port is configured
+		if cfg.MetricsPort == "" {
+			router.GET("/metrics", gin.WrapH(metrics.Handler()))
+		}
+	}
+
+	// Create shared services, keeping usage counters in the database when one is configured
+	var usageStore storage.UsageStore = storage.NewMemoryUsageStore()
+	if db != nil {
+		usageStore = storage.NewPostgresUsageStore(db)
+	}
+	usageService := services.NewUsageService(cfgStore, usageStore)
+
+	var blockchainService *services.BlockchainService
+	if cfg.BlockchainNodeURL != "" {
+		var err error
+		blockchainService, err = services.NewBlockchainService(
+			cfg.BlockchainNodeURL,
+			cfg.BlockchainContractAddress,
+			logger,
+		)
+		if err != nil {
+			logger.Error("Failed to initialize blockchain service", zap.Error(err))
+		}
+	}
+
+	// Attest consent on-chain when a consent registry is configured
+	var consentAttester services.ConsentAttester
+	if blockchainService != nil && cfg.ConsentRegistryAddress != "" {
+		if err := blockchainService.EnableConsentRegistry(cfg.ConsentRegistryAddress, cfg.BlockchainPrivateKey, cfg.ConsentSubjectKey); err != nil {
+			logger.Error("Failed to enable consent registry", zap.Error(err))
+		} else if cfg.BlockchainPrivateKey != "" {
+			consentAttester = blockchainService
+		} else {
+			logger.Warn("Consent registry is read-only: no blockchain private key configured")
+		}
+	}
+
+	// Stores of personal data covered by data subject access and erasure requests
+	var userStore storage.UserStore = storage.NewMemoryUserStore()
+	var auditStore storage.AuditStore = storage.NewMemoryAuditStore()
+	var consentStore storage.ConsentStore = storage.NewMemoryConsentStore()
+	var pseudonymVault storage.PseudonymVault = storage.NewMemoryPseudonymVault()
+	if db != nil {
+		userStore = storage.NewPostgresUserStore(db)
+		auditStore = storage.NewPostgresAuditStore(db)
+		consentStore = storage.NewPostgresConsentStore(db)
+		pseudonymVault = storage.NewPostgresPseudonymVault(db)
+	}
+	consentService := services.NewConsentService(consentStore, consentAttester)
+	apiKeyService := services.NewAPIKeyService(userStore)
+	modelRouter := providers.NewRouter(logger)
+	pipeline := NewPipeline(cfgStore, logger, usageService, consentService, auditStore, pseudonymVault, modelRouter, blockchainService)
+	dsarService := services.NewDSARService(cfgStore, userStore, auditStore, pseudonymVault, consentService, blockchainService, logger)
+
+	// Purge audit entries past their retention period
+	retentionService := services.NewRetentionService(cfgStore, auditStore, blockchainService, logger)
+	scheduler.Register(jobs.Job{
+		Name:     "audit_retention",
+		Interval: cfg.Retention.Interval,
+		Run:      retentionService.RunScheduled,
+	})
+
+	// Liveness and readiness probes
+	router.GET("/health/live", Liveness())
+	router.GET("/health/ready", Readiness(NewReadinessChecks(cfgStore, blockchainService, db)))
 
 	// Define routes
 	v1 := router.Group("/api/v1")
@@ -27,29 +112,80 @@ func SetupRouter(cfg *config.Config, logger *zap.Logger) *gin.Engine {
 		// Public routes
 		public := v1.Group("/")
 		{
-			public.POST("/auth/login", Login(cfg))
+			public.POST("/auth/login", Login(cfgStore, userStore))
 			public.GET("/health", HealthCheck(cfg))
 		}
 
 		// Protected routes
 		protected := v1.Group("/")
-		protected.Use(middlewares.JWTAuth(cfg))
+		protected.Use(middlewares.JWTAuth(cfgStore), middlewares.RequireTenant(cfgStore))
 		{
 			// User routes
-			protected.GET("/user", GetUser())
+			protected.GET("/user", GetUser(userStore))
+
+			// API key routes
+			apiKeyRoutes := protected.Group("/api-keys")
+			{
+				apiKeyRoutes.POST("", CreateAPIKey(apiKeyService))
+				apiKeyRoutes.GET("", ListAPIKeys(apiKeyService))
+				apiKeyRoutes.DELETE("/:id", RevokeAPIKey(apiKeyService))
+			}
+
+			// Usage routes
+			protected.GET("/usage", middlewares.RequirePermission(cfgStore, middlewares.PermissionUsageRead), GetUsage(usageService))
+
+			// Consent routes
+			consentRoutes := protected.Group("/consents")
+			{
+				consentRoutes.POST("", middlewares.RequirePermission(cfgStore, middlewares.PermissionConsentWrite), GrantConsent(consentService))
+				consentRoutes.POST("/withdraw", middlewares.RequirePermission(cfgStore, middlewares.PermissionConsentWrite), WithdrawConsent(consentService))
+				consentRoutes.GET("/:subject_id", middlewares.RequirePermission(cfgStore, middlewares.PermissionConsentRead), ListConsents(consentService))
+				consentRoutes.GET("/:subject_id/attestation", middlewares.RequirePermission(cfgStore, middlewares.PermissionConsentRead), GetConsentAttestation(blockchainService))
+			}
 
 			// LLM routes
 			llmRoutes := protected.Group("/llm")
 			{
-				llmRoutes.POST("/completion", LLMCompletion(cfg, logger))
-				llmRoutes.POST("/chat", LLMChat(cfg, logger))
+				llmRoutes.POST("/completion", middlewares.RequirePermission(cfgStore, middlewares.PermissionLLMCompletion), LLMCompletion(pipeline))
+				llmRoutes.POST("/chat", middlewares.RequirePermission(cfgStore, middlewares.PermissionLLMChat), LLMChat(pipeline))
+				llmRoutes.POST("/embeddings", middlewares.RequirePermission(cfgStore, middlewares.PermissionLLMEmbeddings), LLMEmbeddings(pipeline))
 			}
 
-			// Audit routes with blockchain integration
+			// Data subject access and erasure routes
+			dsarRoutes := protected.Group("/admin/dsar")
+			{
+				dsarRoutes.POST("/export", middlewares.RequirePermission(cfgStore, middlewares.PermissionDSARExport), ExportSubjectData(dsarService))
+				dsarRoutes.POST("/erase", middlewares.RequirePermission(cfgStore, middlewares.PermissionDSARErase), EraseSubjectData(dsarService))
+			}
+
+			// Audit retention routes
+			retentionRoutes := protected.Group("/admin")
+			retentionRoutes.Use(middlewares.RequirePermission(cfgStore, middlewares.PermissionRetention))
+			{
+				retentionRoutes.POST("/retention/run", RunRetention(retentionService))
+				retentionRoutes.PUT("/audit/entries/:id/legal-hold", SetLegalHold(auditStore))
+			}
+
+			// Audit routes, served from the off-chain audit index
 			auditRoutes := protected.Group("/audit")
-			SetupAuditHandlers(auditRoutes, cfg, logger)
+			auditRoutes.Use(middlewares.RequirePermission(cfgStore, middlewares.PermissionAuditRead))
+			SetupAuditHandlers(auditRoutes, auditStore)
 		}
 	}
 
+	// OpenAI-compatible routes for OpenAI SDKs, authenticated by API key
+	openAIRoutes := router.Group("/v1")
+	openAIRoutes.Use(middlewares.OpenAIErrors(), middlewares.APIKeyAuth(cfgStore, apiKeyService), middlewares.RequireTenant(cfgStore))
+	SetupOpenAIHandlers(openAIRoutes, cfgStore, pipeline)
+
+	// Unknown routes get the same JSON error bodies as handlers
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(func(c *gin.Context) {
+		middlewares.RespondError(c, http.StatusNotFound, "Route not found")
+	})
+	router.NoMethod(func(c *gin.Context) {
+		middlewares.RespondError(c, http.StatusMethodNotAllowed, "Method not allowed")
+	})
+
 	return router
 }
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/secura/api/internal/middlewares"
+	"github.com/secura/api/internal/services"
+)
+
+// GetUsage returns a handler for the self-service usage report
+func GetUsage(usageService *services.UsageService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get user ID from context
+		userID, exists := c.Get("userID")
+		if !exists {
+			middlewares.RespondError(c, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		report, err := usageService.Report(c.Request.Context(), userID.(string), c.GetString("tenant"), c.GetString("team"))
+		if err != nil {
+			middlewares.RespondError(c, http.StatusInternalServerError, "Failed to get usage")
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// respondQuotaExceeded writes the error response for an exceeded hard quota.
+// Exhausted cost budgets return 402, exhausted token quotas return 429.
+// Models without a price are refused with 403 while a cost quota applies.
+func respondQuotaExceeded(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrModelUnpriced) {
+		middlewares.RespondError(c, http.StatusForbidden, "Model has no configured price; cost quotas cannot be enforced")
+		return
+	}
+
+	var quotaErr *services.QuotaError
+	if !errors.As(err, &quotaErr) {
+		middlewares.RespondError(c, http.StatusInternalServerError, "Failed to process request")
+		return
+	}
+
+	status := http.StatusTooManyRequests
+	if quotaErr.Metric == services.QuotaMetricCost {
+		status = http.StatusPaymentRequired
+	}
+
+	apiErr := middlewares.NewAPIError(status, "Usage quota exceeded")
+	apiErr.Code = middlewares.ErrorCodeQuotaExceeded
+	body := middlewares.ErrorBody(c, apiErr)
+	body["quota"] = quotaErr
+	c.AbortWithStatusJSON(status, body)
+}
+
+// setUsageWarnings exposes soft-limit warnings to the client
+func setUsageWarnings(c *gin.Context, warnings []string) {
+	if len(warnings) > 0 {
+		c.Header("X-Usage-Warning", strings.Join(warnings, "; "))
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/providers"
+	"github.com/secura/api/internal/services"
+	"github.com/secura/api/internal/storage"
+)
+
+// completionFixture is a provider completion reporting 10 prompt and 20 completion tokens
+const completionFixture = `{
+	"id": "cmpl-1",
+	"object": "text_completion",
+	"model": "gpt-4",
+	"choices": [{"index": 0, "text": "Hello there", "finish_reason": "stop"}],
+	"usage": {"prompt_tokens": 10, "completion_tokens": 20, "total_tokens": 30}
+}`
+
+// newProviderServer starts a stand-in for the OpenAI API that answers every
+// request with the JSON fixture and counts the requests it received
+func newProviderServer(t *testing.T, fixture string) (*httptest.Server, *int64) {
+	t.Helper()
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(fixture))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// newTestConfig returns a configuration forwarding to providerURL without
+// anonymization, so requests need no NLP service
+func newTestConfig(providerURL string) *config.Config {
+	cfg := config.Defaults()
+	cfg.OpenAIBaseURL = providerURL
+	cfg.OpenAIAPIKey = "sk-test"
+	cfg.Policies.Anonymization.Enabled = false
+	return cfg
+}
+
+// newLLMRouter serves the native LLM routes through a pipeline over cfg to
+// user-123, keeping usage in usageStore and the audit trail in auditStore
+func newLLMRouter(cfg *config.Config, usageStore storage.UsageStore, auditStore storage.AuditStore) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	cfgStore := config.NewStore(cfg)
+	logger := zap.NewNop()
+	pipeline := NewPipeline(
+		cfgStore,
+		logger,
+		services.NewUsageService(cfgStore, usageStore),
+		services.NewConsentService(storage.NewMemoryConsentStore(), nil),
+		auditStore,
+		storage.NewMemoryPseudonymVault(),
+		providers.NewRouter(logger),
+		nil,
+	)
+
+	router := gin.New()
+	group := router.Group("/llm", func(c *gin.Context) {
+		c.Set("userID", "user-123")
+		c.Set("tenant", "acme")
+		c.Next()
+	})
+	group.POST("/completion", LLMCompletion(pipeline))
+	group.POST("/chat", LLMChat(pipeline))
+	group.POST("/embeddings", LLMEmbeddings(pipeline))
+	return router
+}
+
+func TestLLMCompletionQuotas(t *testing.T) {
+	tests := []struct {
+		name        string
+		limits      config.UsageLimits
+		used        storage.UsageCounter
+		model       string
+		maxTokens   int
+		wantStatus  int
+		wantMetric  string
+		wantWarning string
+	}{
+		{name: "within quotas", limits: config.UsageLimits{DailyTokens: 1000, DailyCost: 1}, model: "gpt-4", wantStatus: http.StatusOK},
+		{name: "cost quota exhausted", limits: config.UsageLimits{DailyCost: 1}, used: storage.UsageCounter{Cost: 1}, model: "gpt-4", wantStatus: http.StatusPaymentRequired, wantMetric: services.QuotaMetricCost},
+		{name: "token quota exhausted", limits: config.UsageLimits{DailyTokens: 1000}, used: storage.UsageCounter{TotalTokens: 1000}, model: "gpt-4", wantStatus: http.StatusTooManyRequests, wantMetric: services.QuotaMetricTokens},
+		{name: "max tokens overrun token quota", limits: config.UsageLimits{DailyTokens: 1000}, used: storage.UsageCounter{TotalTokens: 500}, model: "gpt-4", maxTokens: 600, wantStatus: http.StatusTooManyRequests, wantMetric: services.QuotaMetricTokens},
+		{name: "unpriced model under cost quota", limits: config.UsageLimits{MonthlyCost: 100}, model: "unpriced-model", wantStatus: http.StatusForbidden},
+		{name: "soft limit reached", limits: config.UsageLimits{DailyTokens: 1000}, used: storage.UsageCounter{TotalTokens: 780}, model: "gpt-4", wantStatus: http.StatusOK, wantWarning: "user:user-123 has used 810 of 1000 daily tokens"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newProviderServer(t, completionFixture)
+			cfg := newTestConfig(server.URL)
+			cfg.UserUsageLimits = tt.limits
+
+			ctx := context.Background()
+			usageStore := storage.NewMemoryUsageStore()
+			now := time.Now().UTC()
+			for _, period := range []string{"day:" + now.Format("2006-01-02"), "month:" + now.Format("2006-01")} {
+				if _, err := usageStore.Add(ctx, "user:user-123", period, tt.used); err != nil {
+					t.Fatalf("Add() error = %v", err)
+				}
+			}
+			router := newLLMRouter(cfg, usageStore, storage.NewMemoryAuditStore())
+
+			body, _ := json.Marshal(map[string]interface{}{"model": tt.model, "prompt": "Say hello", "max_tokens": tt.maxTokens})
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/llm/completion", strings.NewReader(string(body))))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("POST /llm/completion status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if got := w.Header().Get("X-Usage-Warning"); got != tt.wantWarning {
+				t.Errorf("X-Usage-Warning = %q, want %q", got, tt.wantWarning)
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				if forwarded := atomic.LoadInt64(requests); forwarded != 0 {
+					t.Errorf("refused request was forwarded %d times", forwarded)
+				}
+			}
+			if tt.wantMetric != "" {
+				var resp struct {
+					Code  string              `json:"code"`
+					Quota services.QuotaError `json:"quota"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if resp.Code != "quota_exceeded" || resp.Quota.Metric != tt.wantMetric || resp.Quota.Scope != "user:user-123" {
+					t.Errorf("response = %s, want a %s quota error for the user", w.Body.String(), tt.wantMetric)
+				}
+			}
+
+			// Refused requests release their reservation, served ones are billed for the reported usage
+			daily, _ := usageStore.Get(ctx, "user:user-123", "day:"+now.Format("2006-01-02"))
+			want := tt.used.TotalTokens
+			if tt.wantStatus == http.StatusOK {
+				want += 30
+			}
+			if daily.TotalTokens != want {
+				t.Errorf("daily tokens = %d, want %d", daily.TotalTokens, want)
+			}
+		})
+	}
+}
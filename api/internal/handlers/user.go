@@ -1,31 +1,43 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/secura/api/internal/middlewares"
 	"github.com/secura/api/internal/models"
+	"github.com/secura/api/internal/storage"
 )
 
 // GetUser returns a handler for retrieving the current user
-func GetUser() gin.HandlerFunc {
+func GetUser(userStore storage.UserStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get user ID from context
 		userID, exists := c.Get("userID")
 		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Unauthorized",
-			})
+			middlewares.RespondError(c, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		record, err := userStore.Get(c.Request.Context(), userID.(string))
+		if errors.Is(err, storage.ErrUserNotFound) {
+			middlewares.RespondError(c, http.StatusNotFound, "User not found")
+			return
+		}
+		if err != nil {
+			middlewares.RespondError(c, http.StatusInternalServerError, "Failed to get user")
 			return
 		}
 
-		// TODO: Fetch user data from database
-		// For now, just return a mock user
 		user := models.User{
-			ID:       userID.(string),
-			Username: "admin",
-			Email:    "admin@example.com",
-			Role:     "admin",
+			ID:        record.ID,
+			Username:  record.Username,
+			Email:     record.Email,
+			Role:      record.Role,
+			Tenant:    c.GetString("tenant"),
+			CreatedAt: record.CreatedAt.Format(time.RFC3339),
 		}
 
 		c.JSON(http.StatusOK, user)
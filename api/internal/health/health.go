@@ -0,0 +1,123 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Status values reported by checks and reports
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+)
+
+// CheckFunc checks a single dependency and returns an error if it is unhealthy
+type CheckFunc func(ctx context.Context) error
+
+// Result is the outcome of a single dependency check
+type Result struct {
+	Status    string `json:"status"`
+	Critical  bool   `json:"critical"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Report is the aggregated outcome of all dependency checks
+type Report struct {
+	Status     string            `json:"status"`
+	Components map[string]Result `json:"components"`
+}
+
+type check struct {
+	name     string
+	critical bool
+	fn       CheckFunc
+}
+
+// Checker runs registered dependency checks concurrently with a per-check timeout
+type Checker struct {
+	timeout time.Duration
+	checks  []check
+}
+
+// NewChecker creates a new checker with the given per-check timeout
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout: timeout,
+	}
+}
+
+// Register adds a dependency check. A failing critical check marks the
+// service as down; a failing non-critical check only degrades it.
+func (c *Checker) Register(name string, critical bool, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, critical: critical, fn: fn})
+}
+
+// Run executes all checks concurrently and aggregates their results
+func (c *Checker) Run(ctx context.Context) Report {
+	results := make(map[string]Result, len(c.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, chk := range c.checks {
+		wg.Add(1)
+		go func(chk check) {
+			defer wg.Done()
+			result := c.runCheck(ctx, chk)
+
+			mu.Lock()
+			results[chk.name] = result
+			mu.Unlock()
+		}(chk)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:     StatusOK,
+		Components: results,
+	}
+	for _, result := range results {
+		if result.Status == StatusUp {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusDown
+			break
+		}
+		report.Status = StatusDegraded
+	}
+	return report
+}
+
+// runCheck runs a single check bounded by the checker timeout
+func (c *Checker) runCheck(ctx context.Context, chk check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- chk.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := Result{
+		Status:    StatusUp,
+		Critical:  chk.critical,
+		LatencyMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
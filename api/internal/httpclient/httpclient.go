@@ -0,0 +1,47 @@
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// Clients for outbound calls. They share tuned transports so connections are
+// pooled across requests, and have no overall timeout: every call is bounded
+// by its context, which carries the stage deadline and is cancelled when the
+// client disconnects.
+var (
+	providerClient = &http.Client{Transport: newTransport(10*time.Second, 64)}
+	serviceClient  = &http.Client{Transport: newTransport(5*time.Second, 32)}
+)
+
+// Providers returns the client for LLM provider APIs
+func Providers() *http.Client {
+	return providerClient
+}
+
+// Services returns the client for internal services such as the
+// anonymization service
+func Services() *http.Client {
+	return serviceClient
+}
+
+// newTransport creates a transport that keeps enough idle connections per
+// host for concurrent requests to the same few hosts. The default transport
+// keeps two, so bursts open and close a connection per request.
+func newTransport(dialTimeout time.Duration, idlePerHost int) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          4 * idlePerHost,
+		MaxIdleConnsPerHost:   idlePerHost,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
@@ -0,0 +1,95 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/secura/api/internal/metrics"
+)
+
+// Job is a background task run periodically by the scheduler
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler runs background jobs in the API process. Each job runs on its own
+// interval and a run never overlaps the previous run of the same job.
+type Scheduler struct {
+	logger *zap.Logger
+	mu     sync.Mutex
+	jobs   []Job
+}
+
+// NewScheduler creates a new job scheduler
+func NewScheduler(logger *zap.Logger) *Scheduler {
+	return &Scheduler{
+		logger: logger,
+	}
+}
+
+// Register adds a job to the scheduler. Jobs with a non-positive interval are ignored.
+func (s *Scheduler) Register(job Job) {
+	if job.Interval <= 0 {
+		s.logger.Info("Job disabled", zap.String("job", job.Name))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs the registered jobs until ctx is done
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	jobs := append([]Job(nil), s.jobs...)
+	s.mu.Unlock()
+
+	for _, job := range jobs {
+		s.logger.Info("Scheduling job", zap.String("job", job.Name), zap.Duration("interval", job.Interval))
+		go s.loop(ctx, job)
+	}
+}
+
+// loop runs a job on its interval until ctx is done
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.run(ctx, job)
+		}
+	}
+}
+
+// run runs a job once, recording its outcome and recovering from panics
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	start := time.Now()
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("job panicked: %v", r)
+			}
+		}()
+		return job.Run(ctx)
+	}()
+
+	if err != nil {
+		s.logger.Error("Job failed", zap.String("job", job.Name), zap.Error(err), zap.Duration("duration", time.Since(start)))
+		metrics.JobRunsTotal.WithLabelValues(job.Name, "failure").Inc()
+		return
+	}
+	s.logger.Info("Job completed", zap.String("job", job.Name), zap.Duration("duration", time.Since(start)))
+	metrics.JobRunsTotal.WithLabelValues(job.Name, "success").Inc()
+	metrics.JobLastSuccess.WithLabelValues(job.Name).SetToCurrentTime()
+}
@@ -0,0 +1,220 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "secura"
+
+// Registry is the Prometheus registry holding all gateway metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequestsTotal counts HTTP requests by route, method and status
+	HTTPRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Total number of HTTP requests handled by the gateway.",
+	}, []string{"route", "method", "status"})
+
+	// HTTPRequestDuration observes HTTP request latency by route, method and status
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency in seconds.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	// ProviderRequestDuration observes upstream LLM provider latency
+	ProviderRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "provider",
+		Name:      "request_duration_seconds",
+		Help:      "Upstream LLM provider request latency in seconds.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60},
+	}, []string{"provider", "model", "status"})
+
+	// ProviderErrorsTotal counts failed upstream LLM provider requests
+	ProviderErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "provider",
+		Name:      "errors_total",
+		Help:      "Total number of failed upstream LLM provider requests.",
+	}, []string{"provider", "model", "reason"})
+
+	// ProviderRetriesTotal counts provider requests retried on the same deployment
+	ProviderRetriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "provider",
+		Name:      "retries_total",
+		Help:      "Total number of provider requests retried on the same deployment, by deployment.",
+	}, []string{"deployment"})
+
+	// ProviderFailoversTotal counts requests failed over from a deployment to the next one
+	ProviderFailoversTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "provider",
+		Name:      "failovers_total",
+		Help:      "Total number of requests failed over to the next deployment, by failed deployment.",
+	}, []string{"deployment"})
+
+	// CircuitBreakerState tracks the circuit breaker of each deployment (0 closed, 1 half-open, 2 open)
+	CircuitBreakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "provider",
+		Name:      "circuit_breaker_state",
+		Help:      "Circuit breaker state by deployment: 0 closed, 1 half-open, 2 open.",
+	}, []string{"deployment"})
+
+	// AnonymizationDuration observes anonymization service latency
+	AnonymizationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "anonymization",
+		Name:      "duration_seconds",
+		Help:      "Anonymization service request latency in seconds.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+	}, []string{"status"})
+
+	// AnonymizedEntitiesTotal counts detected PII entities by type
+	AnonymizedEntitiesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "anonymization",
+		Name:      "entities_total",
+		Help:      "Total number of sensitive entities detected, by entity type.",
+	}, []string{"type"})
+
+	// OutputPolicyActionsTotal counts model responses containing sensitive data, by output policy action
+	OutputPolicyActionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "anonymization",
+		Name:      "output_policy_actions_total",
+		Help:      "Total number of model responses containing sensitive data, by output policy action.",
+	}, []string{"action"})
+
+	// GuardrailVerdictsTotal counts prompts checked for injection, by verdict (pass, flag or block)
+	GuardrailVerdictsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "guardrail",
+		Name:      "verdicts_total",
+		Help:      "Total number of requests checked for prompt injection, by verdict.",
+	}, []string{"verdict"})
+
+	// GuardrailSignalsTotal counts prompt injection signals detected, by category
+	GuardrailSignalsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "guardrail",
+		Name:      "signals_total",
+		Help:      "Total number of prompt injection signals detected, by category.",
+	}, []string{"category"})
+
+	// TokensTotal counts tokens consumed by model and kind (prompt or completion)
+	TokensTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "llm",
+		Name:      "tokens_total",
+		Help:      "Total number of tokens consumed, by model and kind.",
+	}, []string{"model", "kind"})
+
+	// AuditQueueDepth tracks audit records waiting to be written to the blockchain
+	AuditQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "audit",
+		Name:      "queue_depth",
+		Help:      "Number of audit records waiting to be written to the blockchain.",
+	})
+
+	// BlockchainWriteFailuresTotal counts failed audit writes to the blockchain
+	BlockchainWriteFailuresTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "audit",
+		Name:      "blockchain_write_failures_total",
+		Help:      "Total number of audit records that failed to be written to the blockchain.",
+	})
+
+	// ConfigReloadsTotal counts configuration reload attempts by result
+	ConfigReloadsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "config",
+		Name:      "reloads_total",
+		Help:      "Total number of configuration reload attempts, by result.",
+	}, []string{"result"})
+
+	// ConfigLastReloadSuccess records when the configuration was last reloaded successfully
+	ConfigLastReloadSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "config",
+		Name:      "last_reload_success_timestamp_seconds",
+		Help:      "Unix timestamp of the last successful configuration reload.",
+	})
+
+	// SecretRefreshesTotal counts secret refresh attempts by result
+	SecretRefreshesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "secrets",
+		Name:      "refreshes_total",
+		Help:      "Total number of secret refresh attempts, by result.",
+	}, []string{"result"})
+
+	// JobRunsTotal counts background job runs by job and result
+	JobRunsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "jobs",
+		Name:      "runs_total",
+		Help:      "Total number of background job runs, by job and result.",
+	}, []string{"job", "result"})
+
+	// JobLastSuccess records when each background job last completed successfully
+	JobLastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "jobs",
+		Name:      "last_success_timestamp_seconds",
+		Help:      "Unix timestamp of the last successful run, by job.",
+	}, []string{"job"})
+
+	// AuditRecordsPurgedTotal counts audit records purged by retention, by action type
+	AuditRecordsPurgedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "audit",
+		Name:      "records_purged_total",
+		Help:      "Total number of audit records purged by retention, by action type.",
+	}, []string{"action_type"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestsTotal,
+		HTTPRequestDuration,
+		ProviderRequestDuration,
+		ProviderErrorsTotal,
+		ProviderRetriesTotal,
+		ProviderFailoversTotal,
+		CircuitBreakerState,
+		AnonymizationDuration,
+		AnonymizedEntitiesTotal,
+		OutputPolicyActionsTotal,
+		GuardrailVerdictsTotal,
+		GuardrailSignalsTotal,
+		TokensTotal,
+		AuditQueueDepth,
+		BlockchainWriteFailuresTotal,
+		ConfigReloadsTotal,
+		ConfigLastReloadSuccess,
+		SecretRefreshesTotal,
+		JobRunsTotal,
+		JobLastSuccess,
+		AuditRecordsPurgedTotal,
+	)
+}
+
+// Handler returns an HTTP handler that serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
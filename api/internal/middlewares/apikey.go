@@ -0,0 +1,67 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/services"
+	"github.com/secura/api/internal/storage"
+)
+
+// openAIErrorsKey marks requests whose error bodies use the OpenAI format
+const openAIErrorsKey = "openAIErrors"
+
+// APIKeyAuthenticator resolves an API key to its metadata and the user it belongs to
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (storage.APIKeyRecord, storage.UserRecord, error)
+}
+
+// OpenAIErrors returns a middleware that makes error bodies use the
+// OpenAI API format, so OpenAI SDKs pointed at the gateway can parse them
+func OpenAIErrors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(openAIErrorsKey, true)
+		c.Next()
+	}
+}
+
+// APIKeyAuth returns a middleware that authenticates callers by an API key in
+// the Authorization header, as sent by OpenAI SDKs. The caller acts as the
+// key's user with their current role and team, in the tenant the key was
+// issued for.
+func APIKeyAuth(cfgStore *config.Store, authenticator APIKeyAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || key == "" {
+			RespondError(c, http.StatusUnauthorized, "An API key is required in the Authorization header")
+			return
+		}
+
+		record, user, err := authenticator.Authenticate(c.Request.Context(), key)
+		if errors.Is(err, services.ErrInvalidAPIKey) {
+			RespondError(c, http.StatusUnauthorized, "Incorrect API key provided")
+			return
+		}
+		if err != nil {
+			RespondError(c, http.StatusInternalServerError, "Failed to check API key")
+			return
+		}
+
+		c.Set("userID", user.ID)
+		c.Set("role", user.Role)
+		c.Set("apiKeyID", record.ID)
+		if record.Tenant != "" {
+			c.Set("tenant", record.Tenant)
+		}
+		if team := cfgStore.Current().TeamOf(user.ID); team != "" {
+			c.Set("team", team)
+		}
+
+		c.Next()
+	}
+}
@@ -9,24 +9,23 @@ import (
 	"github.com/secura/api/internal/config"
 )
 
-// JWTAuth returns a middleware that validates JWT tokens
-func JWTAuth(cfg *config.Config) gin.HandlerFunc {
+// JWTAuth returns a middleware that validates JWT tokens against the current
+// JWT secret, so a rotated secret takes effect without a restart
+func JWTAuth(cfgStore *config.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		cfg := cfgStore.Current()
+
 		// Get the Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Authorization header is required",
-			})
+			RespondError(c, http.StatusUnauthorized, "Authorization header is required")
 			return
 		}
 
 		// Check if the header has the Bearer prefix
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Authorization header must be in the format 'Bearer {token}'",
-			})
+			RespondError(c, http.StatusUnauthorized, "Authorization header must be in the format 'Bearer {token}'")
 			return
 		}
 
@@ -42,39 +41,52 @@ func JWTAuth(cfg *config.Config) gin.HandlerFunc {
 
 		// Handle parsing errors
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid token: " + err.Error(),
-			})
+			RespondError(c, http.StatusUnauthorized, "Invalid token: "+err.Error())
 			return
 		}
 
 		// Check if the token is valid
 		if !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid token",
-			})
+			RespondError(c, http.StatusUnauthorized, "Invalid token")
 			return
 		}
 
 		// Extract claims
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid token claims",
-			})
+			RespondError(c, http.StatusUnauthorized, "Invalid token claims")
 			return
 		}
 
 		// Set user ID in context
 		userID, ok := claims["sub"].(string)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid user ID in token",
-			})
+			RespondError(c, http.StatusUnauthorized, "Invalid user ID in token")
 			return
 		}
 
 		c.Set("userID", userID)
+
+		// Set role in context if the token carries one
+		if role, ok := claims["role"].(string); ok {
+			c.Set("role", role)
+		}
+
+		// Set team in context from the token, or from team membership for
+		// tokens issued without one
+		team, _ := claims["team"].(string)
+		if team == "" {
+			team = cfg.TeamOf(userID)
+		}
+		if team != "" {
+			c.Set("team", team)
+		}
+
+		// Set tenant in context if the token carries one
+		if tenant, ok := claims["tenant"].(string); ok && tenant != "" {
+			c.Set("tenant", tenant)
+		}
+
 		c.Next()
 	}
 }
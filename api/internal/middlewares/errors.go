@@ -0,0 +1,189 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes of API error bodies
+const (
+	ErrorCodeInvalidRequest         = "invalid_request"
+	ErrorCodeUnauthorized           = "unauthorized"
+	ErrorCodeForbidden              = "forbidden"
+	ErrorCodeNotFound               = "not_found"
+	ErrorCodeMethodNotAllowed       = "method_not_allowed"
+	ErrorCodeConflict               = "conflict"
+	ErrorCodeRequestTooLarge        = "request_too_large"
+	ErrorCodeUnprocessable          = "unprocessable_request"
+	ErrorCodePromptInjection        = "prompt_injection_detected"
+	ErrorCodeRateLimited            = "rate_limited"
+	ErrorCodeQuotaExceeded          = "quota_exceeded"
+	ErrorCodeClientClosedRequest    = "client_closed_request"
+	ErrorCodeInternal               = "internal_error"
+	ErrorCodeUnavailable            = "service_unavailable"
+	ErrorCodeResidencyViolation     = "residency_violation"
+	ErrorCodeNoHealthyDeployment    = "no_healthy_deployment"
+	ErrorCodeUpstreamInvalidRequest = "upstream_invalid_request"
+	ErrorCodeUpstreamAuthentication = "upstream_authentication_failed"
+	ErrorCodeUpstreamNotFound       = "upstream_not_found"
+	ErrorCodeUpstreamRateLimited    = "upstream_rate_limited"
+	ErrorCodeUpstreamTimeout        = "upstream_timeout"
+	ErrorCodeUpstreamUnavailable    = "upstream_unavailable"
+	ErrorCodeUpstreamError          = "upstream_error"
+)
+
+// StatusClientClosedRequest is the non-standard status recorded when the
+// client disconnects before the response is written
+const StatusClientClosedRequest = 499
+
+// OpenAI API error types
+const (
+	OpenAIErrorInvalidRequest = "invalid_request_error"
+	OpenAIErrorAuthentication = "authentication_error"
+	OpenAIErrorPermission     = "permission_error"
+	OpenAIErrorNotFound       = "not_found_error"
+	OpenAIErrorRateLimit      = "rate_limit_error"
+	OpenAIErrorGateway        = "gateway_error"
+)
+
+// APIError is an error returned to API clients
+type APIError struct {
+	// Status is the HTTP status of the response
+	Status int
+
+	// Code identifies the error for clients, such as "upstream_rate_limited"
+	Code string
+
+	// Message describes the error to the caller
+	Message string
+
+	// Retryable tells clients whether sending the request again may succeed
+	Retryable bool
+
+	// UpstreamStatus is the status returned by the LLM provider, zero when
+	// the error did not come from a provider
+	UpstreamStatus int
+
+	// RetryAfter is how long clients should wait before retrying, sent in
+	// the Retry-After header
+	RetryAfter time.Duration
+}
+
+// NewAPIError creates an error with the default code of an HTTP status.
+// Rate limiting and unavailability are retryable.
+func NewAPIError(status int, message string) *APIError {
+	return &APIError{
+		Status:    status,
+		Code:      ErrorCode(status),
+		Message:   message,
+		Retryable: status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable,
+	}
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// ErrorCode returns the default error code of an HTTP status
+func ErrorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return ErrorCodeInvalidRequest
+	case http.StatusUnauthorized:
+		return ErrorCodeUnauthorized
+	case http.StatusPaymentRequired:
+		return ErrorCodeQuotaExceeded
+	case http.StatusForbidden:
+		return ErrorCodeForbidden
+	case http.StatusNotFound:
+		return ErrorCodeNotFound
+	case http.StatusMethodNotAllowed:
+		return ErrorCodeMethodNotAllowed
+	case http.StatusConflict:
+		return ErrorCodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return ErrorCodeRequestTooLarge
+	case http.StatusUnprocessableEntity:
+		return ErrorCodeUnprocessable
+	case http.StatusTooManyRequests:
+		return ErrorCodeRateLimited
+	case StatusClientClosedRequest:
+		return ErrorCodeClientClosedRequest
+	case http.StatusBadGateway:
+		return ErrorCodeUpstreamError
+	case http.StatusServiceUnavailable:
+		return ErrorCodeUnavailable
+	case http.StatusGatewayTimeout:
+		return ErrorCodeUpstreamTimeout
+	default:
+		if status >= http.StatusInternalServerError {
+			return ErrorCodeInternal
+		}
+		return ErrorCodeInvalidRequest
+	}
+}
+
+// RespondError aborts the request with an error body for a status and message
+func RespondError(c *gin.Context, status int, message string) {
+	RespondAPIError(c, NewAPIError(status, message))
+}
+
+// RespondAPIError aborts the request with the error body of err
+func RespondAPIError(c *gin.Context, err *APIError) {
+	if err.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int((err.RetryAfter+time.Second-1)/time.Second)))
+	}
+	c.AbortWithStatusJSON(err.Status, ErrorBody(c, err))
+}
+
+// ErrorBody builds the JSON error body of err, which carries the request ID.
+// Requests marked by OpenAIErrors get the OpenAI API format.
+func ErrorBody(c *gin.Context, err *APIError) gin.H {
+	var body gin.H
+	if c.GetBool(openAIErrorsKey) {
+		body = gin.H{
+			"error": gin.H{
+				"message": err.Message,
+				"type":    openAIErrorType(err.Status),
+				"param":   nil,
+				"code":    err.Code,
+			},
+		}
+	} else {
+		body = gin.H{
+			"error":     err.Message,
+			"code":      err.Code,
+			"retryable": err.Retryable,
+		}
+		if err.UpstreamStatus != 0 {
+			body["upstream_status"] = err.UpstreamStatus
+		}
+	}
+	if requestID := c.GetString("requestID"); requestID != "" {
+		body["request_id"] = requestID
+	}
+	return body
+}
+
+// openAIErrorType returns the OpenAI API error type of an HTTP status
+func openAIErrorType(status int) string {
+	switch status {
+	case http.StatusUnauthorized:
+		return OpenAIErrorAuthentication
+	case http.StatusForbidden:
+		return OpenAIErrorPermission
+	case http.StatusNotFound:
+		return OpenAIErrorNotFound
+	case http.StatusTooManyRequests:
+		return OpenAIErrorRateLimit
+	default:
+		if status < http.StatusInternalServerError {
+			return OpenAIErrorInvalidRequest
+		}
+		return OpenAIErrorGateway
+	}
+}
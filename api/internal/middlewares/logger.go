@@ -5,6 +5,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+
+	"github.com/secura/api/internal/logging"
 )
 
 // Logger returns a gin middleware for logging requests
@@ -33,14 +35,15 @@ func Logger(logger *zap.Logger) gin.HandlerFunc {
 			zap.Duration("latency", latency),
 		}
 
-		// Log based on status code
+		// Log based on status code with the request-scoped logger
+		reqLogger := logging.FromContext(c.Request.Context(), logger)
 		switch {
 		case c.Writer.Status() >= 500:
-			logger.Error("Server error", fields...)
+			reqLogger.Error("Server error", fields...)
 		case c.Writer.Status() >= 400:
-			logger.Warn("Client error", fields...)
+			reqLogger.Warn("Client error", fields...)
 		default:
-			logger.Info("Request processed", fields...)
+			reqLogger.Info("Request processed", fields...)
 		}
 	}
 } 
\ No newline at end of file
@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/secura/api/internal/config"
+)
+
+// Permissions checked by RequirePermission
+const (
+	PermissionLLMCompletion = "llm:completion"
+	PermissionLLMChat       = "llm:chat"
+	PermissionLLMEmbeddings = "llm:embeddings"
+	PermissionUsageRead     = "usage:read"
+	PermissionAuditRead     = "audit:read"
+	PermissionConsentRead   = "consent:read"
+	PermissionConsentWrite  = "consent:write"
+	PermissionDSARExport    = "dsar:export"
+	PermissionDSARErase     = "dsar:erase"
+	PermissionRetention     = "retention:manage"
+)
+
+// RequirePermission returns a middleware that rejects callers whose role is not
+// granted the permission by the current role permissions policy of their tenant
+func RequirePermission(cfgStore *config.Store, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		policies := cfgStore.Current().PoliciesFor(c.GetString("tenant"))
+		if !policies.HasPermission(role, permission) {
+			RespondError(c, http.StatusForbidden, "Permission denied: "+permission)
+			return
+		}
+		c.Next()
+	}
+}
@@ -15,12 +15,10 @@ func Recover() gin.HandlerFunc {
 			if err := recover(); err != nil {
 				// Log the error and stack trace
 				debugStack := debug.Stack()
-				log.Printf("Recovery from panic: %v\nStack trace: %s", err, debugStack)
+				log.Printf("Recovery from panic (request_id=%s): %v\nStack trace: %s", c.GetString("requestID"), err, debugStack)
 
 				// Return a 500 error
-				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-					"error": "Internal Server Error",
-				})
+				RespondError(c, http.StatusInternalServerError, "Internal Server Error")
 			}
 		}()
 		c.Next()
@@ -0,0 +1,56 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/secura/api/internal/logging"
+)
+
+// RequestIDHeader is the header used to correlate requests
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds client-supplied request IDs
+const maxRequestIDLength = 128
+
+// RequestID returns a middleware that honors or generates an X-Request-ID,
+// echoes it in the response and stores it with a request-scoped logger in the context
+func RequestID(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		fields := []zap.Field{zap.String("request_id", requestID)}
+		if spanCtx := trace.SpanContextFromContext(c.Request.Context()); spanCtx.HasTraceID() {
+			fields = append(fields, zap.String("trace_id", spanCtx.TraceID().String()))
+		}
+		reqLogger := logger.With(fields...)
+
+		ctx := logging.WithRequestID(c.Request.Context(), requestID)
+		ctx = logging.NewContext(ctx, reqLogger)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Set("requestID", requestID)
+		c.Set("logger", reqLogger)
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// validRequestID reports whether a client-supplied request ID is safe to reuse
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
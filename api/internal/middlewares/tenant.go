@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/logging"
+)
+
+// RequireTenant returns a middleware that enforces the tenant claim. When
+// tenants are configured, callers must carry the claim of a known tenant they
+// are a member of; otherwise tenant claims are rejected.
+func RequireTenant(cfgStore *config.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := cfgStore.Current()
+		tenantID := c.GetString("tenant")
+
+		if !cfg.MultiTenant() {
+			if tenantID != "" {
+				RespondError(c, http.StatusForbidden, "Unknown tenant")
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if tenantID == "" {
+			RespondError(c, http.StatusForbidden, "Tenant claim is required")
+			return
+		}
+		tenant, ok := cfg.Tenants[tenantID]
+		if !ok {
+			RespondError(c, http.StatusForbidden, "Unknown tenant")
+			return
+		}
+		if !tenant.HasMember(c.GetString("userID")) {
+			RespondError(c, http.StatusForbidden, "User is not a member of the tenant")
+			return
+		}
+
+		// Tag request logs with the tenant
+		if logger, ok := c.Get("logger"); ok {
+			tenantLogger := logger.(*zap.Logger).With(zap.String("tenant", tenantID))
+			c.Set("logger", tenantLogger)
+			c.Request = c.Request.WithContext(logging.NewContext(c.Request.Context(), tenantLogger))
+		}
+
+		c.Next()
+	}
+}
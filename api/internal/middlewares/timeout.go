@@ -0,0 +1,28 @@
+package middlewares
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/secura/api/internal/config"
+)
+
+// RequestTimeout returns a middleware that bounds the request context by the
+// configured request timeout. Outbound calls made with the context are
+// cancelled when it expires or when the client disconnects.
+func RequestTimeout(cfgStore *config.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := cfgStore.Current().Timeouts.Request
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
@@ -6,6 +6,7 @@ type User struct {
 	Username  string `json:"username"`
 	Email     string `json:"email,omitempty"`
 	Role      string `json:"role"`
+	Tenant    string `json:"tenant,omitempty"`
 	CreatedAt string `json:"created_at,omitempty"`
 	UpdatedAt string `json:"updated_at,omitempty"`
 }
@@ -0,0 +1,44 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/httpclient"
+)
+
+// Azure sends requests to Azure OpenAI deployments. Requests are addressed to
+// a deployment rather than a model, so the deployment's model names the Azure
+// deployment.
+type Azure struct {
+	client *http.Client
+}
+
+// NewAzure creates a new Azure OpenAI provider
+func NewAzure() *Azure {
+	return &Azure{client: httpclient.Providers()}
+}
+
+// Send posts a request body to an endpoint of an Azure OpenAI deployment
+func (p *Azure) Send(ctx context.Context, deployment config.DeploymentConfig, endpoint string, body map[string]interface{}) (map[string]interface{}, error) {
+	apiVersion := deployment.APIVersion
+	if apiVersion == "" {
+		apiVersion = config.DefaultAzureAPIVersion
+	}
+	target := deployment.BaseURL + "/openai/deployments/" + url.PathEscape(deployment.Model) + endpoint +
+		"?api-version=" + url.QueryEscape(apiVersion)
+
+	// The deployment determines the model
+	azureBody := make(map[string]interface{}, len(body))
+	for key, value := range body {
+		if key != "model" {
+			azureBody[key] = value
+		}
+	}
+
+	return postJSON(ctx, p.client, deployment, endpoint, target, azureBody, func(header http.Header) {
+		header.Set("api-key", deployment.APIKey)
+	}, nil)
+}
@@ -0,0 +1,101 @@
+package providers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/secura/api/internal/metrics"
+)
+
+// Circuit breaker states, as reported by the breaker state metric
+const (
+	breakerClosed   = 0
+	breakerHalfOpen = 1
+	breakerOpen     = 2
+)
+
+// breaker is the circuit breaker of a deployment. It opens after a number of
+// consecutive failures and rejects requests until the cooldown has passed.
+// It then lets a single trial request through: success closes the breaker,
+// failure opens it again.
+type breaker struct {
+	name string
+
+	mu       sync.Mutex
+	state    int
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// newBreaker creates a closed circuit breaker for a deployment
+func newBreaker(name string) *breaker {
+	metrics.CircuitBreakerState.WithLabelValues(name).Set(breakerClosed)
+	return &breaker{name: name}
+}
+
+// allow reports whether a request may be sent to the deployment
+func (b *breaker) allow(now time.Time, cooldown time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if now.Sub(b.openedAt) < cooldown {
+			return false
+		}
+		b.setState(breakerHalfOpen)
+		b.trial = true
+		return true
+	case breakerHalfOpen:
+		// Only one trial request at a time
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+// success records a request the deployment served and closes the breaker
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.trial = false
+	b.setState(breakerClosed)
+}
+
+// failure records a failed request. The breaker opens when the failures
+// reach the threshold or a trial request fails.
+func (b *breaker) failure(now time.Time, threshold int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.trial = false
+	if b.state == breakerHalfOpen || b.failures >= threshold {
+		b.openedAt = now
+		b.setState(breakerOpen)
+	}
+}
+
+// release ends a request without an outcome, such as one cancelled by the
+// caller, so a half-open breaker lets another trial request through
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+}
+
+// setState changes the state and reports it. The caller holds the lock.
+func (b *breaker) setState(state int) {
+	if b.state != state {
+		b.state = state
+		metrics.CircuitBreakerState.WithLabelValues(b.name).Set(float64(state))
+	}
+}
@@ -0,0 +1,214 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/httpclient"
+)
+
+// Cohere sends chat requests to the Cohere Chat API (v2). Requests and
+// responses are translated from and to the OpenAI shape.
+type Cohere struct {
+	client *http.Client
+}
+
+// NewCohere creates a new Cohere provider
+func NewCohere() *Cohere {
+	return &Cohere{client: httpclient.Providers()}
+}
+
+// cohereParameters maps OpenAI request parameters to Cohere chat parameters
+var cohereParameters = map[string]string{
+	"temperature":       "temperature",
+	"top_p":             "p",
+	"seed":              "seed",
+	"presence_penalty":  "presence_penalty",
+	"frequency_penalty": "frequency_penalty",
+	"tools":             "tools",
+}
+
+// Send translates a chat completion request to a Cohere chat request and
+// translates the response back
+func (p *Cohere) Send(ctx context.Context, deployment config.DeploymentConfig, endpoint string, body map[string]interface{}) (map[string]interface{}, error) {
+	if endpoint != "/chat/completions" {
+		return nil, &Error{Provider: deployment.Provider, Deployment: deployment.Name, StatusCode: http.StatusNotFound, Message: "endpoint " + endpoint + " is not supported"}
+	}
+
+	request, err := toJSONMap(body)
+	if err == nil {
+		request, err = cohereRequest(request)
+	}
+	if err != nil {
+		return nil, &Error{Provider: deployment.Provider, Deployment: deployment.Name, StatusCode: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	return postJSON(ctx, p.client, deployment, endpoint, deployment.BaseURL+"/chat", request, func(header http.Header) {
+		header.Set("Authorization", "Bearer "+deployment.APIKey)
+	}, func(resp map[string]interface{}) (map[string]interface{}, error) {
+		return cohereResponse(deployment.Model, resp)
+	})
+}
+
+// cohereRequest translates an OpenAI chat completion request to a Cohere
+// chat request. Messages keep their shape, with developer messages sent as
+// system messages and content limited to text and images.
+func cohereRequest(request map[string]interface{}) (map[string]interface{}, error) {
+	rawMessages, _ := request["messages"].([]interface{})
+	messages := make([]interface{}, 0, len(rawMessages))
+	for _, raw := range rawMessages {
+		msg, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid message")
+		}
+
+		role, _ := msg["role"].(string)
+		switch role {
+		case "developer":
+			role = "system"
+		case "function":
+			role = "tool"
+		}
+		translated := map[string]interface{}{"role": role}
+
+		switch content := msg["content"].(type) {
+		case string:
+			translated["content"] = content
+		case []interface{}:
+			parts := make([]interface{}, 0, len(content))
+			for _, rawPart := range content {
+				part, _ := rawPart.(map[string]interface{})
+				switch part["type"] {
+				case "text":
+					parts = append(parts, map[string]interface{}{"type": "text", "text": part["text"]})
+				case "image_url":
+					parts = append(parts, map[string]interface{}{"type": "image_url", "image_url": part["image_url"]})
+				default:
+					return nil, fmt.Errorf("content part type %v is not supported", part["type"])
+				}
+			}
+			translated["content"] = parts
+		}
+		if calls, ok := msg["tool_calls"].([]interface{}); ok && len(calls) > 0 {
+			translated["tool_calls"] = calls
+		}
+		if id, ok := msg["tool_call_id"]; ok {
+			translated["tool_call_id"] = id
+		}
+		messages = append(messages, translated)
+	}
+
+	native := map[string]interface{}{
+		"model":    request["model"],
+		"messages": messages,
+		"stream":   false,
+	}
+	for openAIName, cohereName := range cohereParameters {
+		if value, ok := request[openAIName]; ok && value != nil {
+			native[cohereName] = value
+		}
+	}
+	if maxTokens, ok := requestMaxTokens(request); ok {
+		native["max_tokens"] = maxTokens
+	}
+	if stop, ok := requestStop(request); ok {
+		native["stop_sequences"] = stop
+	}
+	if format, ok := request["response_format"].(map[string]interface{}); ok {
+		switch format["type"] {
+		case "json_object":
+			native["response_format"] = map[string]interface{}{"type": "json_object"}
+		case "json_schema":
+			responseFormat := map[string]interface{}{"type": "json_object"}
+			if schema, ok := format["json_schema"].(map[string]interface{}); ok && schema["schema"] != nil {
+				responseFormat["json_schema"] = schema["schema"]
+			}
+			native["response_format"] = responseFormat
+		}
+	}
+	switch request["tool_choice"] {
+	case "none":
+		native["tool_choice"] = "NONE"
+	case "required":
+		native["tool_choice"] = "REQUIRED"
+	}
+	return native, nil
+}
+
+// cohereResponse translates a Cohere chat response to an OpenAI chat
+// completion
+func cohereResponse(model string, resp map[string]interface{}) (map[string]interface{}, error) {
+	msg, ok := resp["message"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cohere response has no message")
+	}
+
+	// Cohere returns the text as content parts
+	var texts []string
+	parts, _ := msg["content"].([]interface{})
+	for _, rawPart := range parts {
+		part, _ := rawPart.(map[string]interface{})
+		if text, ok := part["text"].(string); ok && part["type"] == "text" {
+			texts = append(texts, text)
+		}
+	}
+	message := map[string]interface{}{
+		"role":    "assistant",
+		"content": strings.Join(texts, ""),
+	}
+	if calls, ok := msg["tool_calls"].([]interface{}); ok && len(calls) > 0 {
+		message["tool_calls"] = calls
+	}
+
+	// Billed units are reported when the token counts are not
+	usage, _ := resp["usage"].(map[string]interface{})
+	tokens, ok := usage["tokens"].(map[string]interface{})
+	if !ok {
+		tokens, _ = usage["billed_units"].(map[string]interface{})
+	}
+	promptTokens, _ := tokens["input_tokens"].(float64)
+	completionTokens, _ := tokens["output_tokens"].(float64)
+
+	id, _ := resp["id"].(string)
+	if id == "" {
+		id = uuid.NewString()
+	}
+	return map[string]interface{}{
+		"id":      "chatcmpl-" + id,
+		"object":  "chat.completion",
+		"created": float64(time.Now().Unix()),
+		"model":   model,
+		"choices": []interface{}{
+			map[string]interface{}{
+				"index":         float64(0),
+				"message":       message,
+				"finish_reason": cohereFinishReason(resp["finish_reason"]),
+			},
+		},
+		"usage": map[string]interface{}{
+			"prompt_tokens":     promptTokens,
+			"completion_tokens": completionTokens,
+			"total_tokens":      promptTokens + completionTokens,
+		},
+	}, nil
+}
+
+// cohereFinishReason returns the OpenAI finish reason of a Cohere response
+func cohereFinishReason(reason interface{}) string {
+	switch reason {
+	case "MAX_TOKENS":
+		return "length"
+	case "TOOL_CALL":
+		return "tool_calls"
+	case "ERROR_TOXIC":
+		return "content_filter"
+	default:
+		return "stop"
+	}
+}
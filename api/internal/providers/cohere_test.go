@@ -0,0 +1,247 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/secura/api/internal/config"
+)
+
+// cohereDeployment returns a Cohere deployment served by a fixture server
+func cohereDeployment(baseURL string) config.DeploymentConfig {
+	return config.DeploymentConfig{
+		Name:     "cohere-test",
+		Provider: config.ProviderCohere,
+		Model:    "command-r-plus",
+		BaseURL:  baseURL,
+		APIKey:   "cohere-key",
+	}
+}
+
+func TestCohereRequest(t *testing.T) {
+	server, captured := newFixtureServer(t, http.StatusOK, `{
+		"id": "co-1",
+		"finish_reason": "COMPLETE",
+		"message": {"role": "assistant", "content": [{"type": "text", "text": "ok"}]}
+	}`)
+
+	body := decodeJSON(t, `{
+		"model": "command-r-plus",
+		"messages": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "developer", "content": "Use metric units."},
+			{"role": "user", "content": [
+				{"type": "text", "text": "Weather here?"},
+				{"type": "image_url", "image_url": {"url": "https://example.com/sky.png"}}
+			]},
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}
+			]},
+			{"role": "function", "tool_call_id": "call_1", "content": "21C"}
+		],
+		"temperature": 0.3,
+		"top_p": 0.9,
+		"seed": 7,
+		"max_completion_tokens": 50,
+		"max_tokens": 10,
+		"stop": ["END", "STOP"],
+		"tools": [{"type": "function", "function": {"name": "get_weather", "parameters": {"type": "object"}}}],
+		"tool_choice": "required",
+		"response_format": {"type": "json_schema", "json_schema": {"name": "weather", "schema": {"type": "object"}}}
+	}`).(map[string]interface{})
+
+	if _, err := NewCohere().Send(context.Background(), cohereDeployment(server.URL), "/chat/completions", body); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	path, header, native := captured.get()
+	if path != "/chat" {
+		t.Errorf("path = %s, want /chat", path)
+	}
+	if got := header.Get("Authorization"); got != "Bearer cohere-key" {
+		t.Errorf("Authorization = %q, want the deployment API key", got)
+	}
+
+	// Developer messages are sent as system messages, function results as
+	// tool results and max_completion_tokens takes precedence over max_tokens
+	assertJSON(t, "chat request", native, `{
+		"model": "command-r-plus",
+		"stream": false,
+		"messages": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "system", "content": "Use metric units."},
+			{"role": "user", "content": [
+				{"type": "text", "text": "Weather here?"},
+				{"type": "image_url", "image_url": {"url": "https://example.com/sky.png"}}
+			]},
+			{"role": "assistant", "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}
+			]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "21C"}
+		],
+		"temperature": 0.3,
+		"p": 0.9,
+		"seed": 7,
+		"max_tokens": 50,
+		"stop_sequences": ["END", "STOP"],
+		"tools": [{"type": "function", "function": {"name": "get_weather", "parameters": {"type": "object"}}}],
+		"tool_choice": "REQUIRED",
+		"response_format": {"type": "json_object", "json_schema": {"type": "object"}}
+	}`)
+}
+
+func TestCohereRequestUnsupportedContent(t *testing.T) {
+	server, _ := newFixtureServer(t, http.StatusOK, `{}`)
+	body := decodeJSON(t, `{
+		"messages": [{"role": "user", "content": [{"type": "input_audio", "input_audio": {"data": "", "format": "wav"}}]}]
+	}`).(map[string]interface{})
+
+	_, err := NewCohere().Send(context.Background(), cohereDeployment(server.URL), "/chat/completions", body)
+	var providerErr *Error
+	if !errors.As(err, &providerErr) || providerErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Send() error = %v, want a 400 *Error", err)
+	}
+}
+
+func TestCohereResponse(t *testing.T) {
+	server, _ := newFixtureServer(t, http.StatusOK, `{
+		"id": "co-1",
+		"finish_reason": "TOOL_CALL",
+		"message": {
+			"role": "assistant",
+			"content": [
+				{"type": "text", "text": "Let me "},
+				{"type": "thinking", "thinking": "The user wants the weather"},
+				{"type": "text", "text": "check."}
+			],
+			"tool_calls": [
+				{"id": "call_9", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}
+			]
+		},
+		"usage": {
+			"billed_units": {"input_tokens": 5, "output_tokens": 6},
+			"tokens": {"input_tokens": 9, "output_tokens": 6}
+		}
+	}`)
+
+	resp, err := NewCohere().Send(context.Background(), cohereDeployment(server.URL), "/chat/completions", map[string]interface{}{
+		"messages": []interface{}{map[string]interface{}{"role": "user", "content": "Weather in Paris?"}},
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if resp["id"] != "chatcmpl-co-1" || resp["object"] != "chat.completion" || resp["model"] != "command-r-plus" {
+		t.Errorf("response id, object and model = %v, %v, %v", resp["id"], resp["object"], resp["model"])
+	}
+	assertJSON(t, "choice", firstChoice(t, resp), `{
+		"index": 0,
+		"finish_reason": "tool_calls",
+		"message": {
+			"role": "assistant",
+			"content": "Let me check.",
+			"tool_calls": [{"id": "call_9", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}]
+		}
+	}`)
+
+	// Token counts are preferred over billed units
+	assertJSON(t, "usage", resp["usage"], `{"prompt_tokens": 9, "completion_tokens": 6, "total_tokens": 15}`)
+}
+
+func TestCohereBilledUnits(t *testing.T) {
+	server, _ := newFixtureServer(t, http.StatusOK, `{
+		"id": "co-2",
+		"finish_reason": "COMPLETE",
+		"message": {"role": "assistant", "content": [{"type": "text", "text": "hi"}]},
+		"usage": {"billed_units": {"input_tokens": 5, "output_tokens": 6}}
+	}`)
+
+	resp, err := NewCohere().Send(context.Background(), cohereDeployment(server.URL), "/chat/completions", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	assertJSON(t, "usage", resp["usage"], `{"prompt_tokens": 5, "completion_tokens": 6, "total_tokens": 11}`)
+}
+
+func TestCohereFinishReasons(t *testing.T) {
+	tests := []struct {
+		reason string
+		want   string
+	}{
+		{reason: "COMPLETE", want: "stop"},
+		{reason: "STOP_SEQUENCE", want: "stop"},
+		{reason: "MAX_TOKENS", want: "length"},
+		{reason: "TOOL_CALL", want: "tool_calls"},
+		{reason: "ERROR_TOXIC", want: "content_filter"},
+		{reason: "ERROR", want: "stop"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reason, func(t *testing.T) {
+			server, _ := newFixtureServer(t, http.StatusOK, `{
+				"id": "co-3",
+				"finish_reason": "`+tt.reason+`",
+				"message": {"role": "assistant", "content": [{"type": "text", "text": "partial"}]}
+			}`)
+			resp, err := NewCohere().Send(context.Background(), cohereDeployment(server.URL), "/chat/completions", map[string]interface{}{})
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if got := firstChoice(t, resp)["finish_reason"]; got != tt.want {
+				t.Errorf("finish_reason = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCohereErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		endpoint    string
+		status      int
+		fixture     string
+		wantStatus  int
+		wantMessage string
+	}{
+		{
+			name:       "no message",
+			endpoint:   "/chat/completions",
+			status:     http.StatusOK,
+			fixture:    `{"id": "co-4", "finish_reason": "COMPLETE"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "invalid API key",
+			endpoint:    "/chat/completions",
+			status:      http.StatusUnauthorized,
+			fixture:     `{"message": "invalid api token"}`,
+			wantStatus:  http.StatusUnauthorized,
+			wantMessage: "invalid api token",
+		},
+		{
+			name:       "unsupported endpoint",
+			endpoint:   "/completions",
+			status:     http.StatusOK,
+			fixture:    `{}`,
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newFixtureServer(t, tt.status, tt.fixture)
+			_, err := NewCohere().Send(context.Background(), cohereDeployment(server.URL), tt.endpoint, map[string]interface{}{})
+
+			var providerErr *Error
+			if !errors.As(err, &providerErr) {
+				t.Fatalf("Send() error = %v, want a *Error", err)
+			}
+			if providerErr.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", providerErr.StatusCode, tt.wantStatus)
+			}
+			if tt.wantMessage != "" && providerErr.Message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", providerErr.Message, tt.wantMessage)
+			}
+		})
+	}
+}
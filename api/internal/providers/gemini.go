@@ -0,0 +1,401 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/httpclient"
+)
+
+// Gemini sends chat requests to the Google Gemini API's generateContent
+// method. Requests and responses are translated from and to the OpenAI shape;
+// safety ratings are recorded in the audit metadata.
+type Gemini struct {
+	client *http.Client
+}
+
+// NewGemini creates a new Gemini provider
+func NewGemini() *Gemini {
+	return &Gemini{client: httpclient.Providers()}
+}
+
+// geminiGenerationConfig maps OpenAI sampling parameters to Gemini
+// generation config fields
+var geminiGenerationConfig = map[string]string{
+	"temperature":       "temperature",
+	"top_p":             "topP",
+	"seed":              "seed",
+	"n":                 "candidateCount",
+	"presence_penalty":  "presencePenalty",
+	"frequency_penalty": "frequencyPenalty",
+}
+
+// Send translates a chat completion request to a generateContent request and
+// translates the response back
+func (p *Gemini) Send(ctx context.Context, deployment config.DeploymentConfig, endpoint string, body map[string]interface{}) (map[string]interface{}, error) {
+	if endpoint != "/chat/completions" {
+		return nil, &Error{Provider: deployment.Provider, Deployment: deployment.Name, StatusCode: http.StatusNotFound, Message: "endpoint " + endpoint + " is not supported"}
+	}
+
+	request, err := toJSONMap(body)
+	if err == nil {
+		request, err = geminiRequest(request)
+	}
+	if err != nil {
+		return nil, &Error{Provider: deployment.Provider, Deployment: deployment.Name, StatusCode: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	target := deployment.BaseURL + "/models/" + url.PathEscape(deployment.Model) + ":generateContent"
+	return postJSON(ctx, p.client, deployment, endpoint, target, request, func(header http.Header) {
+		header.Set("x-goog-api-key", deployment.APIKey)
+	}, func(resp map[string]interface{}) (map[string]interface{}, error) {
+		return geminiResponse(deployment.Model, resp)
+	})
+}
+
+// geminiRequest translates an OpenAI chat completion request to a
+// generateContent request. System and developer messages become the system
+// instruction, assistant messages take the "model" role and tool results are
+// sent by the user as function responses.
+func geminiRequest(request map[string]interface{}) (map[string]interface{}, error) {
+	rawMessages, _ := request["messages"].([]interface{})
+
+	// Function responses are matched to calls by name rather than ID
+	toolNames := make(map[string]interface{})
+	for _, raw := range rawMessages {
+		msg, _ := raw.(map[string]interface{})
+		calls, _ := msg["tool_calls"].([]interface{})
+		for _, rawCall := range calls {
+			call, _ := rawCall.(map[string]interface{})
+			function, _ := call["function"].(map[string]interface{})
+			if id, ok := call["id"].(string); ok {
+				toolNames[id] = function["name"]
+			}
+		}
+	}
+
+	var (
+		system   []interface{}
+		contents []interface{}
+	)
+	for _, raw := range rawMessages {
+		msg, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid message")
+		}
+
+		var (
+			role  string
+			parts []interface{}
+			err   error
+		)
+		switch msg["role"] {
+		case "system", "developer":
+			system = append(system, map[string]interface{}{"text": messageText(msg["content"])})
+			continue
+		case "user":
+			role = "user"
+			parts, err = geminiContentParts(msg["content"])
+		case "assistant":
+			role = "model"
+			parts, err = geminiAssistantParts(msg)
+		case "tool", "function":
+			role = "user"
+			parts = []interface{}{geminiFunctionResponse(msg, toolNames)}
+		default:
+			return nil, fmt.Errorf("message role %v is not supported", msg["role"])
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Consecutive messages of a role are merged into one content, as
+		// Gemini expects the roles to alternate
+		if last := len(contents) - 1; last >= 0 {
+			previous := contents[last].(map[string]interface{})
+			if previous["role"] == role {
+				previous["parts"] = append(previous["parts"].([]interface{}), parts...)
+				continue
+			}
+		}
+		contents = append(contents, map[string]interface{}{"role": role, "parts": parts})
+	}
+
+	native := map[string]interface{}{"contents": contents}
+	if len(system) > 0 {
+		native["systemInstruction"] = map[string]interface{}{"parts": system}
+	}
+
+	generationConfig := make(map[string]interface{})
+	for openAIName, geminiName := range geminiGenerationConfig {
+		if value, ok := request[openAIName]; ok && value != nil {
+			generationConfig[geminiName] = value
+		}
+	}
+	if maxTokens, ok := requestMaxTokens(request); ok {
+		generationConfig["maxOutputTokens"] = maxTokens
+	}
+	if stop, ok := requestStop(request); ok {
+		generationConfig["stopSequences"] = stop
+	}
+	if format, ok := request["response_format"].(map[string]interface{}); ok {
+		switch format["type"] {
+		case "json_object":
+			generationConfig["responseMimeType"] = "application/json"
+		case "json_schema":
+			generationConfig["responseMimeType"] = "application/json"
+			if schema, ok := format["json_schema"].(map[string]interface{}); ok && schema["schema"] != nil {
+				generationConfig["responseSchema"] = schema["schema"]
+			}
+		}
+	}
+	if len(generationConfig) > 0 {
+		native["generationConfig"] = generationConfig
+	}
+
+	if tools, ok := request["tools"].([]interface{}); ok && len(tools) > 0 {
+		declarations := make([]interface{}, 0, len(tools))
+		for _, rawTool := range tools {
+			tool, _ := rawTool.(map[string]interface{})
+			function, ok := tool["function"].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("tool type %v is not supported", tool["type"])
+			}
+			declarations = append(declarations, function)
+		}
+		native["tools"] = []interface{}{map[string]interface{}{"functionDeclarations": declarations}}
+	}
+	if toolConfig, ok := geminiToolConfig(request["tool_choice"]); ok {
+		native["toolConfig"] = toolConfig
+	}
+	return native, nil
+}
+
+// geminiContentParts translates an OpenAI message content to Gemini parts.
+// Images must be given as data URLs.
+func geminiContentParts(content interface{}) ([]interface{}, error) {
+	rawParts, ok := content.([]interface{})
+	if !ok {
+		return []interface{}{map[string]interface{}{"text": messageText(content)}}, nil
+	}
+
+	parts := make([]interface{}, 0, len(rawParts))
+	for _, rawPart := range rawParts {
+		part, _ := rawPart.(map[string]interface{})
+		switch part["type"] {
+		case "text":
+			parts = append(parts, map[string]interface{}{"text": part["text"]})
+		case "image_url":
+			mediaType, data, ok := parseImageDataURL(part)
+			if !ok {
+				return nil, fmt.Errorf("gemini only accepts images as base64 data URLs")
+			}
+			parts = append(parts, map[string]interface{}{
+				"inlineData": map[string]interface{}{"mimeType": mediaType, "data": data},
+			})
+		default:
+			return nil, fmt.Errorf("content part type %v is not supported", part["type"])
+		}
+	}
+	return parts, nil
+}
+
+// geminiAssistantParts translates an OpenAI assistant message, including its
+// tool calls, to Gemini parts
+func geminiAssistantParts(msg map[string]interface{}) ([]interface{}, error) {
+	var parts []interface{}
+	if text := messageText(msg["content"]); text != "" {
+		parts = append(parts, map[string]interface{}{"text": text})
+	}
+
+	calls, _ := msg["tool_calls"].([]interface{})
+	for _, rawCall := range calls {
+		call, _ := rawCall.(map[string]interface{})
+		function, _ := call["function"].(map[string]interface{})
+		arguments, err := toolCallArguments(function)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, map[string]interface{}{
+			"functionCall": map[string]interface{}{"name": function["name"], "args": arguments},
+		})
+	}
+
+	// Gemini rejects contents without parts
+	if len(parts) == 0 {
+		parts = append(parts, map[string]interface{}{"text": ""})
+	}
+	return parts, nil
+}
+
+// geminiFunctionResponse translates an OpenAI tool result to a Gemini
+// function response. Results that are not a JSON object are wrapped in one.
+func geminiFunctionResponse(msg map[string]interface{}, toolNames map[string]interface{}) map[string]interface{} {
+	name := msg["name"]
+	if id, ok := msg["tool_call_id"].(string); ok && toolNames[id] != nil {
+		name = toolNames[id]
+	}
+
+	text := messageText(msg["content"])
+	response, err := toolCallArguments(map[string]interface{}{"arguments": text})
+	if _, isObject := response.(map[string]interface{}); err != nil || !isObject {
+		response = map[string]interface{}{"content": text}
+	}
+	return map[string]interface{}{
+		"functionResponse": map[string]interface{}{"name": name, "response": response},
+	}
+}
+
+// geminiToolConfig translates an OpenAI tool choice to a Gemini tool config
+func geminiToolConfig(toolChoice interface{}) (map[string]interface{}, bool) {
+	functionCalling := make(map[string]interface{})
+	switch choice := toolChoice.(type) {
+	case string:
+		switch choice {
+		case "none":
+			functionCalling["mode"] = "NONE"
+		case "auto":
+			functionCalling["mode"] = "AUTO"
+		case "required":
+			functionCalling["mode"] = "ANY"
+		default:
+			return nil, false
+		}
+	case map[string]interface{}:
+		function, ok := choice["function"].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		functionCalling["mode"] = "ANY"
+		functionCalling["allowedFunctionNames"] = []interface{}{function["name"]}
+	default:
+		return nil, false
+	}
+	return map[string]interface{}{"functionCallingConfig": functionCalling}, true
+}
+
+// geminiResponse translates a generateContent response to an OpenAI chat
+// completion. A prompt blocked by safety filters yields an empty choice with
+// the content_filter finish reason.
+func geminiResponse(model string, resp map[string]interface{}) (map[string]interface{}, error) {
+	candidates, _ := resp["candidates"].([]interface{})
+	metadata := make(map[string]interface{})
+
+	choices := make([]interface{}, 0, len(candidates))
+	for i, rawCandidate := range candidates {
+		candidate, _ := rawCandidate.(map[string]interface{})
+		content, _ := candidate["content"].(map[string]interface{})
+		parts, _ := content["parts"].([]interface{})
+
+		var (
+			texts []string
+			calls []interface{}
+		)
+		for _, rawPart := range parts {
+			part, _ := rawPart.(map[string]interface{})
+			if thought, _ := part["thought"].(bool); thought {
+				continue
+			}
+			if text, ok := part["text"].(string); ok {
+				texts = append(texts, text)
+			}
+			if functionCall, ok := part["functionCall"].(map[string]interface{}); ok {
+				id, _ := functionCall["id"].(string)
+				if id == "" {
+					id = fmt.Sprintf("call_%d", len(calls))
+				}
+				toolCall, err := openAIToolCall(id, functionCall["name"], functionCall["args"])
+				if err != nil {
+					return nil, err
+				}
+				calls = append(calls, toolCall)
+			}
+		}
+
+		message := map[string]interface{}{
+			"role":    "assistant",
+			"content": strings.Join(texts, ""),
+		}
+		finishReason := geminiFinishReason(candidate["finishReason"])
+		if len(calls) > 0 {
+			message["tool_calls"] = calls
+			finishReason = "tool_calls"
+		}
+		choices = append(choices, map[string]interface{}{
+			"index":         float64(i),
+			"message":       message,
+			"finish_reason": finishReason,
+		})
+
+		if ratings, ok := candidate["safetyRatings"]; ok && i == 0 {
+			metadata["safety_ratings"] = ratings
+		}
+	}
+
+	if feedback, ok := resp["promptFeedback"].(map[string]interface{}); ok {
+		if reason, ok := feedback["blockReason"]; ok {
+			metadata["prompt_block_reason"] = reason
+		}
+		if ratings, ok := feedback["safetyRatings"]; ok {
+			metadata["prompt_safety_ratings"] = ratings
+		}
+	}
+	if len(choices) == 0 {
+		if _, blocked := metadata["prompt_block_reason"]; !blocked {
+			return nil, fmt.Errorf("gemini response has no candidates")
+		}
+		choices = append(choices, map[string]interface{}{
+			"index":         float64(0),
+			"message":       map[string]interface{}{"role": "assistant", "content": ""},
+			"finish_reason": "content_filter",
+		})
+	}
+
+	usage, _ := resp["usageMetadata"].(map[string]interface{})
+	promptTokens, _ := usage["promptTokenCount"].(float64)
+	completionTokens, _ := usage["candidatesTokenCount"].(float64)
+	totalTokens, ok := usage["totalTokenCount"].(float64)
+	if !ok {
+		totalTokens = promptTokens + completionTokens
+	}
+
+	id, _ := resp["responseId"].(string)
+	if id == "" {
+		id = uuid.NewString()
+	}
+	result := map[string]interface{}{
+		"id":      "chatcmpl-" + id,
+		"object":  "chat.completion",
+		"created": float64(time.Now().Unix()),
+		"model":   model,
+		"choices": choices,
+		"usage": map[string]interface{}{
+			"prompt_tokens":     promptTokens,
+			"completion_tokens": completionTokens,
+			"total_tokens":      totalTokens,
+		},
+	}
+	if len(metadata) > 0 {
+		result[metadataKey] = metadata
+	}
+	return result, nil
+}
+
+// geminiFinishReason returns the OpenAI finish reason of a Gemini candidate
+func geminiFinishReason(reason interface{}) string {
+	switch reason {
+	case "MAX_TOKENS":
+		return "length"
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII", "IMAGE_SAFETY":
+		return "content_filter"
+	default:
+		return "stop"
+	}
+}
@@ -0,0 +1,228 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/secura/api/internal/config"
+)
+
+// geminiDeployment returns a Gemini deployment served by a fixture server
+func geminiDeployment(baseURL string) config.DeploymentConfig {
+	return config.DeploymentConfig{
+		Name:     "gemini-test",
+		Provider: config.ProviderGemini,
+		Model:    "gemini-1.5-pro",
+		BaseURL:  baseURL,
+		APIKey:   "gemini-key",
+	}
+}
+
+func TestGeminiRequest(t *testing.T) {
+	server, captured := newFixtureServer(t, http.StatusOK, `{
+		"candidates": [{"content": {"role": "model", "parts": [{"text": "ok"}]}, "finishReason": "STOP"}]
+	}`)
+
+	body := decodeJSON(t, `{
+		"model": "secure-gemini",
+		"messages": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "developer", "content": "Answer in English."},
+			{"role": "user", "content": "Weather in Paris?"},
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}
+			]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "{\"temp\":21}"},
+			{"role": "tool", "tool_call_id": "call_1", "content": "sunny"},
+			{"role": "user", "content": [{"type": "text", "text": "And tomorrow?"}]}
+		],
+		"temperature": 0.2,
+		"max_tokens": 100,
+		"stop": "END",
+		"tools": [{"type": "function", "function": {"name": "get_weather", "parameters": {"type": "object"}}}],
+		"tool_choice": {"type": "function", "function": {"name": "get_weather"}}
+	}`).(map[string]interface{})
+
+	if _, err := NewGemini().Send(context.Background(), geminiDeployment(server.URL), "/chat/completions", body); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	path, header, native := captured.get()
+	if path != "/models/gemini-1.5-pro:generateContent" {
+		t.Errorf("path = %s, want the deployment model's generateContent method", path)
+	}
+	if got := header.Get("x-goog-api-key"); got != "gemini-key" {
+		t.Errorf("x-goog-api-key = %q, want the deployment API key", got)
+	}
+
+	// System and developer messages become the system instruction, tool
+	// results are sent by the user under the called function's name and
+	// consecutive user turns are merged
+	assertJSON(t, "generateContent request", native, `{
+		"contents": [
+			{"role": "user", "parts": [{"text": "Weather in Paris?"}]},
+			{"role": "model", "parts": [{"functionCall": {"name": "get_weather", "args": {"city": "Paris"}}}]},
+			{"role": "user", "parts": [
+				{"functionResponse": {"name": "get_weather", "response": {"temp": 21}}},
+				{"functionResponse": {"name": "get_weather", "response": {"content": "sunny"}}},
+				{"text": "And tomorrow?"}
+			]}
+		],
+		"systemInstruction": {"parts": [{"text": "Be brief."}, {"text": "Answer in English."}]},
+		"generationConfig": {"temperature": 0.2, "maxOutputTokens": 100, "stopSequences": ["END"]},
+		"tools": [{"functionDeclarations": [{"name": "get_weather", "parameters": {"type": "object"}}]}],
+		"toolConfig": {"functionCallingConfig": {"mode": "ANY", "allowedFunctionNames": ["get_weather"]}}
+	}`)
+}
+
+func TestGeminiResponse(t *testing.T) {
+	server, _ := newFixtureServer(t, http.StatusOK, `{
+		"responseId": "resp-1",
+		"candidates": [{
+			"content": {"role": "model", "parts": [
+				{"text": "Checking the forecast", "thought": true},
+				{"text": "It is "},
+				{"text": "sunny."},
+				{"functionCall": {"name": "get_weather", "args": {"city": "Paris"}}}
+			]},
+			"finishReason": "STOP",
+			"safetyRatings": [{"category": "HARM_CATEGORY_HARASSMENT", "probability": "NEGLIGIBLE"}]
+		}],
+		"usageMetadata": {"promptTokenCount": 12, "candidatesTokenCount": 8, "totalTokenCount": 20}
+	}`)
+
+	resp, err := NewGemini().Send(context.Background(), geminiDeployment(server.URL), "/chat/completions", map[string]interface{}{
+		"messages": []interface{}{map[string]interface{}{"role": "user", "content": "Weather in Paris?"}},
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if resp["id"] != "chatcmpl-resp-1" || resp["object"] != "chat.completion" || resp["model"] != "gemini-1.5-pro" {
+		t.Errorf("response id, object and model = %v, %v, %v", resp["id"], resp["object"], resp["model"])
+	}
+
+	// Thoughts are dropped and a function call finishes the turn with tool calls
+	assertJSON(t, "choice", firstChoice(t, resp), `{
+		"index": 0,
+		"finish_reason": "tool_calls",
+		"message": {
+			"role": "assistant",
+			"content": "It is sunny.",
+			"tool_calls": [{"id": "call_0", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}]
+		}
+	}`)
+	assertJSON(t, "usage", resp["usage"], `{"prompt_tokens": 12, "completion_tokens": 8, "total_tokens": 20}`)
+	assertJSON(t, "audit metadata", resp[metadataKey], `{
+		"safety_ratings": [{"category": "HARM_CATEGORY_HARASSMENT", "probability": "NEGLIGIBLE"}]
+	}`)
+}
+
+func TestGeminiFinishReasons(t *testing.T) {
+	tests := []struct {
+		reason string
+		want   string
+	}{
+		{reason: "STOP", want: "stop"},
+		{reason: "MAX_TOKENS", want: "length"},
+		{reason: "SAFETY", want: "content_filter"},
+		{reason: "RECITATION", want: "content_filter"},
+		{reason: "BLOCKLIST", want: "content_filter"},
+		{reason: "PROHIBITED_CONTENT", want: "content_filter"},
+		{reason: "SPII", want: "content_filter"},
+		{reason: "OTHER", want: "stop"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reason, func(t *testing.T) {
+			server, _ := newFixtureServer(t, http.StatusOK, `{
+				"candidates": [{"content": {"role": "model", "parts": [{"text": "partial"}]}, "finishReason": "`+tt.reason+`"}]
+			}`)
+			resp, err := NewGemini().Send(context.Background(), geminiDeployment(server.URL), "/chat/completions", map[string]interface{}{})
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if got := firstChoice(t, resp)["finish_reason"]; got != tt.want {
+				t.Errorf("finish_reason = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeminiBlockedPrompt(t *testing.T) {
+	server, _ := newFixtureServer(t, http.StatusOK, `{
+		"promptFeedback": {
+			"blockReason": "SAFETY",
+			"safetyRatings": [{"category": "HARM_CATEGORY_DANGEROUS_CONTENT", "probability": "HIGH"}]
+		},
+		"usageMetadata": {"promptTokenCount": 3, "totalTokenCount": 3}
+	}`)
+
+	resp, err := NewGemini().Send(context.Background(), geminiDeployment(server.URL), "/chat/completions", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	assertJSON(t, "choice", firstChoice(t, resp), `{
+		"index": 0,
+		"finish_reason": "content_filter",
+		"message": {"role": "assistant", "content": ""}
+	}`)
+	assertJSON(t, "usage", resp["usage"], `{"prompt_tokens": 3, "completion_tokens": 0, "total_tokens": 3}`)
+	assertJSON(t, "audit metadata", resp[metadataKey], `{
+		"prompt_block_reason": "SAFETY",
+		"prompt_safety_ratings": [{"category": "HARM_CATEGORY_DANGEROUS_CONTENT", "probability": "HIGH"}]
+	}`)
+}
+
+func TestGeminiErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		endpoint    string
+		status      int
+		fixture     string
+		wantStatus  int
+		wantMessage string
+	}{
+		{
+			name:       "no candidates",
+			endpoint:   "/chat/completions",
+			status:     http.StatusOK,
+			fixture:    `{"usageMetadata": {"promptTokenCount": 3}}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "rate limited",
+			endpoint:    "/chat/completions",
+			status:      http.StatusTooManyRequests,
+			fixture:     `{"error": {"code": 429, "message": "Resource has been exhausted", "status": "RESOURCE_EXHAUSTED"}}`,
+			wantStatus:  http.StatusTooManyRequests,
+			wantMessage: "Resource has been exhausted",
+		},
+		{
+			name:       "unsupported endpoint",
+			endpoint:   "/embeddings",
+			status:     http.StatusOK,
+			fixture:    `{}`,
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newFixtureServer(t, tt.status, tt.fixture)
+			_, err := NewGemini().Send(context.Background(), geminiDeployment(server.URL), tt.endpoint, map[string]interface{}{})
+
+			var providerErr *Error
+			if !errors.As(err, &providerErr) {
+				t.Fatalf("Send() error = %v, want a *Error", err)
+			}
+			if providerErr.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", providerErr.StatusCode, tt.wantStatus)
+			}
+			if tt.wantMessage != "" && providerErr.Message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", providerErr.Message, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,363 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/httpclient"
+)
+
+// Ollama sends requests to the native API of an Ollama server. Requests and
+// responses are translated from and to the OpenAI shape.
+type Ollama struct {
+	client *http.Client
+}
+
+// NewOllama creates a new Ollama provider
+func NewOllama() *Ollama {
+	return &Ollama{client: httpclient.Providers()}
+}
+
+// ollamaOptions maps OpenAI sampling parameters to Ollama model options
+var ollamaOptions = map[string]string{
+	"temperature":       "temperature",
+	"top_p":             "top_p",
+	"stop":              "stop",
+	"seed":              "seed",
+	"presence_penalty":  "presence_penalty",
+	"frequency_penalty": "frequency_penalty",
+}
+
+// Send translates a request body for an OpenAI endpoint to the matching
+// Ollama endpoint and translates the response back
+func (p *Ollama) Send(ctx context.Context, deployment config.DeploymentConfig, endpoint string, body map[string]interface{}) (map[string]interface{}, error) {
+	request, err := toJSONMap(body)
+	if err != nil {
+		return nil, &Error{Provider: deployment.Provider, Deployment: deployment.Name, StatusCode: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	var (
+		path      string
+		native    map[string]interface{}
+		translate func(map[string]interface{}) (map[string]interface{}, error)
+	)
+	switch endpoint {
+	case "/chat/completions":
+		path = "/api/chat"
+		native, err = ollamaChatRequest(request)
+		translate = func(resp map[string]interface{}) (map[string]interface{}, error) {
+			return ollamaChatResponse(deployment.Model, resp)
+		}
+	case "/completions":
+		path = "/api/generate"
+		native, err = ollamaGenerateRequest(request)
+		translate = func(resp map[string]interface{}) (map[string]interface{}, error) {
+			return ollamaGenerateResponse(deployment.Model, resp)
+		}
+	case "/embeddings":
+		path = "/api/embed"
+		native = map[string]interface{}{"model": request["model"], "input": request["input"]}
+		translate = func(resp map[string]interface{}) (map[string]interface{}, error) {
+			return ollamaEmbedResponse(deployment.Model, resp)
+		}
+	default:
+		return nil, &Error{Provider: deployment.Provider, Deployment: deployment.Name, StatusCode: http.StatusNotFound, Message: "endpoint " + endpoint + " is not supported"}
+	}
+	if err != nil {
+		return nil, &Error{Provider: deployment.Provider, Deployment: deployment.Name, StatusCode: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	return postJSON(ctx, p.client, deployment, endpoint, deployment.BaseURL+path, native, func(header http.Header) {
+		if deployment.APIKey != "" {
+			header.Set("Authorization", "Bearer "+deployment.APIKey)
+		}
+	}, translate)
+}
+
+// ollamaChatRequest translates an OpenAI chat completion request to an
+// Ollama chat request
+func ollamaChatRequest(request map[string]interface{}) (map[string]interface{}, error) {
+	rawMessages, _ := request["messages"].([]interface{})
+	messages := make([]interface{}, 0, len(rawMessages))
+	for _, raw := range rawMessages {
+		msg, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid message")
+		}
+		translated, err := ollamaMessage(msg)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, translated)
+	}
+
+	native := map[string]interface{}{
+		"model":    request["model"],
+		"messages": messages,
+		"stream":   false,
+		"options":  ollamaRequestOptions(request),
+	}
+	if tools, ok := request["tools"]; ok {
+		native["tools"] = tools
+	}
+	if format, ok := request["response_format"].(map[string]interface{}); ok {
+		switch format["type"] {
+		case "json_object":
+			native["format"] = "json"
+		case "json_schema":
+			if schema, ok := format["json_schema"].(map[string]interface{}); ok {
+				native["format"] = schema["schema"]
+			}
+		}
+	}
+	return native, nil
+}
+
+// ollamaMessage translates an OpenAI chat message. Content parts are joined
+// and images given as data URLs are passed as base64 images.
+func ollamaMessage(msg map[string]interface{}) (map[string]interface{}, error) {
+	role, _ := msg["role"].(string)
+	switch role {
+	case "developer":
+		role = "system"
+	case "function":
+		role = "tool"
+	}
+	translated := map[string]interface{}{"role": role}
+
+	switch content := msg["content"].(type) {
+	case string:
+		translated["content"] = content
+	case []interface{}:
+		var (
+			texts  []string
+			images []interface{}
+		)
+		for _, rawPart := range content {
+			part, _ := rawPart.(map[string]interface{})
+			switch part["type"] {
+			case "text":
+				text, _ := part["text"].(string)
+				texts = append(texts, text)
+			case "image_url":
+				_, data, ok := parseImageDataURL(part)
+				if !ok {
+					return nil, fmt.Errorf("ollama only accepts images as base64 data URLs")
+				}
+				images = append(images, data)
+			default:
+				return nil, fmt.Errorf("content part type %v is not supported", part["type"])
+			}
+		}
+		translated["content"] = strings.Join(texts, "\n")
+		if len(images) > 0 {
+			translated["images"] = images
+		}
+	default:
+		translated["content"] = ""
+	}
+
+	if name, ok := msg["name"].(string); ok && role == "tool" {
+		translated["tool_name"] = name
+	}
+
+	// Ollama takes tool call arguments as an object rather than a JSON string
+	if rawCalls, ok := msg["tool_calls"].([]interface{}); ok {
+		calls := make([]interface{}, 0, len(rawCalls))
+		for _, rawCall := range rawCalls {
+			call, _ := rawCall.(map[string]interface{})
+			function, _ := call["function"].(map[string]interface{})
+			arguments, err := toolCallArguments(function)
+			if err != nil {
+				return nil, err
+			}
+			calls = append(calls, map[string]interface{}{
+				"function": map[string]interface{}{
+					"name":      function["name"],
+					"arguments": arguments,
+				},
+			})
+		}
+		translated["tool_calls"] = calls
+	}
+	return translated, nil
+}
+
+// ollamaRequestOptions returns the Ollama model options for the sampling
+// parameters of an OpenAI request
+func ollamaRequestOptions(request map[string]interface{}) map[string]interface{} {
+	options := make(map[string]interface{})
+	for openAIName, ollamaName := range ollamaOptions {
+		if value, ok := request[openAIName]; ok && value != nil {
+			options[ollamaName] = value
+		}
+	}
+	if maxTokens, ok := requestMaxTokens(request); ok {
+		options["num_predict"] = maxTokens
+	}
+	return options
+}
+
+// ollamaGenerateRequest translates an OpenAI completion request to an Ollama
+// generate request
+func ollamaGenerateRequest(request map[string]interface{}) (map[string]interface{}, error) {
+	var prompt string
+	switch value := request["prompt"].(type) {
+	case string:
+		prompt = value
+	case []interface{}:
+		if len(value) != 1 {
+			return nil, fmt.Errorf("ollama accepts a single prompt per request")
+		}
+		text, ok := value[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("ollama only accepts text prompts")
+		}
+		prompt = text
+	default:
+		return nil, fmt.Errorf("prompt is required")
+	}
+
+	return map[string]interface{}{
+		"model":   request["model"],
+		"prompt":  prompt,
+		"stream":  false,
+		"options": ollamaRequestOptions(request),
+	}, nil
+}
+
+// ollamaChatResponse translates an Ollama chat response to an OpenAI chat
+// completion
+func ollamaChatResponse(model string, resp map[string]interface{}) (map[string]interface{}, error) {
+	msg, ok := resp["message"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("ollama response has no message")
+	}
+
+	message := map[string]interface{}{
+		"role":    "assistant",
+		"content": msg["content"],
+	}
+	finishReason := ollamaFinishReason(resp)
+
+	// OpenAI returns tool call arguments as a JSON string
+	if rawCalls, ok := msg["tool_calls"].([]interface{}); ok && len(rawCalls) > 0 {
+		calls := make([]interface{}, 0, len(rawCalls))
+		for i, rawCall := range rawCalls {
+			call, _ := rawCall.(map[string]interface{})
+			function, _ := call["function"].(map[string]interface{})
+			toolCall, err := openAIToolCall(fmt.Sprintf("call_%d", i), function["name"], function["arguments"])
+			if err != nil {
+				return nil, err
+			}
+			calls = append(calls, toolCall)
+		}
+		message["tool_calls"] = calls
+		finishReason = "tool_calls"
+	}
+
+	return map[string]interface{}{
+		"id":      "chatcmpl-" + uuid.NewString(),
+		"object":  "chat.completion",
+		"created": ollamaCreated(resp),
+		"model":   model,
+		"choices": []interface{}{
+			map[string]interface{}{
+				"index":         float64(0),
+				"message":       message,
+				"finish_reason": finishReason,
+			},
+		},
+		"usage": ollamaUsage(resp),
+	}, nil
+}
+
+// ollamaGenerateResponse translates an Ollama generate response to an
+// OpenAI completion
+func ollamaGenerateResponse(model string, resp map[string]interface{}) (map[string]interface{}, error) {
+	text, ok := resp["response"].(string)
+	if !ok {
+		return nil, fmt.Errorf("ollama response has no text")
+	}
+
+	return map[string]interface{}{
+		"id":      "cmpl-" + uuid.NewString(),
+		"object":  "text_completion",
+		"created": ollamaCreated(resp),
+		"model":   model,
+		"choices": []interface{}{
+			map[string]interface{}{
+				"index":         float64(0),
+				"text":          text,
+				"logprobs":      nil,
+				"finish_reason": ollamaFinishReason(resp),
+			},
+		},
+		"usage": ollamaUsage(resp),
+	}, nil
+}
+
+// ollamaEmbedResponse translates an Ollama embed response to an OpenAI
+// embeddings response
+func ollamaEmbedResponse(model string, resp map[string]interface{}) (map[string]interface{}, error) {
+	embeddings, ok := resp["embeddings"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("ollama response has no embeddings")
+	}
+
+	data := make([]interface{}, len(embeddings))
+	for i, embedding := range embeddings {
+		data[i] = map[string]interface{}{
+			"object":    "embedding",
+			"index":     float64(i),
+			"embedding": embedding,
+		}
+	}
+	promptTokens, _ := resp["prompt_eval_count"].(float64)
+
+	return map[string]interface{}{
+		"object": "list",
+		"data":   data,
+		"model":  model,
+		"usage": map[string]interface{}{
+			"prompt_tokens": promptTokens,
+			"total_tokens":  promptTokens,
+		},
+	}, nil
+}
+
+// ollamaFinishReason returns the OpenAI finish reason of an Ollama response
+func ollamaFinishReason(resp map[string]interface{}) string {
+	if reason, _ := resp["done_reason"].(string); reason == "length" {
+		return "length"
+	}
+	return "stop"
+}
+
+// ollamaCreated returns the creation time of an Ollama response as a Unix
+// timestamp
+func ollamaCreated(resp map[string]interface{}) float64 {
+	if createdAt, ok := resp["created_at"].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, createdAt); err == nil {
+			return float64(t.Unix())
+		}
+	}
+	return float64(time.Now().Unix())
+}
+
+// ollamaUsage returns the OpenAI usage block of an Ollama response
+func ollamaUsage(resp map[string]interface{}) map[string]interface{} {
+	promptTokens, _ := resp["prompt_eval_count"].(float64)
+	completionTokens, _ := resp["eval_count"].(float64)
+	return map[string]interface{}{
+		"prompt_tokens":     promptTokens,
+		"completion_tokens": completionTokens,
+		"total_tokens":      promptTokens + completionTokens,
+	}
+}
@@ -0,0 +1,157 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/httpclient"
+	"github.com/secura/api/internal/metrics"
+	"github.com/secura/api/internal/telemetry"
+)
+
+// OpenAI sends requests to the OpenAI API and APIs compatible with it
+type OpenAI struct {
+	client *http.Client
+}
+
+// NewOpenAI creates a new OpenAI provider
+func NewOpenAI() *OpenAI {
+	return &OpenAI{client: httpclient.Providers()}
+}
+
+// Send posts a request body to an endpoint of an OpenAI deployment
+func (p *OpenAI) Send(ctx context.Context, deployment config.DeploymentConfig, endpoint string, body map[string]interface{}) (map[string]interface{}, error) {
+	return postJSON(ctx, p.client, deployment, endpoint, deployment.BaseURL+endpoint, body, func(header http.Header) {
+		// Self-hosted OpenAI-compatible servers often run without a key
+		if deployment.APIKey != "" {
+			header.Set("Authorization", "Bearer "+deployment.APIKey)
+		}
+	}, nil)
+}
+
+// postJSON posts a request body for an endpoint to the URL of a provider API
+// and decodes the response. setAuth adds the deployment's credentials to the
+// request headers. translate converts a native response to the OpenAI shape;
+// it is nil for APIs that already answer in that shape.
+func postJSON(ctx context.Context, client *http.Client, deployment config.DeploymentConfig, endpoint string, url string, body map[string]interface{}, setAuth func(http.Header), translate func(map[string]interface{}) (map[string]interface{}, error)) (map[string]interface{}, error) {
+	provider, model := deployment.Provider, deployment.Model
+	ctx, span := telemetry.Tracer().Start(ctx, provider+" "+path.Base(endpoint),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			telemetry.AttrLLMProvider.String(provider),
+			telemetry.AttrLLMModel.String(model),
+			telemetry.AttrLLMDeployment.String(deployment.Name),
+		),
+	)
+	defer span.End()
+
+	fail := func(statusCode int, reason string, err error) error {
+		metrics.ProviderErrorsTotal.WithLabelValues(provider, model, reason).Inc()
+		telemetry.RecordError(span, err)
+		return &Error{Provider: provider, Deployment: deployment.Name, StatusCode: statusCode, Err: err}
+	}
+
+	// Marshal request body
+	reqBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, fail(0, "invalid_request", err)
+	}
+
+	// Create request
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, fail(0, "invalid_request", err)
+	}
+
+	// Set headers
+	req.Header.Set("Content-Type", "application/json")
+	setAuth(req.Header)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	// Send request
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		metrics.ProviderRequestDuration.WithLabelValues(provider, model, "error").Observe(time.Since(start).Seconds())
+		return nil, fail(0, "transport", err)
+	}
+	defer resp.Body.Close()
+	span.SetAttributes(semconv.HTTPStatusCode(resp.StatusCode))
+
+	// Read response
+	respBytes, err := io.ReadAll(resp.Body)
+	metrics.ProviderRequestDuration.WithLabelValues(provider, model, strconv.Itoa(resp.StatusCode)).Observe(time.Since(start).Seconds())
+	if err != nil {
+		return nil, fail(0, "transport", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		metrics.ProviderErrorsTotal.WithLabelValues(provider, model, "http_"+strconv.Itoa(resp.StatusCode)).Inc()
+		span.SetStatus(codes.Error, resp.Status)
+		return nil, &Error{
+			Provider:   provider,
+			Deployment: deployment.Name,
+			StatusCode: resp.StatusCode,
+			Message:    errorMessage(respBytes),
+			RetryAfter: retryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	// Parse response
+	var result map[string]interface{}
+	if err := json.Unmarshal(respBytes, &result); err != nil {
+		return nil, fail(resp.StatusCode, "invalid_response", err)
+	}
+	if translate != nil {
+		if result, err = translate(result); err != nil {
+			return nil, fail(resp.StatusCode, "invalid_response", err)
+		}
+	}
+
+	// Record token usage on the span, never the content
+	promptTokens, completionTokens, totalTokens := TokenUsage(result)
+	span.SetAttributes(
+		telemetry.AttrLLMPromptTokens.Int64(promptTokens),
+		telemetry.AttrLLMCompletionTokens.Int64(completionTokens),
+		telemetry.AttrLLMTotalTokens.Int64(totalTokens),
+	)
+
+	return result, nil
+}
+
+// errorMessage returns the message of an error body. OpenAI-shaped APIs nest
+// it in an error object; others return the error as a string or a top-level
+// message.
+func errorMessage(body []byte) string {
+	var errBody struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+	}
+	if err := json.Unmarshal(body, &errBody); err != nil {
+		return ""
+	}
+
+	var nested struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(errBody.Error, &nested); err == nil && nested.Message != "" {
+		return nested.Message
+	}
+	var message string
+	if err := json.Unmarshal(errBody.Error, &message); err == nil && message != "" {
+		return message
+	}
+	return errBody.Message
+}
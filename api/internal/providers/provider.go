@@ -0,0 +1,114 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/secura/api/internal/config"
+)
+
+// Provider sends requests to the API of an LLM provider
+type Provider interface {
+	// Send posts an OpenAI-shaped request body to an endpoint of a
+	// deployment, such as "/chat/completions", and returns the response body
+	Send(ctx context.Context, deployment config.DeploymentConfig, endpoint string, body map[string]interface{}) (map[string]interface{}, error)
+}
+
+// Error is a failed provider request
+type Error struct {
+	Provider   string
+	Deployment string
+
+	// StatusCode is the upstream HTTP status, zero when no response was received
+	StatusCode int
+
+	// Message is the error message returned by the provider, if any
+	Message string
+
+	// RetryAfter is how long the provider asked callers to wait before retrying
+	RetryAfter time.Duration
+
+	// Err is the underlying transport or decoding error, if any
+	Err error
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	switch {
+	case e.Err != nil:
+		return fmt.Sprintf("%s deployment %s: %v", e.Provider, e.Deployment, e.Err)
+	case e.Message != "":
+		return fmt.Sprintf("%s deployment %s returned status %d: %s", e.Provider, e.Deployment, e.StatusCode, e.Message)
+	default:
+		return fmt.Sprintf("%s deployment %s returned status %d", e.Provider, e.Deployment, e.StatusCode)
+	}
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether the request may succeed when sent again: the
+// provider was unreachable, timed out, rate limited the request or failed
+// with a server error. Requests cancelled by the caller are not retried.
+func (e *Error) Retryable() bool {
+	if e.StatusCode == 0 {
+		return !errors.Is(e.Err, context.Canceled)
+	}
+	return e.StatusCode == http.StatusRequestTimeout ||
+		e.StatusCode == http.StatusTooManyRequests ||
+		e.StatusCode >= http.StatusInternalServerError
+}
+
+// deploymentFailure reports whether an error is caused by the deployment
+// rather than the request, so another deployment may serve the request.
+// Authentication and not found errors point to a misconfigured deployment.
+func deploymentFailure(err error) bool {
+	var providerErr *Error
+	if !errors.As(err, &providerErr) {
+		return false
+	}
+	switch providerErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return true
+	default:
+		return providerErr.Retryable()
+	}
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// TokenUsage extracts token counts from the usage block of an OpenAI-shaped response
+func TokenUsage(resp map[string]interface{}) (promptTokens int64, completionTokens int64, totalTokens int64) {
+	usage, ok := resp["usage"].(map[string]interface{})
+	if !ok {
+		return 0, 0, 0
+	}
+	if v, ok := usage["prompt_tokens"].(float64); ok {
+		promptTokens = int64(v)
+	}
+	if v, ok := usage["completion_tokens"].(float64); ok {
+		completionTokens = int64(v)
+	}
+	if v, ok := usage["total_tokens"].(float64); ok {
+		totalTokens = int64(v)
+	}
+	return promptTokens, completionTokens, totalTokens
+}
@@ -0,0 +1,84 @@
+package providers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// capturedRequest is the last request a fixture server received
+type capturedRequest struct {
+	mu     sync.Mutex
+	path   string
+	header http.Header
+	body   map[string]interface{}
+}
+
+func (r *capturedRequest) get() (string, http.Header, map[string]interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.path, r.header, r.body
+}
+
+// newFixtureServer starts a stand-in for a provider API that answers every
+// request with status and the JSON fixture, and captures the request
+func newFixtureServer(t *testing.T, status int, fixture string) (*httptest.Server, *capturedRequest) {
+	t.Helper()
+	captured := &capturedRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("request body is not a JSON object: %v", err)
+		}
+
+		captured.mu.Lock()
+		captured.path = r.URL.RequestURI()
+		captured.header = r.Header.Clone()
+		captured.body = body
+		captured.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(fixture))
+	}))
+	t.Cleanup(server.Close)
+	return server, captured
+}
+
+// decodeJSON decodes a JSON fixture to plain JSON values
+func decodeJSON(t *testing.T, data string) interface{} {
+	t.Helper()
+	var value interface{}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+	return value
+}
+
+// assertJSON fails the test if got does not encode to the same JSON as want
+func assertJSON(t *testing.T, name string, got interface{}, want string) {
+	t.Helper()
+	gotData, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("%s: failed to encode: %v", name, err)
+	}
+	wantData, _ := json.Marshal(decodeJSON(t, want))
+	if string(gotData) != string(wantData) {
+		t.Errorf("%s =\n%s\nwant\n%s", name, gotData, wantData)
+	}
+}
+
+// firstChoice returns the first choice of an OpenAI chat completion
+func firstChoice(t *testing.T, resp map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	choices, _ := resp["choices"].([]interface{})
+	if len(choices) == 0 {
+		t.Fatalf("response has no choices: %v", resp)
+	}
+	choice, _ := choices[0].(map[string]interface{})
+	return choice
+}
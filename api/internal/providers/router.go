@@ -0,0 +1,284 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/logging"
+	"github.com/secura/api/internal/metrics"
+)
+
+// ErrNoHealthyDeployment is returned when the circuit breakers of every
+// deployment serving a model are open
+var ErrNoHealthyDeployment = errors.New("no healthy deployment available")
+
+// Result describes a routed request
+type Result struct {
+	// Response is the response body of the deployment that served the request
+	Response map[string]interface{}
+
+	// Deployment, Provider, Model and Region identify the deployment that
+	// served the request or, when routing failed, the last one tried
+	Deployment string
+	Provider   string
+	Model      string
+	Region     string
+
+	// Attempts is the number of requests sent across all deployments
+	Attempts int
+
+	// Metadata holds provider details to audit, such as Gemini safety ratings
+	Metadata map[string]interface{}
+}
+
+// Router sends requests for logical models to the provider deployments that
+// serve them. Deployments are tried in priority order, with load balanced by
+// weight between deployments of the same priority. Retryable errors are
+// retried with exponential backoff and jitter before failing over to the
+// next deployment. Circuit breakers skip deployments that keep failing.
+type Router struct {
+	providers map[string]Provider
+	logger    *zap.Logger
+
+	// breakers are kept by deployment name so they survive config reloads
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+// NewRouter creates a new router with the built-in providers
+func NewRouter(logger *zap.Logger) *Router {
+	return &Router{
+		providers: map[string]Provider{
+			config.ProviderOpenAI:           NewOpenAI(),
+			config.ProviderAzure:            NewAzure(),
+			config.ProviderOllama:           NewOllama(),
+			config.ProviderOpenAICompatible: NewOpenAI(),
+			config.ProviderGemini:           NewGemini(),
+			config.ProviderCohere:           NewCohere(),
+		},
+		logger:   logger,
+		breakers: make(map[string]*breaker),
+	}
+}
+
+// Forward routes a request body for an endpoint such as "/chat/completions".
+// The body's model names the logical model and is replaced by each
+// deployment's own model name. The result is returned on failure too, to
+// report how far routing got.
+func (r *Router) Forward(ctx context.Context, cfg *config.Config, tenant string, endpoint string, body map[string]interface{}) (Result, error) {
+	logger := logging.FromContext(ctx, r.logger)
+	routing := cfg.Routing
+	model, _ := body["model"].(string)
+
+	var (
+		result  Result
+		lastErr error
+	)
+	deployments, err := cfg.DeploymentsFor(model, tenant)
+	if err != nil {
+		return Result{}, err
+	}
+	deployments = orderDeployments(deployments)
+	for i, deployment := range deployments {
+		provider, ok := r.providers[deployment.Provider]
+		if !ok {
+			lastErr = fmt.Errorf("deployment %s uses unknown provider %s", deployment.Name, deployment.Provider)
+			continue
+		}
+		breaker := r.breaker(deployment.Name)
+
+		// Send the deployment's own model name
+		deploymentBody := make(map[string]interface{}, len(body))
+		for key, value := range body {
+			deploymentBody[key] = value
+		}
+		deploymentBody["model"] = deployment.Model
+
+		result.Deployment = deployment.Name
+		result.Provider = deployment.Provider
+		result.Model = deployment.Model
+		result.Region = deployment.Region
+
+		var deploymentErr error
+		for attempt := 1; attempt <= routing.MaxAttempts; attempt++ {
+			if routing.BreakerThreshold > 0 && !breaker.allow(time.Now(), routing.BreakerCooldown) {
+				logger.Warn("Skipping deployment with open circuit breaker", zap.String("deployment", deployment.Name))
+				break
+			}
+
+			result.Attempts++
+			resp, err := send(ctx, cfg.Timeouts.Provider, provider, deployment, endpoint, deploymentBody)
+			if err == nil {
+				breaker.success()
+				if metadata, ok := resp[metadataKey].(map[string]interface{}); ok {
+					delete(resp, metadataKey)
+					result.Metadata = metadata
+				}
+				result.Response = resp
+				return result, nil
+			}
+			lastErr = err
+			deploymentErr = err
+
+			// The caller went away, stop without blaming the deployment
+			if ctx.Err() != nil {
+				breaker.release()
+				return result, lastErr
+			}
+
+			// Errors caused by the request fail on every deployment
+			if !deploymentFailure(err) {
+				breaker.success()
+				return result, lastErr
+			}
+			if routing.BreakerThreshold > 0 {
+				breaker.failure(time.Now(), routing.BreakerThreshold)
+			}
+
+			var providerErr *Error
+			if !errors.As(err, &providerErr) || !providerErr.Retryable() || attempt == routing.MaxAttempts {
+				break
+			}
+
+			// Fail over at once rather than wait longer than the backoff allows
+			delay := backoff(routing, attempt)
+			if providerErr.RetryAfter > routing.BackoffMax {
+				break
+			}
+			if providerErr.RetryAfter > delay {
+				delay = providerErr.RetryAfter
+			}
+
+			logger.Warn("Retrying provider request",
+				zap.String("deployment", deployment.Name),
+				zap.Int("attempt", attempt),
+				zap.Duration("delay", delay),
+				zap.Error(err),
+			)
+			metrics.ProviderRetriesTotal.WithLabelValues(deployment.Name).Inc()
+			if err := sleepContext(ctx, delay); err != nil {
+				return result, err
+			}
+		}
+
+		if deploymentErr != nil && i < len(deployments)-1 {
+			logger.Warn("Failing over to the next deployment", zap.String("deployment", deployment.Name), zap.Error(deploymentErr))
+			metrics.ProviderFailoversTotal.WithLabelValues(deployment.Name).Inc()
+		}
+	}
+
+	if result.Attempts == 0 && lastErr == nil {
+		return result, ErrNoHealthyDeployment
+	}
+	return result, lastErr
+}
+
+// send sends a request to a deployment within the provider timeout, so a
+// hanging deployment is retried or failed over before the request deadline
+func send(ctx context.Context, timeout time.Duration, provider Provider, deployment config.DeploymentConfig, endpoint string, body map[string]interface{}) (map[string]interface{}, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return provider.Send(ctx, deployment, endpoint, body)
+}
+
+// breaker returns the circuit breaker of a deployment
+func (r *Router) breaker(name string) *breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.breakers[name]
+	if !ok {
+		b = newBreaker(name)
+		r.breakers[name] = b
+	}
+	return b
+}
+
+// orderDeployments returns deployments by ascending priority. Deployments of
+// the same priority are shuffled with a probability proportional to their
+// weight, so the first one tried balances the load.
+func orderDeployments(deployments []config.DeploymentConfig) []config.DeploymentConfig {
+	sort.SliceStable(deployments, func(i, j int) bool {
+		return deployments[i].Priority < deployments[j].Priority
+	})
+
+	ordered := make([]config.DeploymentConfig, 0, len(deployments))
+	for start := 0; start < len(deployments); {
+		end := start
+		for end < len(deployments) && deployments[end].Priority == deployments[start].Priority {
+			end++
+		}
+		ordered = append(ordered, weightedShuffle(deployments[start:end])...)
+		start = end
+	}
+	return ordered
+}
+
+// weightedShuffle orders deployments by repeatedly drawing one with a
+// probability proportional to its weight
+func weightedShuffle(deployments []config.DeploymentConfig) []config.DeploymentConfig {
+	remaining := append([]config.DeploymentConfig(nil), deployments...)
+	shuffled := make([]config.DeploymentConfig, 0, len(deployments))
+	for len(remaining) > 0 {
+		total := 0
+		for _, deployment := range remaining {
+			total += weight(deployment)
+		}
+		pick := rand.Intn(total)
+		i := 0
+		for ; pick >= weight(remaining[i]); i++ {
+			pick -= weight(remaining[i])
+		}
+		shuffled = append(shuffled, remaining[i])
+		remaining = append(remaining[:i], remaining[i+1:]...)
+	}
+	return shuffled
+}
+
+// weight returns the load balancing weight of a deployment
+func weight(deployment config.DeploymentConfig) int {
+	if deployment.Weight <= 0 {
+		return 1
+	}
+	return deployment.Weight
+}
+
+// backoff returns the delay before retrying after an attempt: a random
+// duration up to an exponentially growing bound ("full jitter")
+func backoff(routing config.RoutingConfig, attempt int) time.Duration {
+	bound := routing.BackoffInitial
+	for i := 1; i < attempt && bound < routing.BackoffMax; i++ {
+		bound *= 2
+	}
+	if bound > routing.BackoffMax {
+		bound = routing.BackoffMax
+	}
+	if bound <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(bound) + 1))
+}
+
+// sleepContext waits for a duration or until the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
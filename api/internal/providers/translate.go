@@ -0,0 +1,112 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// metadataKey holds details a translating provider adds to a response for
+// the audit trail, such as safety ratings. The router moves them to
+// Result.Metadata so clients never see them.
+const metadataKey = "_secura_metadata"
+
+// toJSONMap converts a request body holding typed values, such as chat
+// messages, to plain JSON values so it can be translated
+func toJSONMap(body map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+	return result, nil
+}
+
+// requestMaxTokens returns the completion token limit of an OpenAI request,
+// preferring max_completion_tokens over the older max_tokens. Zero means no
+// limit.
+func requestMaxTokens(request map[string]interface{}) (float64, bool) {
+	for _, name := range []string{"max_completion_tokens", "max_tokens"} {
+		if value, ok := request[name].(float64); ok && value > 0 {
+			return value, true
+		}
+	}
+	return 0, false
+}
+
+// requestStop returns the stop sequences of an OpenAI request, which may be
+// a single string or a list
+func requestStop(request map[string]interface{}) ([]interface{}, bool) {
+	switch stop := request["stop"].(type) {
+	case string:
+		return []interface{}{stop}, true
+	case []interface{}:
+		return stop, len(stop) > 0
+	default:
+		return nil, false
+	}
+}
+
+// parseImageDataURL returns the media type and base64 data of an image_url
+// content part given as a data URL. It reports false for other URLs.
+func parseImageDataURL(part map[string]interface{}) (mediaType string, data string, ok bool) {
+	imageURL, _ := part["image_url"].(map[string]interface{})
+	url, _ := imageURL["url"].(string)
+	header, data, ok := strings.Cut(strings.TrimPrefix(url, "data:"), ";base64,")
+	if !strings.HasPrefix(url, "data:") || !ok {
+		return "", "", false
+	}
+	return header, data, true
+}
+
+// messageText returns the text of an OpenAI message content, joining the
+// text parts of multi-part content
+func messageText(content interface{}) string {
+	switch content := content.(type) {
+	case string:
+		return content
+	case []interface{}:
+		var texts []string
+		for _, rawPart := range content {
+			part, _ := rawPart.(map[string]interface{})
+			if text, ok := part["text"].(string); ok && part["type"] == "text" {
+				texts = append(texts, text)
+			}
+		}
+		return strings.Join(texts, "\n")
+	default:
+		return ""
+	}
+}
+
+// toolCallArguments decodes the JSON string arguments of an OpenAI tool call
+// to the object native APIs expect
+func toolCallArguments(function map[string]interface{}) (interface{}, error) {
+	var arguments interface{} = map[string]interface{}{}
+	if text, ok := function["arguments"].(string); ok && text != "" {
+		if err := json.Unmarshal([]byte(text), &arguments); err != nil {
+			return nil, fmt.Errorf("invalid tool call arguments: %w", err)
+		}
+	}
+	return arguments, nil
+}
+
+// openAIToolCall builds an OpenAI tool call from a native function call,
+// encoding its arguments as the JSON string OpenAI returns
+func openAIToolCall(id string, name interface{}, arguments interface{}) (map[string]interface{}, error) {
+	encoded, err := json.Marshal(arguments)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tool call arguments: %w", err)
+	}
+	return map[string]interface{}{
+		"id":   id,
+		"type": "function",
+		"function": map[string]interface{}{
+			"name":      name,
+			"arguments": string(encoded),
+		},
+	}, nil
+}
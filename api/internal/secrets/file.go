@@ -0,0 +1,33 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FileProvider reads secrets from files, such as Docker and Kubernetes
+// mounted secrets. Trailing newlines are trimmed.
+type FileProvider struct{}
+
+// Resolve reads the secret stored in the file at path
+func (FileProvider) Resolve(_ context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// EnvProvider reads secrets from environment variables
+type EnvProvider struct{}
+
+// Resolve returns the value of the environment variable name
+func (EnvProvider) Resolve(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
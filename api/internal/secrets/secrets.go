@@ -0,0 +1,62 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Provider resolves a secret reference to its value. The reference is the
+// part of the secret URI after the scheme, e.g. "/run/secrets/openai" for
+// "file:///run/secrets/openai".
+type Provider interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// Resolver dispatches secret URIs to the provider registered for their scheme.
+// Values without a registered scheme are treated as literal secrets.
+type Resolver struct {
+	providers map[string]Provider
+}
+
+// NewResolver creates a resolver with the file and env providers registered
+func NewResolver() *Resolver {
+	resolver := &Resolver{providers: make(map[string]Provider)}
+	resolver.Register("file", FileProvider{})
+	resolver.Register("env", EnvProvider{})
+	return resolver
+}
+
+// Register adds a provider for a URI scheme, replacing any existing one
+func (r *Resolver) Register(scheme string, provider Provider) {
+	r.providers[scheme] = provider
+}
+
+// IsReference reports whether value is a secret URI with a registered scheme
+func (r *Resolver) IsReference(value string) bool {
+	scheme, _, ok := strings.Cut(value, "://")
+	if !ok {
+		return false
+	}
+	_, ok = r.providers[scheme]
+	return ok
+}
+
+// Resolve returns the secret a value refers to, or the value itself if it is
+// not a secret URI
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	scheme, ref, ok := strings.Cut(value, "://")
+	if !ok {
+		return value, nil
+	}
+	provider, ok := r.providers[scheme]
+	if !ok {
+		return value, nil
+	}
+
+	secret, err := provider.Resolve(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s secret: %w", scheme, err)
+	}
+	return secret, nil
+}
@@ -0,0 +1,78 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// VaultProvider reads secrets from a HashiCorp Vault KV secrets engine over
+// its HTTP API. References take the form "<path>#<key>", for example
+// "secret/data/secura/openai#api_key" for KV v2 or "secret/secura#api_key"
+// for KV v1.
+type VaultProvider struct {
+	address string
+	token   string
+	client  *http.Client
+}
+
+// NewVaultProvider creates a provider for the Vault server at address that
+// authenticates with token
+func NewVaultProvider(address, token string, client *http.Client) *VaultProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &VaultProvider{
+		address: strings.TrimSuffix(address, "/"),
+		token:   token,
+		client:  client,
+	}
+}
+
+// vaultResponse is the part of a Vault read response holding the secret data
+type vaultResponse struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+// Resolve reads the key from the secret at the referenced path
+func (p *VaultProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	path, key, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || key == "" {
+		return "", fmt.Errorf("invalid vault reference %q: expected path#key", ref)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.address+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to read vault secret %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned status %d for secret %s", resp.StatusCode, path)
+	}
+
+	var body vaultResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode vault response: %w", err)
+	}
+
+	// KV v2 nests the secret under data.data; KV v1 returns it under data
+	data := body.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+
+	value, ok := data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no string key %s", path, key)
+	}
+	return value, nil
+}
@@ -0,0 +1,90 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testVaultToken = "test-token"
+
+// newVaultServer starts a stand-in for the Vault HTTP API serving a KV v2
+// secret at secret/data/secura/openai and a KV v1 secret at secret/secura
+func newVaultServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("X-Vault-Token") != testVaultToken {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/secret/data/secura/openai":
+			w.Write([]byte(`{"data":{"data":{"api_key":"sk-v2","count":3},"metadata":{"version":2}}}`))
+		case "/v1/secret/secura":
+			w.Write([]byte(`{"data":{"api_key":"sk-v1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestVaultReferences(t *testing.T) {
+	server := newVaultServer(t)
+	resolver := NewResolver()
+	resolver.Register("vault", NewVaultProvider(server.URL+"/", testVaultToken, server.Client()))
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr string
+	}{
+		{name: "KV v2", value: "vault://secret/data/secura/openai#api_key", want: "sk-v2"},
+		{name: "KV v1", value: "vault://secret/secura#api_key", want: "sk-v1"},
+		{name: "leading slash", value: "vault:///secret/secura#api_key", want: "sk-v1"},
+		{name: "literal", value: "sk-literal", want: "sk-literal"},
+		{name: "missing key", value: "vault://secret/secura#token", wantErr: "has no string key token"},
+		{name: "non-string key", value: "vault://secret/data/secura/openai#count", wantErr: "has no string key count"},
+		{name: "missing secret", value: "vault://secret/missing#api_key", wantErr: "status 404"},
+		{name: "no key", value: "vault://secret/secura", wantErr: "expected path#key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.Resolve(context.Background(), tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Resolve(%q) error = %v, want an error containing %q", tt.value, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve(%q) error = %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVaultRejectedToken(t *testing.T) {
+	server := newVaultServer(t)
+	resolver := NewResolver()
+	resolver.Register("vault", NewVaultProvider(server.URL, "wrong-token", server.Client()))
+
+	_, err := resolver.Resolve(context.Background(), "vault://secret/secura#api_key")
+	if err == nil || !strings.Contains(err.Error(), "status 403") {
+		t.Fatalf("Resolve() error = %v, want a 403 error", err)
+	}
+}
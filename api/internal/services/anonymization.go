@@ -2,15 +2,28 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/secura/api/internal/httpclient"
+	"github.com/secura/api/internal/metrics"
+	"github.com/secura/api/internal/telemetry"
 )
 
 // AnonymizationService handles anonymization of sensitive data
 type AnonymizationService struct {
 	baseURL string
+	client  *http.Client
 }
 
 // AnonymizeRequest represents a request to the anonymization service
@@ -37,11 +50,112 @@ type DetectedEntity struct {
 func NewAnonymizationService(baseURL string) *AnonymizationService {
 	return &AnonymizationService{
 		baseURL: baseURL,
+		client:  httpclient.Services(),
 	}
 }
 
 // AnonymizeText anonymizes sensitive information in text
-func (s *AnonymizationService) AnonymizeText(text string) (string, error) {
+func (s *AnonymizationService) AnonymizeText(ctx context.Context, text string) (string, error) {
+	anonymized, _, err := s.Anonymize(ctx, text)
+	return anonymized, err
+}
+
+// Anonymize anonymizes sensitive information in text and returns the entities detected
+func (s *AnonymizationService) Anonymize(ctx context.Context, text string) (string, []DetectedEntity, error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "anonymization.AnonymizeText", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	start := time.Now()
+	result, err := s.anonymize(ctx, text)
+	if err != nil {
+		metrics.AnonymizationDuration.WithLabelValues("error").Observe(time.Since(start).Seconds())
+		telemetry.RecordError(span, err)
+		return "", nil, err
+	}
+	metrics.AnonymizationDuration.WithLabelValues("success").Observe(time.Since(start).Seconds())
+	span.SetAttributes(telemetry.AttrAnonymizedEntities.Int(len(result.Entities)))
+
+	for _, entity := range result.Entities {
+		metrics.AnonymizedEntitiesTotal.WithLabelValues(entity.Type).Inc()
+	}
+
+	return result.AnonymizedText, result.Entities, nil
+}
+
+// AnonymizeExcept anonymizes text like Anonymize but leaves the entity types
+// in keep in clear. Only the masked entities are returned.
+func (s *AnonymizationService) AnonymizeExcept(ctx context.Context, text string, keep []string) (string, []DetectedEntity, error) {
+	anonymized, entities, err := s.Anonymize(ctx, text)
+	if err != nil || len(keep) == 0 {
+		return anonymized, entities, err
+	}
+
+	var masked []DetectedEntity
+	for _, entity := range entities {
+		if !containsEntityType(keep, entity.Type) {
+			masked = append(masked, entity)
+		}
+	}
+	if len(masked) == len(entities) {
+		return anonymized, entities, nil
+	}
+
+	relaxed, ok := maskEntities(text, masked)
+	if !ok {
+		// The offsets do not match the text, keep the full anonymization
+		return anonymized, entities, nil
+	}
+	return relaxed, masked, nil
+}
+
+// maskEntities replaces entities in text with <TYPE> placeholders, as the
+// anonymization service does. Offsets count characters, not bytes.
+// Overlapping entities are masked as one. It reports false if an entity's
+// offsets do not match the text.
+func maskEntities(text string, entities []DetectedEntity) (string, bool) {
+	runes := []rune(text)
+	sorted := append([]DetectedEntity(nil), entities...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].StartOffset != sorted[j].StartOffset {
+			return sorted[i].StartOffset < sorted[j].StartOffset
+		}
+		return sorted[i].EndOffset > sorted[j].EndOffset
+	})
+
+	var b strings.Builder
+	pos := 0
+	for _, entity := range sorted {
+		if entity.StartOffset < 0 || entity.EndOffset > len(runes) || entity.StartOffset >= entity.EndOffset ||
+			string(runes[entity.StartOffset:entity.EndOffset]) != entity.Text {
+			return "", false
+		}
+		if entity.StartOffset < pos {
+			// Overlaps the entity masked last
+			if entity.EndOffset > pos {
+				pos = entity.EndOffset
+			}
+			continue
+		}
+		b.WriteString(string(runes[pos:entity.StartOffset]))
+		b.WriteString("<" + entity.Type + ">")
+		pos = entity.EndOffset
+	}
+	b.WriteString(string(runes[pos:]))
+	return b.String(), true
+}
+
+// containsEntityType reports whether entityType is one of types
+func containsEntityType(types []string, entityType string) bool {
+	for _, t := range types {
+		if strings.EqualFold(t, entityType) {
+			return true
+		}
+	}
+	return false
+}
+
+// anonymize calls the anonymization service and returns its full response
+func (s *AnonymizationService) anonymize(ctx context.Context, text string) (*AnonymizeResponse, error) {
 	// Create request body
 	reqBody := AnonymizeRequest{
 		Text: text,
@@ -49,43 +163,43 @@ func (s *AnonymizationService) AnonymizeText(text string) (string, error) {
 
 	reqBytes, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	// Create HTTP request
 	url := fmt.Sprintf("%s/api/v1/anonymize", s.baseURL)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(reqBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBytes))
 	if err != nil {
-		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
 	// Send request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send HTTP request: %w", err)
+		return nil, fmt.Errorf("failed to send HTTP request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Read response
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	// Parse response
 	var result AnonymizeResponse
 	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	// Check for errors in the response
 	if result.Error != "" {
-		return "", fmt.Errorf("anonymization service error: %s", result.Error)
+		return nil, fmt.Errorf("anonymization service error: %s", result.Error)
 	}
 
-	return result.AnonymizedText, nil
+	return &result, nil
 }
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/secura/api/internal/storage"
+)
+
+// APIKeyPrefix starts every gateway API key so it can be told apart from a JWT
+const APIKeyPrefix = "sk-secura-"
+
+// apiKeyDisplayLength is the number of leading characters of a key kept for display
+const apiKeyDisplayLength = len(APIKeyPrefix) + 6
+
+// ErrInvalidAPIKey is returned when an API key is unknown, revoked, expired or
+// belongs to an erased user. The causes are not distinguished to callers.
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// APIKeyService issues and authenticates API keys. Keys are only stored as a
+// SHA-256 hash; the key itself is returned once, when it is created.
+type APIKeyService struct {
+	users storage.UserStore
+	now   func() time.Time
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(users storage.UserStore) *APIKeyService {
+	return &APIKeyService{
+		users: users,
+		now:   time.Now,
+	}
+}
+
+// Create issues a new API key for a user, scoped to a tenant. It returns the
+// key and its stored metadata.
+func (s *APIKeyService) Create(ctx context.Context, userID string, tenant string, name string, expiresAt *time.Time) (string, storage.APIKeyRecord, error) {
+	if _, err := s.users.Get(ctx, userID); err != nil {
+		return "", storage.APIKeyRecord{}, err
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", storage.APIKeyRecord{}, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key := APIKeyPrefix + hex.EncodeToString(secret)
+
+	record := storage.APIKeyRecord{
+		UserID:    userID,
+		Tenant:    tenant,
+		Name:      name,
+		Prefix:    key[:apiKeyDisplayLength],
+		KeyHash:   HashAPIKey(key),
+		CreatedAt: s.now().UTC(),
+		ExpiresAt: expiresAt,
+		Active:    true,
+	}
+	record, err := s.users.AddAPIKey(ctx, record)
+	if err != nil {
+		return "", storage.APIKeyRecord{}, err
+	}
+	return key, record, nil
+}
+
+// List returns the API keys a user holds in a tenant
+func (s *APIKeyService) List(ctx context.Context, userID string, tenant string) ([]storage.APIKeyRecord, error) {
+	return s.users.APIKeys(ctx, tenant, userID)
+}
+
+// Revoke deactivates one of the API keys a user holds in a tenant
+func (s *APIKeyService) Revoke(ctx context.Context, userID string, tenant string, id string) (storage.APIKeyRecord, error) {
+	return s.users.RevokeAPIKey(ctx, tenant, userID, id)
+}
+
+// Authenticate returns the active API key matching key and the user it
+// belongs to. Errors other than ErrInvalidAPIKey mean the key could not be
+// checked.
+func (s *APIKeyService) Authenticate(ctx context.Context, key string) (storage.APIKeyRecord, storage.UserRecord, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return storage.APIKeyRecord{}, storage.UserRecord{}, ErrInvalidAPIKey
+	}
+
+	record, err := s.users.FindAPIKey(ctx, HashAPIKey(key))
+	if errors.Is(err, storage.ErrAPIKeyNotFound) {
+		return storage.APIKeyRecord{}, storage.UserRecord{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return storage.APIKeyRecord{}, storage.UserRecord{}, err
+	}
+	now := s.now()
+	if !record.Active || (record.ExpiresAt != nil && !now.Before(*record.ExpiresAt)) {
+		return storage.APIKeyRecord{}, storage.UserRecord{}, ErrInvalidAPIKey
+	}
+
+	user, err := s.users.Get(ctx, record.UserID)
+	if errors.Is(err, storage.ErrUserNotFound) || (err == nil && user.ErasedAt != nil) {
+		return storage.APIKeyRecord{}, storage.UserRecord{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return storage.APIKeyRecord{}, storage.UserRecord{}, err
+	}
+
+	// Failing to record the last use does not fail the request
+	_ = s.users.TouchAPIKey(ctx, record.ID, now.UTC())
+	return record, user, nil
+}
+
+// HashAPIKey returns the stored hash of an API key. Keys carry 192 bits of
+// randomness, so an unsalted hash is sufficient and allows lookup by hash.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,9 +2,15 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/secura/api/internal/blockchain"
+	"github.com/secura/api/internal/logging"
+	"github.com/secura/api/internal/metrics"
+	"github.com/secura/api/internal/telemetry"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -12,6 +18,9 @@ import (
 type BlockchainService struct {
 	client *blockchain.Client
 	logger *zap.Logger
+
+	// subjectKey keys the subject hashes published to the consent registry
+	subjectKey []byte
 }
 
 // NewBlockchainService creates a new blockchain service
@@ -36,6 +45,11 @@ func (s *BlockchainService) RecordLLMInteraction(
 	responseData map[string]interface{},
 	metadata map[string]interface{},
 ) (string, error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "audit.RecordLLMInteraction",
+		trace.WithAttributes(telemetry.AttrAuditActionType.String(actionType)),
+	)
+	defer span.End()
+
 	// Create audit log data
 	logData := blockchain.AuditLogData{
 		UserID:       userID,
@@ -46,19 +60,30 @@ func (s *BlockchainService) RecordLLMInteraction(
 	}
 
 	// Record to blockchain
+	metrics.AuditQueueDepth.Inc()
 	txHash, err := s.client.RecordAuditLog(ctx, logData)
+	metrics.AuditQueueDepth.Dec()
 	if err != nil {
-		s.logger.Error("Failed to record audit log",
+		metrics.BlockchainWriteFailuresTotal.Inc()
+		telemetry.RecordError(span, err)
+		logging.FromContext(ctx, s.logger).Error("Failed to record audit log",
 			zap.Error(err),
 			zap.String("user_id", userID),
 			zap.String("action_type", actionType),
 		)
 		return "", fmt.Errorf("failed to record audit log: %w", err)
 	}
+	span.SetAttributes(telemetry.AttrAuditTxHash.String(txHash))
 
 	return txHash, nil
 }
 
+// RecordEvent records an event other than an LLM interaction, such as a data
+// erasure, to the blockchain audit trail
+func (s *BlockchainService) RecordEvent(ctx context.Context, userID string, actionType string, data map[string]interface{}, metadata map[string]interface{}) (string, error) {
+	return s.RecordLLMInteraction(ctx, userID, actionType, data, nil, metadata)
+}
+
 // VerifyContentHash verifies if a content hash exists in the audit trail
 func (s *BlockchainService) VerifyContentHash(ctx context.Context, contentHash string) (bool, error) {
 	exists, err := s.client.VerifyContentHash(ctx, contentHash)
@@ -73,16 +98,81 @@ func (s *BlockchainService) VerifyContentHash(ctx context.Context, contentHash s
 	return exists, nil
 }
 
-// GetUserAuditLogs retrieves all audit logs for a specific user
-func (s *BlockchainService) GetUserAuditLogs(ctx context.Context, userID string) ([]map[string]interface{}, error) {
-	logs, err := s.client.GetAuditLogsByUser(ctx, userID)
+// CheckHealth verifies the blockchain node and audit contract are available
+func (s *BlockchainService) CheckHealth(ctx context.Context) error {
+	return s.client.CheckHealth(ctx)
+}
+
+// EnableConsentRegistry binds the service to the consent registry contract.
+// Subjects are published under hashes keyed by subjectKey, which must stay
+// the same for earlier attestations to be found. Without a private key
+// consent state can be queried but not attested.
+func (s *BlockchainService) EnableConsentRegistry(address string, privateKey string, subjectKey string) error {
+	if subjectKey == "" {
+		return errors.New("no subject key configured for the consent registry")
+	}
+	if err := s.client.EnableConsentRegistry(address, privateKey); err != nil {
+		return err
+	}
+	s.subjectKey = []byte(subjectKey)
+	return nil
+}
+
+// subjectHash returns the on-chain identifier of a tenant-scoped data subject
+func (s *BlockchainService) subjectHash(tenant string, subjectID string) [32]byte {
+	return blockchain.SubjectHash(s.subjectKey, tenant, subjectID)
+}
+
+// AttestConsentGrant records a data subject's consent grant on-chain
+func (s *BlockchainService) AttestConsentGrant(ctx context.Context, tenant string, subjectID string, purpose string, consentID string, expiresAt *time.Time) (string, error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "consent.AttestGrant")
+	defer span.End()
+
+	txHash, err := s.client.AttestConsentGrant(ctx, s.subjectHash(tenant, subjectID), purpose, consentID, expiresAt)
 	if err != nil {
-		s.logger.Error("Failed to get user audit logs",
+		telemetry.RecordError(span, err)
+		logging.FromContext(ctx, s.logger).Error("Failed to attest consent grant",
 			zap.Error(err),
-			zap.String("user_id", userID),
+			zap.String("consent_id", consentID),
 		)
-		return nil, fmt.Errorf("failed to get user audit logs: %w", err)
+		return "", fmt.Errorf("failed to attest consent grant: %w", err)
+	}
+	span.SetAttributes(telemetry.AttrAuditTxHash.String(txHash))
+
+	return txHash, nil
+}
+
+// AttestConsentRevocation records a data subject's consent withdrawal on-chain
+func (s *BlockchainService) AttestConsentRevocation(ctx context.Context, tenant string, subjectID string, purpose string) (string, error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "consent.AttestRevocation")
+	defer span.End()
+
+	txHash, err := s.client.AttestConsentRevocation(ctx, s.subjectHash(tenant, subjectID), purpose)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		logging.FromContext(ctx, s.logger).Error("Failed to attest consent revocation", zap.Error(err))
+		return "", fmt.Errorf("failed to attest consent revocation: %w", err)
 	}
+	span.SetAttributes(telemetry.AttrAuditTxHash.String(txHash))
 
-	return logs, nil
+	return txHash, nil
+}
+
+// GetConsentAttestation returns the on-chain consent state of a subject for a purpose
+func (s *BlockchainService) GetConsentAttestation(ctx context.Context, tenant string, subjectID string, purpose string) (blockchain.ConsentAttestation, error) {
+	attestation, err := s.client.GetConsent(ctx, s.subjectHash(tenant, subjectID), purpose)
+	if err != nil {
+		return blockchain.ConsentAttestation{}, fmt.Errorf("failed to get consent attestation: %w", err)
+	}
+	return attestation, nil
+}
+
+// WasConsentValidAt reports whether the chain proves a subject's consent for a
+// purpose at a point in time, and the reference of the consent relied on
+func (s *BlockchainService) WasConsentValidAt(ctx context.Context, tenant string, subjectID string, purpose string, at time.Time) (bool, string, error) {
+	valid, consentID, err := s.client.WasConsentValidAt(ctx, s.subjectHash(tenant, subjectID), purpose, at)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to check consent attestation: %w", err)
+	}
+	return valid, consentID, nil
 }
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/secura/api/internal/storage"
+)
+
+// ConsentError is returned when a data subject has no valid consent for a purpose
+type ConsentError struct {
+	SubjectID string `json:"subject_id"`
+	Purpose   string `json:"purpose"`
+}
+
+// Error implements the error interface
+func (e *ConsentError) Error() string {
+	return fmt.Sprintf("no valid consent from subject %s for purpose %q", e.SubjectID, e.Purpose)
+}
+
+// ErrConsentExpiryPassed is returned when granting consent that has already expired
+var ErrConsentExpiryPassed = errors.New("consent expiry must be in the future")
+
+// ErrAttestationFailed is returned when a consent grant or withdrawal cannot be attested
+var ErrAttestationFailed = errors.New("consent attestation failed")
+
+// ConsentAttester records consent grants and withdrawals in a tamper-evident
+// registry and returns the transaction reference
+type ConsentAttester interface {
+	AttestConsentGrant(ctx context.Context, tenant string, subjectID string, purpose string, consentID string, expiresAt *time.Time) (string, error)
+	AttestConsentRevocation(ctx context.Context, tenant string, subjectID string, purpose string) (string, error)
+}
+
+// ConsentService manages data subject consent and checks it before processing
+type ConsentService struct {
+	store    storage.ConsentStore
+	attester ConsentAttester
+	now      func() time.Time
+}
+
+// NewConsentService creates a new consent service. If attester is not nil,
+// every grant and withdrawal is attested before it takes effect.
+func NewConsentService(store storage.ConsentStore, attester ConsentAttester) *ConsentService {
+	return &ConsentService{
+		store:    store,
+		attester: attester,
+		now:      time.Now,
+	}
+}
+
+// Grant records a subject's consent to processing for a purpose. A nil
+// expiresAt grants consent until it is withdrawn.
+func (s *ConsentService) Grant(ctx context.Context, tenant string, subjectID string, purpose string, grantedBy string, expiresAt *time.Time) (storage.ConsentRecord, error) {
+	now := s.now().UTC()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return storage.ConsentRecord{}, ErrConsentExpiryPassed
+	}
+
+	record := storage.ConsentRecord{
+		ID:        uuid.NewString(),
+		Tenant:    tenant,
+		SubjectID: subjectID,
+		Purpose:   purpose,
+		GrantedBy: grantedBy,
+		GrantedAt: now,
+		ExpiresAt: expiresAt,
+	}
+
+	if s.attester != nil {
+		txHash, err := s.attester.AttestConsentGrant(ctx, tenant, subjectID, purpose, record.ID, expiresAt)
+		if err != nil {
+			return storage.ConsentRecord{}, fmt.Errorf("%w: %v", ErrAttestationFailed, err)
+		}
+		record.AttestationTx = txHash
+	}
+
+	record, err := s.store.Grant(ctx, record)
+	if err != nil {
+		return storage.ConsentRecord{}, fmt.Errorf("failed to grant consent: %w", err)
+	}
+	return record, nil
+}
+
+// Withdraw withdraws a subject's active consent for a purpose
+func (s *ConsentService) Withdraw(ctx context.Context, tenant string, subjectID string, purpose string, withdrawnBy string) (storage.ConsentRecord, error) {
+	if _, err := s.store.Active(ctx, tenant, subjectID, purpose, s.now().UTC()); err != nil {
+		return storage.ConsentRecord{}, fmt.Errorf("failed to withdraw consent: %w", err)
+	}
+
+	var revocationTx string
+	if s.attester != nil {
+		txHash, err := s.attester.AttestConsentRevocation(ctx, tenant, subjectID, purpose)
+		if err != nil {
+			return storage.ConsentRecord{}, fmt.Errorf("%w: %v", ErrAttestationFailed, err)
+		}
+		revocationTx = txHash
+	}
+
+	record, err := s.store.Withdraw(ctx, tenant, subjectID, purpose, withdrawnBy, revocationTx, s.now().UTC())
+	if err != nil {
+		return storage.ConsentRecord{}, fmt.Errorf("failed to withdraw consent: %w", err)
+	}
+	return record, nil
+}
+
+// List returns the consent history of a subject
+func (s *ConsentService) List(ctx context.Context, tenant string, subjectID string) ([]storage.ConsentRecord, error) {
+	records, err := s.store.List(ctx, tenant, subjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list consents: %w", err)
+	}
+	return records, nil
+}
+
+// ReplaceSubject replaces a subject's identifier in its consent history and
+// returns the number of records changed
+func (s *ConsentService) ReplaceSubject(ctx context.Context, tenant string, subjectID string, replacement string) (int, error) {
+	replaced, err := s.store.ReplaceSubject(ctx, tenant, subjectID, replacement)
+	if err != nil {
+		return 0, fmt.Errorf("failed to replace consent subject: %w", err)
+	}
+	return replaced, nil
+}
+
+// Check returns the subject's active consent for a purpose, or a *ConsentError
+// if there is none
+func (s *ConsentService) Check(ctx context.Context, tenant string, subjectID string, purpose string) (storage.ConsentRecord, error) {
+	record, err := s.store.Active(ctx, tenant, subjectID, purpose, s.now().UTC())
+	if errors.Is(err, storage.ErrConsentNotFound) {
+		return storage.ConsentRecord{}, &ConsentError{SubjectID: subjectID, Purpose: purpose}
+	}
+	if err != nil {
+		return storage.ConsentRecord{}, fmt.Errorf("failed to check consent: %w", err)
+	}
+	return record, nil
+}
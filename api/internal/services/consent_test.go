@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/secura/api/internal/storage"
+)
+
+// chainConsent is a consent window held by simulatedRegistry
+type chainConsent struct {
+	consentID string
+	expiresAt *time.Time
+	revoked   bool
+}
+
+// simulatedRegistry is a ConsentAttester that applies the rules of the
+// ConsentRegistry contract in memory: a grant supersedes the active consent
+// for the purpose and a revocation requires an active consent
+type simulatedRegistry struct {
+	consents map[string][]chainConsent
+	txs      int
+	fail     error
+}
+
+func newSimulatedRegistry() *simulatedRegistry {
+	return &simulatedRegistry{consents: make(map[string][]chainConsent)}
+}
+
+func (r *simulatedRegistry) AttestConsentGrant(_ context.Context, tenant string, subjectID string, purpose string, consentID string, expiresAt *time.Time) (string, error) {
+	if r.fail != nil {
+		return "", r.fail
+	}
+	key := tenant + "|" + subjectID + "|" + purpose
+	windows := r.consents[key]
+	if len(windows) > 0 {
+		windows[len(windows)-1].revoked = true
+	}
+	r.consents[key] = append(windows, chainConsent{consentID: consentID, expiresAt: expiresAt})
+	return r.tx(), nil
+}
+
+func (r *simulatedRegistry) AttestConsentRevocation(_ context.Context, tenant string, subjectID string, purpose string) (string, error) {
+	if r.fail != nil {
+		return "", r.fail
+	}
+	windows := r.consents[tenant+"|"+subjectID+"|"+purpose]
+	if len(windows) == 0 || windows[len(windows)-1].revoked {
+		return "", errors.New("execution reverted: No active consent")
+	}
+	windows[len(windows)-1].revoked = true
+	return r.tx(), nil
+}
+
+// latest returns the latest consent window for a subject and purpose
+func (r *simulatedRegistry) latest(tenant string, subjectID string, purpose string) (chainConsent, bool) {
+	windows := r.consents[tenant+"|"+subjectID+"|"+purpose]
+	if len(windows) == 0 {
+		return chainConsent{}, false
+	}
+	return windows[len(windows)-1], true
+}
+
+func (r *simulatedRegistry) tx() string {
+	r.txs++
+	return fmt.Sprintf("0x%064x", r.txs)
+}
+
+// newTestConsentService creates a consent service attesting to registry at a fixed time
+func newTestConsentService(registry *simulatedRegistry, now time.Time) *ConsentService {
+	service := NewConsentService(storage.NewMemoryConsentStore(), registry)
+	service.now = func() time.Time { return now }
+	return service
+}
+
+func TestConsentGrantAndCheck(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	registry := newSimulatedRegistry()
+	service := newTestConsentService(registry, now)
+	ctx := context.Background()
+
+	record, err := service.Grant(ctx, "acme", "subject-1", "summarization", "user-123", nil)
+	if err != nil {
+		t.Fatalf("Grant() error = %v", err)
+	}
+	if record.AttestationTx == "" {
+		t.Error("Grant() did not record the attestation transaction")
+	}
+	onChain, ok := registry.latest("acme", "subject-1", "summarization")
+	if !ok || onChain.consentID != record.ID || onChain.revoked {
+		t.Errorf("registry consent = %+v, want active consent %s", onChain, record.ID)
+	}
+
+	checked, err := service.Check(ctx, "acme", "subject-1", "summarization")
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if checked.ID != record.ID {
+		t.Errorf("Check() = consent %s, want %s", checked.ID, record.ID)
+	}
+
+	// Consent is scoped to the tenant and purpose
+	for _, tt := range []struct{ tenant, purpose string }{
+		{"other", "summarization"},
+		{"acme", "marketing"},
+	} {
+		var consentErr *ConsentError
+		if _, err := service.Check(ctx, tt.tenant, "subject-1", tt.purpose); !errors.As(err, &consentErr) {
+			t.Errorf("Check(%q, %q) error = %v, want a *ConsentError", tt.tenant, tt.purpose, err)
+		}
+	}
+}
+
+func TestConsentGrantExpiry(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	registry := newSimulatedRegistry()
+	service := newTestConsentService(registry, now)
+	ctx := context.Background()
+
+	past := now.Add(-time.Minute)
+	if _, err := service.Grant(ctx, "", "subject-1", "summarization", "user-123", &past); !errors.Is(err, ErrConsentExpiryPassed) {
+		t.Fatalf("Grant() with a past expiry error = %v, want ErrConsentExpiryPassed", err)
+	}
+	if _, ok := registry.latest("", "subject-1", "summarization"); ok {
+		t.Error("Grant() with a past expiry was attested")
+	}
+
+	expiresAt := now.Add(time.Hour)
+	if _, err := service.Grant(ctx, "", "subject-1", "summarization", "user-123", &expiresAt); err != nil {
+		t.Fatalf("Grant() error = %v", err)
+	}
+	if _, err := service.Check(ctx, "", "subject-1", "summarization"); err != nil {
+		t.Fatalf("Check() before expiry error = %v", err)
+	}
+
+	service.now = func() time.Time { return expiresAt }
+	if _, err := service.Check(ctx, "", "subject-1", "summarization"); err == nil {
+		t.Fatal("Check() at expiry error = nil, want a *ConsentError")
+	}
+}
+
+func TestConsentWithdraw(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	registry := newSimulatedRegistry()
+	service := newTestConsentService(registry, now)
+	ctx := context.Background()
+
+	if _, err := service.Grant(ctx, "", "subject-1", "summarization", "user-123", nil); err != nil {
+		t.Fatalf("Grant() error = %v", err)
+	}
+
+	record, err := service.Withdraw(ctx, "", "subject-1", "summarization", "user-456")
+	if err != nil {
+		t.Fatalf("Withdraw() error = %v", err)
+	}
+	if record.WithdrawnAt == nil || record.WithdrawnBy != "user-456" || record.RevocationTx == "" {
+		t.Errorf("Withdraw() = %+v, want a withdrawal by user-456 with a revocation transaction", record)
+	}
+	if onChain, _ := registry.latest("", "subject-1", "summarization"); !onChain.revoked {
+		t.Error("Withdraw() did not revoke the consent on-chain")
+	}
+
+	var consentErr *ConsentError
+	if _, err := service.Check(ctx, "", "subject-1", "summarization"); !errors.As(err, &consentErr) {
+		t.Errorf("Check() after withdrawal error = %v, want a *ConsentError", err)
+	}
+
+	// There is no active consent left to withdraw
+	if _, err := service.Withdraw(ctx, "", "subject-1", "summarization", "user-456"); !errors.Is(err, storage.ErrConsentNotFound) {
+		t.Errorf("second Withdraw() error = %v, want ErrConsentNotFound", err)
+	}
+}
+
+func TestConsentRegrantAfterWithdraw(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	registry := newSimulatedRegistry()
+	service := newTestConsentService(registry, now)
+	ctx := context.Background()
+
+	if _, err := service.Grant(ctx, "", "subject-1", "summarization", "user-123", nil); err != nil {
+		t.Fatalf("Grant() error = %v", err)
+	}
+	if _, err := service.Withdraw(ctx, "", "subject-1", "summarization", "user-123"); err != nil {
+		t.Fatalf("Withdraw() error = %v", err)
+	}
+	regranted, err := service.Grant(ctx, "", "subject-1", "summarization", "user-123", nil)
+	if err != nil {
+		t.Fatalf("second Grant() error = %v", err)
+	}
+
+	checked, err := service.Check(ctx, "", "subject-1", "summarization")
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if checked.ID != regranted.ID {
+		t.Errorf("Check() = consent %s, want the new grant %s", checked.ID, regranted.ID)
+	}
+	history, err := service.List(ctx, "", "subject-1")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(history) != 2 {
+		t.Errorf("List() returned %d records, want 2", len(history))
+	}
+}
+
+func TestConsentAttestationFailure(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	registry := newSimulatedRegistry()
+	service := newTestConsentService(registry, now)
+	ctx := context.Background()
+
+	// A grant that cannot be attested does not take effect
+	registry.fail = errors.New("node unavailable")
+	if _, err := service.Grant(ctx, "", "subject-1", "summarization", "user-123", nil); !errors.Is(err, ErrAttestationFailed) {
+		t.Fatalf("Grant() error = %v, want ErrAttestationFailed", err)
+	}
+	if _, err := service.Check(ctx, "", "subject-1", "summarization"); err == nil {
+		t.Fatal("Check() error = nil after a failed grant")
+	}
+
+	// A withdrawal that cannot be attested leaves the consent active
+	registry.fail = nil
+	if _, err := service.Grant(ctx, "", "subject-1", "summarization", "user-123", nil); err != nil {
+		t.Fatalf("Grant() error = %v", err)
+	}
+	registry.fail = errors.New("node unavailable")
+	if _, err := service.Withdraw(ctx, "", "subject-1", "summarization", "user-123"); !errors.Is(err, ErrAttestationFailed) {
+		t.Fatalf("Withdraw() error = %v, want ErrAttestationFailed", err)
+	}
+	if _, err := service.Check(ctx, "", "subject-1", "summarization"); err != nil {
+		t.Errorf("Check() after a failed withdrawal error = %v, want the consent kept", err)
+	}
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/logging"
+	"github.com/secura/api/internal/storage"
+)
+
+// ErrDSARTargetRequired is returned when a DSAR names neither a user nor a data subject
+var ErrDSARTargetRequired = errors.New("user_id or subject_id is required")
+
+// erasedAuditMetadata are the audit metadata fields holding a user's personal data
+var erasedAuditMetadata = []string{"ip_address", "user_agent"}
+
+// erasedSubjectPrefix marks the tombstones that replace erased data subject identifiers
+const erasedSubjectPrefix = "erased-"
+
+// DSARExport is everything the gateway holds about a user or data subject
+type DSARExport struct {
+	GeneratedAt  time.Time                `json:"generated_at"`
+	Tenant       string                   `json:"tenant,omitempty"`
+	UserID       string                   `json:"user_id,omitempty"`
+	SubjectID    string                   `json:"subject_id,omitempty"`
+	User         *storage.UserRecord      `json:"user,omitempty"`
+	APIKeys      []storage.APIKeyRecord   `json:"api_keys"`
+	AuditEntries []storage.AuditEntry     `json:"audit_entries"`
+	Pseudonyms   []storage.PseudonymEntry `json:"pseudonyms"`
+	Consents     []storage.ConsentRecord  `json:"consents"`
+}
+
+// ErasureReceipt records what an erasure removed. It holds no personal data:
+// the data subject is identified only by the random tombstone that replaced
+// its identifier, which cannot be derived from the identifier.
+type ErasureReceipt struct {
+	ID                   string    `json:"id"`
+	Tenant               string    `json:"tenant,omitempty"`
+	UserID               string    `json:"user_id,omitempty"`
+	SubjectTombstone     string    `json:"subject_tombstone,omitempty"`
+	RequestedBy          string    `json:"requested_by"`
+	ErasedAt             time.Time `json:"erased_at"`
+	UserErased           bool      `json:"user_erased"`
+	APIKeysRevoked       int       `json:"api_keys_revoked"`
+	AuditEntriesRedacted int       `json:"audit_entries_redacted"`
+	PseudonymsShredded   int       `json:"pseudonyms_shredded"`
+	ConsentsRedacted     int       `json:"consents_redacted"`
+	AuditTx              string    `json:"audit_tx,omitempty"`
+}
+
+// DSARService answers data subject access and erasure requests. Users are
+// only covered by the requests of the tenants they belong to.
+type DSARService struct {
+	cfgStore          *config.Store
+	users             storage.UserStore
+	audit             storage.AuditStore
+	vault             storage.PseudonymVault
+	consents          *ConsentService
+	blockchainService *BlockchainService
+	logger            *zap.Logger
+	now               func() time.Time
+}
+
+// NewDSARService creates a new DSAR service. If blockchainService is not nil,
+// erasure receipts are also recorded on-chain.
+func NewDSARService(cfgStore *config.Store, users storage.UserStore, audit storage.AuditStore, vault storage.PseudonymVault, consents *ConsentService, blockchainService *BlockchainService, logger *zap.Logger) *DSARService {
+	return &DSARService{
+		cfgStore:          cfgStore,
+		users:             users,
+		audit:             audit,
+		vault:             vault,
+		consents:          consents,
+		blockchainService: blockchainService,
+		logger:            logger,
+		now:               time.Now,
+	}
+}
+
+// Export returns everything held about a user and/or data subject within a tenant
+func (s *DSARService) Export(ctx context.Context, tenant string, userID string, subjectID string) (*DSARExport, error) {
+	if userID == "" && subjectID == "" {
+		return nil, ErrDSARTargetRequired
+	}
+	if userID != "" && !s.cfgStore.Current().IsMember(tenant, userID) {
+		return nil, storage.ErrUserNotFound
+	}
+
+	export := &DSARExport{
+		GeneratedAt:  s.now().UTC(),
+		Tenant:       tenant,
+		UserID:       userID,
+		SubjectID:    subjectID,
+		APIKeys:      []storage.APIKeyRecord{},
+		AuditEntries: []storage.AuditEntry{},
+		Pseudonyms:   []storage.PseudonymEntry{},
+		Consents:     []storage.ConsentRecord{},
+	}
+
+	if userID != "" {
+		user, err := s.users.Get(ctx, userID)
+		if err != nil && !errors.Is(err, storage.ErrUserNotFound) {
+			return nil, fmt.Errorf("failed to export user: %w", err)
+		}
+		if err == nil {
+			export.User = &user
+		}
+		keys, err := s.users.APIKeys(ctx, tenant, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export API keys: %w", err)
+		}
+		export.APIKeys = append(export.APIKeys, keys...)
+
+		entries, err := s.audit.ListByUser(ctx, tenant, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export audit entries: %w", err)
+		}
+		export.AuditEntries = append(export.AuditEntries, entries...)
+	}
+
+	if subjectID != "" {
+		pseudonyms, err := s.vault.List(ctx, tenant, subjectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export pseudonyms: %w", err)
+		}
+		export.Pseudonyms = append(export.Pseudonyms, pseudonyms...)
+		consents, err := s.consents.List(ctx, tenant, subjectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export consents: %w", err)
+		}
+		export.Consents = append(export.Consents, consents...)
+
+		entries, err := s.audit.ListBySubject(ctx, tenant, subjectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export audit entries: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.UserID != userID {
+				export.AuditEntries = append(export.AuditEntries, entry)
+			}
+		}
+	}
+
+	return export, nil
+}
+
+// Erase removes the personal data held about a user and/or data subject within
+// a tenant and records a receipt in the audit trail. A subject's pseudonym
+// vault key is shredded and its identifier in consent and audit records is
+// replaced by a random tombstone, so the records can no longer be found from
+// the identifier. Consent records keep their IDs, which on-chain attestations
+// reference.
+func (s *DSARService) Erase(ctx context.Context, tenant string, userID string, subjectID string, requestedBy string) (*ErasureReceipt, error) {
+	if userID == "" && subjectID == "" {
+		return nil, ErrDSARTargetRequired
+	}
+	if userID != "" && !s.cfgStore.Current().IsMember(tenant, userID) {
+		return nil, storage.ErrUserNotFound
+	}
+
+	receipt := &ErasureReceipt{
+		ID:          uuid.NewString(),
+		Tenant:      tenant,
+		UserID:      userID,
+		RequestedBy: requestedBy,
+		ErasedAt:    s.now().UTC(),
+	}
+
+	if userID != "" {
+		_, revoked, err := s.users.Erase(ctx, userID, receipt.ErasedAt)
+		if err != nil && !errors.Is(err, storage.ErrUserNotFound) {
+			return nil, fmt.Errorf("failed to erase user: %w", err)
+		}
+		receipt.UserErased = err == nil
+		receipt.APIKeysRevoked = revoked
+		scrubbed, err := s.audit.ScrubUser(ctx, tenant, userID, erasedAuditMetadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to redact audit entries: %w", err)
+		}
+		receipt.AuditEntriesRedacted += scrubbed
+	}
+
+	if subjectID != "" {
+		receipt.SubjectTombstone = erasedSubjectPrefix + uuid.NewString()
+		shredded, err := s.vault.Shred(ctx, tenant, subjectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to shred pseudonyms: %w", err)
+		}
+		receipt.PseudonymsShredded = shredded
+		redacted, err := s.consents.ReplaceSubject(ctx, tenant, subjectID, receipt.SubjectTombstone)
+		if err != nil {
+			return nil, fmt.Errorf("failed to redact consents: %w", err)
+		}
+		receipt.ConsentsRedacted = redacted
+		replaced, err := s.audit.ReplaceSubject(ctx, tenant, subjectID, receipt.SubjectTombstone)
+		if err != nil {
+			return nil, fmt.Errorf("failed to redact audit entries: %w", err)
+		}
+		receipt.AuditEntriesRedacted += replaced
+	}
+
+	s.recordReceipt(ctx, receipt)
+	return receipt, nil
+}
+
+// recordReceipt records an erasure receipt in the audit trail
+func (s *DSARService) recordReceipt(ctx context.Context, receipt *ErasureReceipt) {
+	metadata := map[string]interface{}{
+		"tenant":                 receipt.Tenant,
+		"receipt_id":             receipt.ID,
+		"user_erased":            receipt.UserErased,
+		"api_keys_revoked":       receipt.APIKeysRevoked,
+		"audit_entries_redacted": receipt.AuditEntriesRedacted,
+		"pseudonyms_shredded":    receipt.PseudonymsShredded,
+		"consents_redacted":      receipt.ConsentsRedacted,
+	}
+	if receipt.UserID != "" {
+		metadata["erased_user_id"] = receipt.UserID
+	}
+	if receipt.SubjectTombstone != "" {
+		metadata["subject_tombstone"] = receipt.SubjectTombstone
+	}
+
+	if s.blockchainService != nil {
+		data := map[string]interface{}{
+			"receipt_id": receipt.ID,
+			"erased_at":  receipt.ErasedAt,
+		}
+		txHash, err := s.blockchainService.RecordEvent(ctx, receipt.RequestedBy, "erasure", data, metadata)
+		if err != nil {
+			logging.FromContext(ctx, s.logger).Error("Failed to record erasure receipt on-chain",
+				zap.Error(err),
+				zap.String("receipt_id", receipt.ID),
+			)
+		}
+		receipt.AuditTx = txHash
+	}
+
+	_, err := s.audit.Add(ctx, storage.AuditEntry{
+		Tenant:     receipt.Tenant,
+		UserID:     receipt.RequestedBy,
+		SubjectID:  receipt.SubjectTombstone,
+		ActionType: "erasure",
+		TxHash:     receipt.AuditTx,
+		Timestamp:  receipt.ErasedAt,
+		Metadata:   metadata,
+	})
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to index erasure receipt",
+			zap.Error(err),
+			zap.String("receipt_id", receipt.ID),
+		)
+	}
+
+	logging.FromContext(ctx, s.logger).Info("Erased personal data",
+		zap.String("receipt_id", receipt.ID),
+		zap.String("tenant", receipt.Tenant),
+		zap.String("requested_by", receipt.RequestedBy),
+	)
+}
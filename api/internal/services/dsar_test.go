@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/storage"
+)
+
+func TestEraseSubject(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	audit := storage.NewMemoryAuditStore()
+	vault := storage.NewMemoryPseudonymVault()
+	consents := newTestConsentService(newSimulatedRegistry(), now)
+	service := NewDSARService(config.NewStore(config.Defaults()), storage.NewMemoryUserStore(), audit, vault, consents, nil, zap.NewNop())
+	service.now = func() time.Time { return now }
+
+	granted, err := consents.Grant(ctx, "acme", "subject-1", "summarization", "user-123", nil)
+	if err != nil {
+		t.Fatalf("Grant() error = %v", err)
+	}
+	if _, err := vault.Put(ctx, "acme", "subject-1", "PERSON", "John Smith", now); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if _, err := audit.Add(ctx, storage.AuditEntry{Tenant: "acme", UserID: "user-123", SubjectID: "subject-1", ActionType: "llm_completion", Timestamp: now}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	receipt, err := service.Erase(ctx, "acme", "", "subject-1", "admin")
+	if err != nil {
+		t.Fatalf("Erase() error = %v", err)
+	}
+	if receipt.ConsentsRedacted != 1 || receipt.AuditEntriesRedacted != 1 || receipt.PseudonymsShredded != 1 {
+		t.Errorf("Erase() = %+v, want one consent, audit entry and pseudonym erased", receipt)
+	}
+	if !strings.HasPrefix(receipt.SubjectTombstone, erasedSubjectPrefix) || strings.Contains(receipt.SubjectTombstone, "subject-1") {
+		t.Errorf("receipt tombstone = %q, want a random tombstone", receipt.SubjectTombstone)
+	}
+
+	// Nothing can be found from the erased identifier any more
+	export, err := service.Export(ctx, "acme", "", "subject-1")
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if len(export.Consents) != 0 || len(export.AuditEntries) != 0 || len(export.Pseudonyms) != 0 {
+		t.Errorf("Export() after erasure = %+v, want nothing", export)
+	}
+
+	// The consent record is kept under the tombstone with its on-chain reference
+	redacted, err := consents.List(ctx, "acme", receipt.SubjectTombstone)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(redacted) != 1 || redacted[0].ID != granted.ID || redacted[0].AttestationTx != granted.AttestationTx {
+		t.Errorf("consents under the tombstone = %+v, want the granted consent", redacted)
+	}
+
+	// Erasing the same subject again gives a different tombstone
+	again, err := service.Erase(ctx, "acme", "", "subject-1", "admin")
+	if err != nil {
+		t.Fatalf("second Erase() error = %v", err)
+	}
+	if again.SubjectTombstone == receipt.SubjectTombstone {
+		t.Error("Erase() reused a tombstone")
+	}
+}
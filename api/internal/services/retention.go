@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/logging"
+	"github.com/secura/api/internal/metrics"
+	"github.com/secura/api/internal/storage"
+)
+
+// RetentionSystemUser is recorded as the requester of scheduled purge runs
+const RetentionSystemUser = "system"
+
+// RetentionReport describes a retention purge run. In a dry run nothing is
+// purged and Eligible reports what would have been.
+type RetentionReport struct {
+	ID          string         `json:"id"`
+	RunAt       time.Time      `json:"run_at"`
+	DryRun      bool           `json:"dry_run"`
+	RequestedBy string         `json:"requested_by"`
+	Eligible    int            `json:"eligible"`
+	Purged      int            `json:"purged"`
+	Held        int            `json:"held"`
+	ByAction    map[string]int `json:"by_action"`
+	AuditTx     string         `json:"audit_tx,omitempty"`
+}
+
+// RetentionService purges audit index entries that have outlived their
+// retention period, except entries under legal hold
+type RetentionService struct {
+	cfgStore          *config.Store
+	audit             storage.AuditStore
+	blockchainService *BlockchainService
+	logger            *zap.Logger
+	now               func() time.Time
+}
+
+// NewRetentionService creates a new retention service. If blockchainService is
+// not nil, each run is also recorded on-chain.
+func NewRetentionService(cfgStore *config.Store, audit storage.AuditStore, blockchainService *BlockchainService, logger *zap.Logger) *RetentionService {
+	return &RetentionService{
+		cfgStore:          cfgStore,
+		audit:             audit,
+		blockchainService: blockchainService,
+		logger:            logger,
+		now:               time.Now,
+	}
+}
+
+// RunScheduled runs a purge with the configured dry-run setting. It is the
+// entry point of the scheduled retention job.
+func (s *RetentionService) RunScheduled(ctx context.Context) error {
+	_, err := s.Run(ctx, s.cfgStore.Current().Retention.DryRun, RetentionSystemUser)
+	return err
+}
+
+// Run purges the audit entries past their retention period, or only reports
+// them in a dry run, and records the run in the audit trail
+func (s *RetentionService) Run(ctx context.Context, dryRun bool, requestedBy string) (*RetentionReport, error) {
+	cfg := s.cfgStore.Current()
+	now := s.now().UTC()
+	minimum := time.Duration(cfg.Retention.Minimum)
+
+	report := &RetentionReport{
+		ID:          uuid.NewString(),
+		RunAt:       now,
+		DryRun:      dryRun,
+		RequestedBy: requestedBy,
+		ByAction:    map[string]int{},
+	}
+
+	candidates, err := s.audit.ListBefore(ctx, now.Add(-minimum))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+
+	expired := make(map[string][]string)
+	for _, entry := range candidates {
+		period, ok := cfg.RetentionFor(entry.Tenant, entry.ActionType)
+		if !ok || !entry.Timestamp.Before(now.Add(-period)) {
+			continue
+		}
+		if entry.LegalHold {
+			report.Held++
+			continue
+		}
+		expired[entry.ActionType] = append(expired[entry.ActionType], entry.ID)
+		report.Eligible++
+		report.ByAction[entry.ActionType]++
+	}
+
+	// Purge by action type so the metric counts what was actually deleted;
+	// entries placed under legal hold since they were listed are skipped
+	if !dryRun {
+		for action, ids := range expired {
+			purged, err := s.audit.Delete(ctx, ids)
+			if err != nil {
+				return nil, fmt.Errorf("failed to purge audit entries: %w", err)
+			}
+			report.Purged += purged
+			metrics.AuditRecordsPurgedTotal.WithLabelValues(action).Add(float64(purged))
+		}
+	}
+
+	s.recordRun(ctx, report)
+	return report, nil
+}
+
+// recordRun records a purge run in the audit trail
+func (s *RetentionService) recordRun(ctx context.Context, report *RetentionReport) {
+	metadata := map[string]interface{}{
+		"run_id":    report.ID,
+		"dry_run":   report.DryRun,
+		"eligible":  report.Eligible,
+		"purged":    report.Purged,
+		"held":      report.Held,
+		"by_action": report.ByAction,
+	}
+
+	if s.blockchainService != nil {
+		data := map[string]interface{}{
+			"run_id": report.ID,
+			"run_at": report.RunAt,
+		}
+		txHash, err := s.blockchainService.RecordEvent(ctx, report.RequestedBy, "retention_purge", data, metadata)
+		if err != nil {
+			logging.FromContext(ctx, s.logger).Error("Failed to record retention run on-chain",
+				zap.Error(err),
+				zap.String("run_id", report.ID),
+			)
+		}
+		report.AuditTx = txHash
+	}
+
+	_, err := s.audit.Add(ctx, storage.AuditEntry{
+		UserID:     report.RequestedBy,
+		ActionType: "retention_purge",
+		TxHash:     report.AuditTx,
+		Timestamp:  report.RunAt,
+		Metadata:   metadata,
+	})
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to index retention run",
+			zap.Error(err),
+			zap.String("run_id", report.ID),
+		)
+	}
+
+	logging.FromContext(ctx, s.logger).Info("Audit retention run completed",
+		zap.String("run_id", report.ID),
+		zap.Bool("dry_run", report.DryRun),
+		zap.Int("eligible", report.Eligible),
+		zap.Int("purged", report.Purged),
+		zap.Int("held", report.Held),
+	)
+}
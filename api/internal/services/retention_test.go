@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/metrics"
+	"github.com/secura/api/internal/storage"
+)
+
+// retentionNow is the fixed time retention runs are tested at
+var retentionNow = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// days returns a retention period of n days
+func days(n int) config.RetentionPeriod {
+	return config.RetentionPeriod(time.Duration(n) * 24 * time.Hour)
+}
+
+// newTestRetentionService creates a retention service at retentionNow over an
+// audit index holding entries of the given ages. Records are kept 30 days by
+// default, chats 90 days and consents forever; tenant eu keeps everything 10 days.
+func newTestRetentionService(t *testing.T, minimum config.RetentionPeriod) (*RetentionService, *storage.MemoryAuditStore) {
+	t.Helper()
+	cfg := config.Defaults()
+	defaultPeriod, euPeriod := days(30), days(10)
+	cfg.Retention.Minimum = minimum
+	cfg.Retention.Rules = config.RetentionRules{
+		Default: &defaultPeriod,
+		Actions: map[string]config.RetentionPeriod{"chat": days(90), "consent_grant": 0},
+	}
+	cfg.Tenants = map[string]config.TenantConfig{
+		"eu": {Members: []string{"user-456"}, Retention: &config.RetentionRules{Default: &euPeriod}},
+	}
+
+	audit := storage.NewMemoryAuditStore()
+	for _, entry := range []struct {
+		id     string
+		tenant string
+		action string
+		age    int
+		onHold bool
+	}{
+		{id: "acme-completion-40d", tenant: "acme", action: "completion", age: 40},
+		{id: "acme-completion-20d", tenant: "acme", action: "completion", age: 20},
+		{id: "acme-completion-held", tenant: "acme", action: "completion", age: 60, onHold: true},
+		{id: "acme-chat-40d", tenant: "acme", action: "chat", age: 40},
+		{id: "acme-chat-100d", tenant: "acme", action: "chat", age: 100},
+		{id: "acme-consent-1000d", tenant: "acme", action: "consent_grant", age: 1000},
+		{id: "eu-completion-15d", tenant: "eu", action: "completion", age: 15},
+		{id: "eu-chat-15d", tenant: "eu", action: "chat", age: 15},
+	} {
+		_, err := audit.Add(context.Background(), storage.AuditEntry{
+			ID:         entry.id,
+			Tenant:     entry.tenant,
+			UserID:     "user-123",
+			ActionType: entry.action,
+			Timestamp:  retentionNow.AddDate(0, 0, -entry.age),
+			LegalHold:  entry.onHold,
+		})
+		if err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	service := NewRetentionService(config.NewStore(cfg), audit, nil, zap.NewNop())
+	service.now = func() time.Time { return retentionNow }
+	return service, audit
+}
+
+func TestRetentionRun(t *testing.T) {
+	tests := []struct {
+		name         string
+		minimum      config.RetentionPeriod
+		dryRun       bool
+		wantEligible map[string]int
+		wantHeld     int
+		wantKept     []string
+	}{
+		{
+			name:         "purge",
+			wantEligible: map[string]int{"completion": 2, "chat": 2},
+			wantHeld:     1,
+			wantKept:     []string{"acme-chat-40d", "acme-completion-20d", "acme-completion-held", "acme-consent-1000d"},
+		},
+		{
+			name:         "dry run",
+			dryRun:       true,
+			wantEligible: map[string]int{"completion": 2, "chat": 2},
+			wantHeld:     1,
+			wantKept:     []string{"acme-chat-100d", "acme-chat-40d", "acme-completion-20d", "acme-completion-40d", "acme-completion-held", "acme-consent-1000d", "eu-chat-15d", "eu-completion-15d"},
+		},
+		{
+			// Records younger than the minimum are kept whatever the rules say
+			name:         "minimum retention",
+			minimum:      days(50),
+			wantEligible: map[string]int{"chat": 1},
+			wantHeld:     1,
+			wantKept:     []string{"acme-chat-40d", "acme-completion-20d", "acme-completion-40d", "acme-completion-held", "acme-consent-1000d", "eu-chat-15d", "eu-completion-15d"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			service, audit := newTestRetentionService(t, tt.minimum)
+			purgedBefore := map[string]float64{}
+			for _, action := range []string{"completion", "chat"} {
+				purgedBefore[action] = testutil.ToFloat64(metrics.AuditRecordsPurgedTotal.WithLabelValues(action))
+			}
+
+			report, err := service.Run(ctx, tt.dryRun, "admin")
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			eligible := 0
+			for _, count := range tt.wantEligible {
+				eligible += count
+			}
+			wantPurged := eligible
+			if tt.dryRun {
+				wantPurged = 0
+			}
+			if report.Eligible != eligible || report.Purged != wantPurged || report.Held != tt.wantHeld || !reflect.DeepEqual(report.ByAction, tt.wantEligible) {
+				t.Errorf("Run() report = %+v, want %d eligible by action %v, %d purged and %d held", report, eligible, tt.wantEligible, wantPurged, tt.wantHeld)
+			}
+			if !report.RunAt.Equal(retentionNow) || report.DryRun != tt.dryRun || report.RequestedBy != "admin" {
+				t.Errorf("Run() report = %+v, want a run by admin at %s", report, retentionNow)
+			}
+
+			// The metric counts the records actually deleted
+			for _, action := range []string{"completion", "chat"} {
+				want := float64(tt.wantEligible[action])
+				if tt.dryRun {
+					want = 0
+				}
+				if got := testutil.ToFloat64(metrics.AuditRecordsPurgedTotal.WithLabelValues(action)) - purgedBefore[action]; got != want {
+					t.Errorf("%s records purged metric increased by %v, want %v", action, got, want)
+				}
+			}
+
+			// Only the expired records are gone, and the run itself is recorded
+			remaining, err := audit.ListBefore(ctx, retentionNow.Add(time.Hour))
+			if err != nil {
+				t.Fatalf("ListBefore() error = %v", err)
+			}
+			var kept []string
+			var runs []storage.AuditEntry
+			for _, entry := range remaining {
+				if entry.ActionType == "retention_purge" {
+					runs = append(runs, entry)
+					continue
+				}
+				kept = append(kept, entry.ID)
+			}
+			sort.Strings(kept)
+			if !reflect.DeepEqual(kept, tt.wantKept) {
+				t.Errorf("entries after Run() = %v, want %v", kept, tt.wantKept)
+			}
+			if len(runs) != 1 {
+				t.Fatalf("%d retention_purge entries, want 1", len(runs))
+			}
+			run := runs[0]
+			if run.UserID != "admin" || run.Metadata["run_id"] != report.ID || run.Metadata["dry_run"] != tt.dryRun || run.Metadata["eligible"] != eligible || run.Metadata["purged"] != wantPurged || run.Metadata["held"] != tt.wantHeld {
+				t.Errorf("retention_purge entry = %+v, want the run report", run)
+			}
+		})
+	}
+}
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/storage"
+)
+
+// Quota metrics
+const (
+	QuotaMetricTokens = "tokens"
+	QuotaMetricCost   = "cost"
+)
+
+// ErrModelUnpriced is returned when a cost quota applies to a request for a
+// model without a configured price, whose cost could not be accounted
+var ErrModelUnpriced = errors.New("model has no configured price")
+
+// QuotaError is returned when a hard usage quota has been exceeded
+type QuotaError struct {
+	Scope  string  `json:"scope"`
+	Period string  `json:"period"`
+	Metric string  `json:"metric"`
+	Used   float64 `json:"used"`
+	Limit  float64 `json:"limit"`
+}
+
+// Error implements the error interface
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s %s %s quota exceeded (%.4g of %.4g)", e.Scope, e.Period, e.Metric, e.Used, e.Limit)
+}
+
+// UsagePeriod reports usage against limits for a single period
+type UsagePeriod struct {
+	Period     string               `json:"period"`
+	Usage      storage.UsageCounter `json:"usage"`
+	TokenLimit int64                `json:"token_limit,omitempty"`
+	CostLimit  float64              `json:"cost_limit_usd,omitempty"`
+}
+
+// UsageScopeReport reports daily and monthly usage for a user, team or tenant
+type UsageScopeReport struct {
+	ID      string      `json:"id"`
+	Daily   UsagePeriod `json:"daily"`
+	Monthly UsagePeriod `json:"monthly"`
+}
+
+// UsageReport is the self-service usage report for a caller
+type UsageReport struct {
+	User   UsageScopeReport  `json:"user"`
+	Team   *UsageScopeReport `json:"team,omitempty"`
+	Tenant *UsageScopeReport `json:"tenant,omitempty"`
+}
+
+// UsageService tracks token and cost usage and enforces quotas. Limits and
+// prices are read from the current configuration snapshot on every call so
+// they follow configuration reloads.
+type UsageService struct {
+	store  storage.UsageStore
+	config *config.Store
+	now    func() time.Time
+}
+
+// NewUsageService creates a new usage service
+func NewUsageService(cfgStore *config.Store, store storage.UsageStore) *UsageService {
+	return &UsageService{
+		store:  store,
+		config: cfgStore,
+		now:    time.Now,
+	}
+}
+
+// CheckQuota returns a *QuotaError if the user, their team or their tenant has
+// exceeded a hard quota, or another error if the counters cannot be read
+func (s *UsageService) CheckQuota(ctx context.Context, userID string, tenant string, team string) error {
+	cfg := s.config.Current()
+	day, month := s.periods()
+	for _, scope := range scopes(cfg, userID, tenant, team) {
+		daily, err := s.store.Get(ctx, scope.key, day)
+		if err != nil {
+			return err
+		}
+		if err := checkLimits(scope.key, "daily", daily, scope.limits.DailyTokens, scope.limits.DailyCost); err != nil {
+			return err
+		}
+		monthly, err := s.store.Get(ctx, scope.key, month)
+		if err != nil {
+			return err
+		}
+		if err := checkLimits(scope.key, "monthly", monthly, scope.limits.MonthlyTokens, scope.limits.MonthlyCost); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reservation is usage reserved against the quotas of a request in flight.
+// It is settled with the actual usage once the provider responds, or
+// released if the request fails.
+type Reservation struct {
+	model    string
+	estimate storage.UsageCounter
+	counters []reservedCounter
+}
+
+// reservedCounter is a counter a reservation was added to, with its hard limits
+type reservedCounter struct {
+	scope      string
+	period     string // Period key, such as "day:2024-01-31"
+	name       string // Period name used in quota errors and warnings
+	tokenLimit int64
+	costLimit  float64
+}
+
+// Reserve adds the estimated usage of a request, its prompt tokens plus the
+// completion tokens it may generate, to the user's, team's and tenant's
+// counters before the request is forwarded. Each counter is updated
+// atomically, so concurrent requests see each other's reservations and cannot
+// jointly overrun a hard quota. If the reservation does not fit a quota it is
+// released again and a *QuotaError is returned.
+func (s *UsageService) Reserve(ctx context.Context, userID string, tenant string, team string, model string, promptTokens int64, maxTokens int64) (*Reservation, error) {
+	cfg := s.config.Current()
+	requestScopes := scopes(cfg, userID, tenant, team)
+
+	// Without a price the request would be free under a cost quota
+	if _, priced := cfg.Models[model]; !priced {
+		for _, scope := range requestScopes {
+			if scope.limits.DailyCost > 0 || scope.limits.MonthlyCost > 0 {
+				return nil, fmt.Errorf("%w: %s", ErrModelUnpriced, model)
+			}
+		}
+	}
+
+	reservation := &Reservation{
+		model: model,
+		estimate: storage.UsageCounter{
+			PromptTokens:     promptTokens,
+			CompletionTokens: maxTokens,
+			TotalTokens:      promptTokens + maxTokens,
+			Cost:             cost(cfg, model, promptTokens, maxTokens),
+		},
+	}
+	day, month := s.periods()
+	for _, scope := range requestScopes {
+		for _, reserved := range []reservedCounter{
+			{scope: scope.key, period: day, name: "daily", tokenLimit: scope.limits.DailyTokens, costLimit: scope.limits.DailyCost},
+			{scope: scope.key, period: month, name: "monthly", tokenLimit: scope.limits.MonthlyTokens, costLimit: scope.limits.MonthlyCost},
+		} {
+			counter, err := s.store.Add(ctx, reserved.scope, reserved.period, reservation.estimate)
+			if err != nil {
+				return nil, s.abandon(ctx, reservation, err)
+			}
+			reservation.counters = append(reservation.counters, reserved)
+
+			if err := checkReservation(reserved.scope, reserved.name, counter, reservation.estimate, reserved.tokenLimit, reserved.costLimit); err != nil {
+				return nil, s.abandon(ctx, reservation, err)
+			}
+		}
+	}
+	return reservation, nil
+}
+
+// Settle replaces the estimate of a reservation with the actual usage of the
+// request. It returns the cost of the request and any soft-limit warnings,
+// along with an error if a counter could not be updated.
+func (s *UsageService) Settle(ctx context.Context, reservation *Reservation, promptTokens int64, completionTokens int64) (float64, []string, error) {
+	cfg := s.config.Current()
+	requestCost := cost(cfg, reservation.model, promptTokens, completionTokens)
+	delta := storage.UsageCounter{
+		Requests:         1,
+		PromptTokens:     promptTokens - reservation.estimate.PromptTokens,
+		CompletionTokens: completionTokens - reservation.estimate.CompletionTokens,
+		TotalTokens:      promptTokens + completionTokens - reservation.estimate.TotalTokens,
+		Cost:             requestCost - reservation.estimate.Cost,
+	}
+
+	var warnings []string
+	counters := reservation.counters
+	reservation.counters = nil
+	for _, reserved := range counters {
+		counter, err := s.store.Add(ctx, reserved.scope, reserved.period, delta)
+		if err != nil {
+			return requestCost, warnings, err
+		}
+		warnings = append(warnings, softWarnings(cfg.UsageSoftLimitRatio, reserved.scope, reserved.name, counter, reserved.tokenLimit, reserved.costLimit)...)
+	}
+	return requestCost, warnings, nil
+}
+
+// Release returns the usage reserved for a request that was not served
+func (s *UsageService) Release(ctx context.Context, reservation *Reservation) error {
+	release := storage.UsageCounter{
+		PromptTokens:     -reservation.estimate.PromptTokens,
+		CompletionTokens: -reservation.estimate.CompletionTokens,
+		TotalTokens:      -reservation.estimate.TotalTokens,
+		Cost:             -reservation.estimate.Cost,
+	}
+
+	counters := reservation.counters
+	reservation.counters = nil
+	for i, reserved := range counters {
+		if _, err := s.store.Add(ctx, reserved.scope, reserved.period, release); err != nil {
+			// Keep the counters not released yet so a retry releases only those
+			reservation.counters = counters[i:]
+			return fmt.Errorf("failed to release usage: %w", err)
+		}
+	}
+	return nil
+}
+
+// abandon releases a reservation that could not be completed and returns
+// the error that stopped it, along with any error releasing it
+func (s *UsageService) abandon(ctx context.Context, reservation *Reservation, err error) error {
+	if releaseErr := s.Release(ctx, reservation); releaseErr != nil {
+		return errors.Join(err, releaseErr)
+	}
+	return err
+}
+
+// Cost returns the USD cost of a request using the configured price table.
+// Unknown models are priced at zero; Reserve refuses them when a cost quota
+// applies.
+func (s *UsageService) Cost(model string, promptTokens int64, completionTokens int64) float64 {
+	return cost(s.config.Current(), model, promptTokens, completionTokens)
+}
+
+// cost returns the USD cost of a request using the price table of cfg
+func cost(cfg *config.Config, model string, promptTokens int64, completionTokens int64) float64 {
+	modelConfig, ok := cfg.Models[model]
+	if !ok {
+		return 0
+	}
+	price := modelConfig.Price
+	return float64(promptTokens)/1000*price.PromptPer1K + float64(completionTokens)/1000*price.CompletionPer1K
+}
+
+// Report returns the current usage of a user, their team and their tenant
+func (s *UsageService) Report(ctx context.Context, userID string, tenant string, team string) (UsageReport, error) {
+	cfg := s.config.Current()
+	day, month := s.periods()
+	scopeReport := func(id string, key string, limits config.UsageLimits) (*UsageScopeReport, error) {
+		daily, err := s.store.Get(ctx, key, day)
+		if err != nil {
+			return nil, err
+		}
+		monthly, err := s.store.Get(ctx, key, month)
+		if err != nil {
+			return nil, err
+		}
+		return &UsageScopeReport{
+			ID: id,
+			Daily: UsagePeriod{
+				Period:     day,
+				Usage:      daily,
+				TokenLimit: limits.DailyTokens,
+				CostLimit:  limits.DailyCost,
+			},
+			Monthly: UsagePeriod{
+				Period:     month,
+				Usage:      monthly,
+				TokenLimit: limits.MonthlyTokens,
+				CostLimit:  limits.MonthlyCost,
+			},
+		}, nil
+	}
+
+	userReport, err := scopeReport(userID, "user:"+userID, cfg.UserUsageLimits)
+	if err != nil {
+		return UsageReport{}, err
+	}
+	report := UsageReport{User: *userReport}
+	if team != "" {
+		if report.Team, err = scopeReport(team, teamKey(tenant, team), cfg.TeamUsageLimits); err != nil {
+			return UsageReport{}, err
+		}
+	}
+	if tenantConfig, ok := cfg.Tenants[tenant]; ok {
+		if report.Tenant, err = scopeReport(tenant, "tenant:"+tenant, tenantConfig.Limits); err != nil {
+			return UsageReport{}, err
+		}
+	}
+	return report, nil
+}
+
+type usageScope struct {
+	key    string
+	limits config.UsageLimits
+}
+
+// scopes returns the usage scopes that apply to a request
+func scopes(cfg *config.Config, userID string, tenant string, team string) []usageScope {
+	scopes := []usageScope{{key: "user:" + userID, limits: cfg.UserUsageLimits}}
+	if team != "" {
+		scopes = append(scopes, usageScope{key: teamKey(tenant, team), limits: cfg.TeamUsageLimits})
+	}
+	if tenantConfig, ok := cfg.Tenants[tenant]; ok {
+		scopes = append(scopes, usageScope{key: "tenant:" + tenant, limits: tenantConfig.Limits})
+	}
+	return scopes
+}
+
+// teamKey returns the usage scope key of a team. Teams are namespaced by
+// tenant so teams with the same name in different tenants are counted apart.
+func teamKey(tenant string, team string) string {
+	if tenant != "" {
+		return "tenant:" + tenant + "/team:" + team
+	}
+	return "team:" + team
+}
+
+// periods returns the current daily and monthly period keys in UTC
+func (s *UsageService) periods() (string, string) {
+	now := s.now().UTC()
+	return "day:" + now.Format("2006-01-02"), "month:" + now.Format("2006-01")
+}
+
+// softWarnings returns a warning for each limit that has crossed the soft threshold
+func softWarnings(softRatio float64, scope string, period string, counter storage.UsageCounter, tokenLimit int64, costLimit float64) []string {
+	if softRatio <= 0 {
+		return nil
+	}
+
+	var warnings []string
+	if tokenLimit > 0 && float64(counter.TotalTokens) >= float64(tokenLimit)*softRatio {
+		warnings = append(warnings, fmt.Sprintf("%s has used %d of %d %s tokens", scope, counter.TotalTokens, tokenLimit, period))
+	}
+	if costLimit > 0 && counter.Cost >= costLimit*softRatio {
+		warnings = append(warnings, fmt.Sprintf("%s has used $%.2f of $%.2f %s budget", scope, counter.Cost, costLimit, period))
+	}
+	return warnings
+}
+
+// checkReservation returns a *QuotaError if a counter had reached a hard
+// limit before the reservation was added, or the reservation overruns it
+func checkReservation(scope string, period string, counter storage.UsageCounter, reserved storage.UsageCounter, tokenLimit int64, costLimit float64) error {
+	before := storage.UsageCounter{
+		TotalTokens: counter.TotalTokens - reserved.TotalTokens,
+		Cost:        counter.Cost - reserved.Cost,
+	}
+	if err := checkLimits(scope, period, before, tokenLimit, costLimit); err != nil {
+		return err
+	}
+	if costLimit > 0 && counter.Cost > costLimit {
+		return &QuotaError{Scope: scope, Period: period, Metric: QuotaMetricCost, Used: before.Cost, Limit: costLimit}
+	}
+	if tokenLimit > 0 && counter.TotalTokens > tokenLimit {
+		return &QuotaError{Scope: scope, Period: period, Metric: QuotaMetricTokens, Used: float64(before.TotalTokens), Limit: float64(tokenLimit)}
+	}
+	return nil
+}
+
+// checkLimits returns a *QuotaError if a counter has reached a hard limit
+func checkLimits(scope string, period string, counter storage.UsageCounter, tokenLimit int64, costLimit float64) error {
+	if costLimit > 0 && counter.Cost >= costLimit {
+		return &QuotaError{Scope: scope, Period: period, Metric: QuotaMetricCost, Used: counter.Cost, Limit: costLimit}
+	}
+	if tokenLimit > 0 && counter.TotalTokens >= tokenLimit {
+		return &QuotaError{Scope: scope, Period: period, Metric: QuotaMetricTokens, Used: float64(counter.TotalTokens), Limit: float64(tokenLimit)}
+	}
+	return nil
+}
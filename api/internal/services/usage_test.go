@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/storage"
+)
+
+// newTestUsageService creates a usage service with the given user limits, at a fixed time
+func newTestUsageService(limits config.UsageLimits) (*UsageService, *storage.MemoryUsageStore) {
+	cfg := config.Defaults()
+	cfg.UserUsageLimits = limits
+	store := storage.NewMemoryUsageStore()
+	service := NewUsageService(config.NewStore(cfg), store)
+	service.now = func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC) }
+	return service, store
+}
+
+func TestReserve(t *testing.T) {
+	tests := []struct {
+		name         string
+		limits       config.UsageLimits
+		used         storage.UsageCounter
+		model        string
+		promptTokens int64
+		maxTokens    int64
+		wantMetric   string
+		wantUnpriced bool
+	}{
+		{name: "within quotas", limits: config.UsageLimits{DailyTokens: 1000, DailyCost: 1}, model: "gpt-4", promptTokens: 100, maxTokens: 200},
+		{name: "no quotas", model: "gpt-4", promptTokens: 100, maxTokens: 200},
+		{name: "token quota exhausted", limits: config.UsageLimits{DailyTokens: 1000}, used: storage.UsageCounter{TotalTokens: 1000}, model: "gpt-4", promptTokens: 10, wantMetric: QuotaMetricTokens},
+		{name: "estimate overruns token quota", limits: config.UsageLimits{DailyTokens: 1000}, used: storage.UsageCounter{TotalTokens: 900}, model: "gpt-4", promptTokens: 50, maxTokens: 100, wantMetric: QuotaMetricTokens},
+		{name: "estimate fills token quota", limits: config.UsageLimits{DailyTokens: 1000}, used: storage.UsageCounter{TotalTokens: 850}, model: "gpt-4", promptTokens: 50, maxTokens: 100},
+		{name: "monthly token quota exhausted", limits: config.UsageLimits{MonthlyTokens: 5000}, used: storage.UsageCounter{TotalTokens: 5000}, model: "gpt-4", promptTokens: 10, wantMetric: QuotaMetricTokens},
+		{name: "cost quota exhausted", limits: config.UsageLimits{DailyCost: 1}, used: storage.UsageCounter{Cost: 1}, model: "gpt-4", promptTokens: 10, wantMetric: QuotaMetricCost},
+		// 100 prompt tokens at $0.03/1K plus 200 completion tokens at $0.06/1K cost $0.015
+		{name: "estimate overruns cost quota", limits: config.UsageLimits{DailyCost: 0.01}, model: "gpt-4", promptTokens: 100, maxTokens: 200, wantMetric: QuotaMetricCost},
+		{name: "unpriced model under cost quota", limits: config.UsageLimits{MonthlyCost: 100}, model: "unpriced-model", promptTokens: 100, wantUnpriced: true},
+		{name: "unpriced model under token quota", limits: config.UsageLimits{DailyTokens: 1000}, model: "unpriced-model", promptTokens: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			service, store := newTestUsageService(tt.limits)
+			day, month := service.periods()
+			for _, period := range []string{day, month} {
+				if _, err := store.Add(ctx, "user:user-123", period, tt.used); err != nil {
+					t.Fatalf("Add() error = %v", err)
+				}
+			}
+
+			reservation, err := service.Reserve(ctx, "user-123", "", "", tt.model, tt.promptTokens, tt.maxTokens)
+			var quotaErr *QuotaError
+			switch {
+			case tt.wantUnpriced:
+				if !errors.Is(err, ErrModelUnpriced) {
+					t.Fatalf("Reserve() error = %v, want ErrModelUnpriced", err)
+				}
+			case tt.wantMetric != "":
+				if !errors.As(err, &quotaErr) || quotaErr.Metric != tt.wantMetric {
+					t.Fatalf("Reserve() error = %v, want a %s quota error", err, tt.wantMetric)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Reserve() error = %v", err)
+				}
+			}
+
+			// A refused reservation leaves the counters as they were
+			want := tt.used
+			if reservation != nil {
+				want.TotalTokens += tt.promptTokens + tt.maxTokens
+			}
+			for _, period := range []string{day, month} {
+				counter, _ := store.Get(ctx, "user:user-123", period)
+				if counter.TotalTokens != want.TotalTokens {
+					t.Errorf("%s total tokens = %d, want %d", period, counter.TotalTokens, want.TotalTokens)
+				}
+			}
+		})
+	}
+}
+
+func TestReserveConcurrent(t *testing.T) {
+	ctx := context.Background()
+	service, store := newTestUsageService(config.UsageLimits{DailyTokens: 1000})
+
+	// Twenty requests of 100 tokens race for a quota that fits ten
+	var (
+		wg           sync.WaitGroup
+		mu           sync.Mutex
+		reservations []*Reservation
+		refused      int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reservation, err := service.Reserve(ctx, "user-123", "", "", "gpt-4", 40, 60)
+			mu.Lock()
+			defer mu.Unlock()
+			var quotaErr *QuotaError
+			switch {
+			case err == nil:
+				reservations = append(reservations, reservation)
+			case errors.As(err, &quotaErr):
+				refused++
+			default:
+				t.Errorf("Reserve() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(reservations) != 10 || refused != 10 {
+		t.Fatalf("Reserve() admitted %d and refused %d requests, want 10 and 10", len(reservations), refused)
+	}
+
+	// Each request used less than it reserved
+	for _, reservation := range reservations {
+		if _, _, err := service.Settle(ctx, reservation, 40, 10); err != nil {
+			t.Fatalf("Settle() error = %v", err)
+		}
+	}
+	day, _ := service.periods()
+	counter, _ := store.Get(ctx, "user:user-123", day)
+	if counter.Requests != 10 || counter.TotalTokens != 500 {
+		t.Errorf("counter after Settle() = %+v, want 10 requests and 500 tokens", counter)
+	}
+}
+
+func TestSettleAndRelease(t *testing.T) {
+	ctx := context.Background()
+	service, store := newTestUsageService(config.UsageLimits{DailyTokens: 1000, MonthlyCost: 0.055})
+	day, month := service.periods()
+
+	reservation, err := service.Reserve(ctx, "user-123", "", "", "gpt-4", 100, 500)
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	counter, _ := store.Get(ctx, "user:user-123", day)
+	if counter.TotalTokens != 600 || counter.Requests != 0 {
+		t.Errorf("counter after Reserve() = %+v, want 600 tokens reserved and no request", counter)
+	}
+
+	// 100 prompt tokens and 700 completion tokens cost $0.045, past the soft limit of both quotas
+	cost, warnings, err := service.Settle(ctx, reservation, 100, 700)
+	if err != nil {
+		t.Fatalf("Settle() error = %v", err)
+	}
+	if math.Abs(cost-0.045) > 1e-9 {
+		t.Errorf("Settle() cost = %v, want 0.045", cost)
+	}
+	if len(warnings) != 2 {
+		t.Errorf("Settle() warnings = %q, want a daily token and a monthly budget warning", warnings)
+	}
+	for _, period := range []string{day, month} {
+		counter, _ := store.Get(ctx, "user:user-123", period)
+		if counter.Requests != 1 || counter.PromptTokens != 100 || counter.CompletionTokens != 700 || counter.TotalTokens != 800 || math.Abs(counter.Cost-0.045) > 1e-9 {
+			t.Errorf("%s counter after Settle() = %+v, want the actual usage", period, counter)
+		}
+	}
+
+	// A released reservation is returned in full
+	reservation, err = service.Reserve(ctx, "user-123", "", "", "gpt-4", 50, 100)
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if err := service.Release(ctx, reservation); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	counter, _ = store.Get(ctx, "user:user-123", day)
+	if counter.Requests != 1 || counter.TotalTokens != 800 || math.Abs(counter.Cost-0.045) > 1e-9 {
+		t.Errorf("counter after Release() = %+v, want the settled usage only", counter)
+	}
+}
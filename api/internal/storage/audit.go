@@ -0,0 +1,221 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrAuditEntryNotFound is returned when an audit entry does not exist
+var ErrAuditEntryNotFound = errors.New("audit entry not found")
+
+// AuditEntry is an off-chain index row of an interaction recorded in the
+// blockchain audit trail. The chain holds only hashes; the index links them
+// to the user and data subject the interaction concerned.
+type AuditEntry struct {
+	ID         string                 `json:"id"`
+	Tenant     string                 `json:"tenant,omitempty"`
+	UserID     string                 `json:"user_id"`
+	SubjectID  string                 `json:"subject_id,omitempty"`
+	ActionType string                 `json:"action_type"`
+	TxHash     string                 `json:"blockchain_tx"`
+	Timestamp  time.Time              `json:"timestamp"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+
+	// A legal hold exempts the entry from retention purges
+	LegalHold       bool   `json:"legal_hold,omitempty"`
+	LegalHoldReason string `json:"legal_hold_reason,omitempty"`
+}
+
+// AuditStore persists the off-chain audit index. Entries are stored whether
+// or not the interaction was recorded on-chain; TxHash is empty if it was not.
+type AuditStore interface {
+	// Add stores an entry and returns it with its ID
+	Add(ctx context.Context, entry AuditEntry) (AuditEntry, error)
+	// Get returns a tenant's entry by ID
+	Get(ctx context.Context, tenant string, id string) (AuditEntry, error)
+	// ListByUser returns the entries of a user, oldest first
+	ListByUser(ctx context.Context, tenant string, userID string) ([]AuditEntry, error)
+	// ListBySubject returns the entries concerning a data subject, oldest first
+	ListBySubject(ctx context.Context, tenant string, subjectID string) ([]AuditEntry, error)
+	// ScrubUser removes the given metadata fields from a user's entries and
+	// returns the number of entries changed
+	ScrubUser(ctx context.Context, tenant string, userID string, fields []string) (int, error)
+	// ReplaceSubject replaces a data subject's identifier in its entries and
+	// returns the number of entries changed
+	ReplaceSubject(ctx context.Context, tenant string, subjectID string, replacement string) (int, error)
+	// ListBefore returns the entries recorded before t across all tenants, oldest first
+	ListBefore(ctx context.Context, t time.Time) ([]AuditEntry, error)
+	// Delete removes entries by ID, skipping entries under legal hold, and
+	// returns the number removed
+	Delete(ctx context.Context, ids []string) (int, error)
+	// SetLegalHold places or lifts a legal hold on a tenant's entry
+	SetLegalHold(ctx context.Context, tenant string, id string, hold bool, reason string) (AuditEntry, error)
+}
+
+// MemoryAuditStore is an in-memory AuditStore. Its entries are lost on
+// restart, so it is only suitable for development.
+type MemoryAuditStore struct {
+	mu      sync.RWMutex
+	entries []AuditEntry
+}
+
+// NewMemoryAuditStore creates a new in-memory audit store
+func NewMemoryAuditStore() *MemoryAuditStore {
+	return &MemoryAuditStore{}
+}
+
+// Add stores an entry and returns it, generating an ID if none is set
+func (s *MemoryAuditStore) Add(ctx context.Context, entry AuditEntry) (AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry.ID == "" {
+		entry.ID = uuid.NewString()
+	}
+	s.entries = append(s.entries, entry)
+	return entry, nil
+}
+
+// Get returns a tenant's entry by ID
+func (s *MemoryAuditStore) Get(ctx context.Context, tenant string, id string) (AuditEntry, error) {
+	entries, _ := s.list(func(entry AuditEntry) bool {
+		return entry.Tenant == tenant && entry.ID == id
+	})
+	if len(entries) == 0 {
+		return AuditEntry{}, ErrAuditEntryNotFound
+	}
+	return entries[0], nil
+}
+
+// ListByUser returns the entries of a user, oldest first
+func (s *MemoryAuditStore) ListByUser(ctx context.Context, tenant string, userID string) ([]AuditEntry, error) {
+	return s.list(func(entry AuditEntry) bool {
+		return entry.Tenant == tenant && entry.UserID == userID
+	})
+}
+
+// ListBySubject returns the entries concerning a data subject, oldest first
+func (s *MemoryAuditStore) ListBySubject(ctx context.Context, tenant string, subjectID string) ([]AuditEntry, error) {
+	return s.list(func(entry AuditEntry) bool {
+		return entry.Tenant == tenant && entry.SubjectID == subjectID
+	})
+}
+
+// ScrubUser removes the given metadata fields from a user's entries and
+// returns the number of entries changed
+func (s *MemoryAuditStore) ScrubUser(ctx context.Context, tenant string, userID string, fields []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := 0
+	for i := range s.entries {
+		entry := &s.entries[i]
+		if entry.Tenant != tenant || entry.UserID != userID {
+			continue
+		}
+		scrubbed := false
+		for _, field := range fields {
+			if _, ok := entry.Metadata[field]; ok {
+				scrubbed = true
+			}
+		}
+		if !scrubbed {
+			continue
+		}
+		metadata := make(map[string]interface{}, len(entry.Metadata))
+		for key, value := range entry.Metadata {
+			metadata[key] = value
+		}
+		for _, field := range fields {
+			delete(metadata, field)
+		}
+		entry.Metadata = metadata
+		changed++
+	}
+	return changed, nil
+}
+
+// ReplaceSubject replaces a data subject's identifier in its entries and
+// returns the number of entries changed
+func (s *MemoryAuditStore) ReplaceSubject(ctx context.Context, tenant string, subjectID string, replacement string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := 0
+	for i := range s.entries {
+		if s.entries[i].Tenant == tenant && s.entries[i].SubjectID == subjectID {
+			s.entries[i].SubjectID = replacement
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// ListBefore returns the entries recorded before t across all tenants, oldest first
+func (s *MemoryAuditStore) ListBefore(ctx context.Context, t time.Time) ([]AuditEntry, error) {
+	return s.list(func(entry AuditEntry) bool {
+		return entry.Timestamp.Before(t)
+	})
+}
+
+// Delete removes entries by ID, skipping entries under legal hold, and
+// returns the number removed
+func (s *MemoryAuditStore) Delete(ctx context.Context, ids []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	remove := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+
+	kept := s.entries[:0]
+	for _, entry := range s.entries {
+		if remove[entry.ID] && !entry.LegalHold {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	removed := len(s.entries) - len(kept)
+	for i := len(kept); i < len(s.entries); i++ {
+		s.entries[i] = AuditEntry{}
+	}
+	s.entries = kept
+	return removed, nil
+}
+
+// SetLegalHold places or lifts a legal hold on a tenant's entry
+func (s *MemoryAuditStore) SetLegalHold(ctx context.Context, tenant string, id string, hold bool, reason string) (AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.entries {
+		if s.entries[i].ID == id && s.entries[i].Tenant == tenant {
+			s.entries[i].LegalHold = hold
+			s.entries[i].LegalHoldReason = reason
+			if !hold {
+				s.entries[i].LegalHoldReason = ""
+			}
+			return s.entries[i], nil
+		}
+	}
+	return AuditEntry{}, ErrAuditEntryNotFound
+}
+
+// list returns copies of the entries matching a filter
+func (s *MemoryAuditStore) list(match func(AuditEntry) bool) ([]AuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var entries []AuditEntry
+	for _, entry := range s.entries {
+		if match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// auditColumns are the audit_logs columns scanned by scanAuditEntry
+const auditColumns = `id::text, tenant, COALESCE(user_id, ''), COALESCE(subject_id, ''), action_type,
+	COALESCE(blockchain_tx, ''), timestamp, metadata, legal_hold, COALESCE(legal_hold_reason, '')`
+
+// PostgresAuditStore is an AuditStore backed by the audit_logs table
+type PostgresAuditStore struct {
+	db *sql.DB
+}
+
+// NewPostgresAuditStore creates an audit store backed by the audit_logs table
+func NewPostgresAuditStore(db *sql.DB) *PostgresAuditStore {
+	return &PostgresAuditStore{db: db}
+}
+
+// Add stores an entry and returns it with the ID assigned by the database
+func (s *PostgresAuditStore) Add(ctx context.Context, entry AuditEntry) (AuditEntry, error) {
+	metadata, err := json.Marshal(entry.Metadata)
+	if err != nil {
+		return AuditEntry{}, fmt.Errorf("failed to encode audit metadata: %w", err)
+	}
+
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO audit_logs (tenant, user_id, subject_id, action_type, blockchain_tx, timestamp, metadata, legal_hold, legal_hold_reason)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4, NULLIF($5, ''), $6, $7, $8, NULLIF($9, ''))
+		RETURNING id::text`,
+		entry.Tenant, entry.UserID, entry.SubjectID, entry.ActionType, entry.TxHash, entry.Timestamp,
+		string(metadata), entry.LegalHold, entry.LegalHoldReason,
+	).Scan(&entry.ID)
+	if err != nil {
+		return AuditEntry{}, fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	return entry, nil
+}
+
+// Get returns a tenant's entry by ID
+func (s *PostgresAuditStore) Get(ctx context.Context, tenant string, id string) (AuditEntry, error) {
+	keys := auditKeys([]string{id})
+	if len(keys) == 0 {
+		return AuditEntry{}, ErrAuditEntryNotFound
+	}
+
+	entry, err := scanAuditEntry(s.db.QueryRowContext(ctx, `SELECT `+auditColumns+` FROM audit_logs WHERE id = $1 AND tenant = $2`, keys[0], tenant))
+	if errors.Is(err, sql.ErrNoRows) {
+		return AuditEntry{}, ErrAuditEntryNotFound
+	}
+	if err != nil {
+		return AuditEntry{}, fmt.Errorf("failed to get audit entry: %w", err)
+	}
+	return entry, nil
+}
+
+// ListByUser returns the entries of a user, oldest first
+func (s *PostgresAuditStore) ListByUser(ctx context.Context, tenant string, userID string) ([]AuditEntry, error) {
+	return s.list(ctx, `WHERE tenant = $1 AND user_id = $2`, tenant, userID)
+}
+
+// ListBySubject returns the entries concerning a data subject, oldest first
+func (s *PostgresAuditStore) ListBySubject(ctx context.Context, tenant string, subjectID string) ([]AuditEntry, error) {
+	return s.list(ctx, `WHERE tenant = $1 AND subject_id = $2`, tenant, subjectID)
+}
+
+// ScrubUser removes the given metadata fields from a user's entries and
+// returns the number of entries changed
+func (s *PostgresAuditStore) ScrubUser(ctx context.Context, tenant string, userID string, fields []string) (int, error) {
+	return s.exec(ctx, `
+		UPDATE audit_logs SET metadata = metadata - $3::text[]
+		WHERE tenant = $1 AND user_id = $2 AND metadata ?| $3::text[]`,
+		tenant, userID, pq.Array(fields),
+	)
+}
+
+// ReplaceSubject replaces a data subject's identifier in its entries and
+// returns the number of entries changed
+func (s *PostgresAuditStore) ReplaceSubject(ctx context.Context, tenant string, subjectID string, replacement string) (int, error) {
+	return s.exec(ctx, `UPDATE audit_logs SET subject_id = $3 WHERE tenant = $1 AND subject_id = $2`,
+		tenant, subjectID, replacement,
+	)
+}
+
+// ListBefore returns the entries recorded before t across all tenants, oldest first
+func (s *PostgresAuditStore) ListBefore(ctx context.Context, t time.Time) ([]AuditEntry, error) {
+	return s.list(ctx, `WHERE timestamp < $1`, t)
+}
+
+// Delete removes entries by ID, skipping entries under legal hold, and
+// returns the number removed
+func (s *PostgresAuditStore) Delete(ctx context.Context, ids []string) (int, error) {
+	keys := auditKeys(ids)
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	return s.exec(ctx, `DELETE FROM audit_logs WHERE id = ANY($1) AND NOT legal_hold`, pq.Array(keys))
+}
+
+// SetLegalHold places or lifts a legal hold on a tenant's entry
+func (s *PostgresAuditStore) SetLegalHold(ctx context.Context, tenant string, id string, hold bool, reason string) (AuditEntry, error) {
+	keys := auditKeys([]string{id})
+	if len(keys) == 0 {
+		return AuditEntry{}, ErrAuditEntryNotFound
+	}
+	if !hold {
+		reason = ""
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		UPDATE audit_logs SET legal_hold = $3, legal_hold_reason = NULLIF($4, '')
+		WHERE id = $1 AND tenant = $2
+		RETURNING `+auditColumns,
+		keys[0], tenant, hold, reason,
+	)
+	entry, err := scanAuditEntry(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return AuditEntry{}, ErrAuditEntryNotFound
+	}
+	if err != nil {
+		return AuditEntry{}, fmt.Errorf("failed to update legal hold: %w", err)
+	}
+	return entry, nil
+}
+
+// list returns the entries matching a WHERE clause, oldest first
+func (s *PostgresAuditStore) list(ctx context.Context, where string, args ...interface{}) ([]AuditEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+auditColumns+` FROM audit_logs `+where+` ORDER BY timestamp, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	return entries, nil
+}
+
+// exec runs a statement and returns the number of rows it affected
+func (s *PostgresAuditStore) exec(ctx context.Context, query string, args ...interface{}) (int, error) {
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update audit entries: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to update audit entries: %w", err)
+	}
+	return int(affected), nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanAuditEntry reads an entry selected with auditColumns
+func scanAuditEntry(row rowScanner) (AuditEntry, error) {
+	var entry AuditEntry
+	var metadata []byte
+	err := row.Scan(&entry.ID, &entry.Tenant, &entry.UserID, &entry.SubjectID, &entry.ActionType,
+		&entry.TxHash, &entry.Timestamp, &metadata, &entry.LegalHold, &entry.LegalHoldReason)
+	if err != nil {
+		return AuditEntry{}, err
+	}
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &entry.Metadata); err != nil {
+			return AuditEntry{}, fmt.Errorf("failed to decode audit metadata: %w", err)
+		}
+	}
+	entry.Timestamp = entry.Timestamp.UTC()
+	return entry, nil
+}
+
+// auditKeys converts entry IDs to audit_logs keys, skipping IDs that cannot
+// name a row
+func auditKeys(ids []string) []int64 {
+	keys := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if key, err := strconv.ParseInt(id, 10, 64); err == nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
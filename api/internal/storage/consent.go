@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrConsentNotFound is returned when no active consent exists
+var ErrConsentNotFound = errors.New("consent not found")
+
+// ConsentRecord is a data subject's consent to processing for a purpose
+type ConsentRecord struct {
+	ID          string     `json:"id"`
+	Tenant      string     `json:"tenant,omitempty"`
+	SubjectID   string     `json:"subject_id"`
+	Purpose     string     `json:"purpose"`
+	GrantedBy   string     `json:"granted_by"`
+	GrantedAt   time.Time  `json:"granted_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	WithdrawnAt *time.Time `json:"withdrawn_at,omitempty"`
+	WithdrawnBy string     `json:"withdrawn_by,omitempty"`
+
+	// On-chain attestation transactions, when a consent registry is configured
+	AttestationTx string `json:"attestation_tx,omitempty"`
+	RevocationTx  string `json:"revocation_tx,omitempty"`
+}
+
+// ActiveAt reports whether the consent is granted and neither withdrawn nor expired at t
+func (r ConsentRecord) ActiveAt(t time.Time) bool {
+	if t.Before(r.GrantedAt) {
+		return false
+	}
+	if r.WithdrawnAt != nil && !t.Before(*r.WithdrawnAt) {
+		return false
+	}
+	if r.ExpiresAt != nil && !t.Before(*r.ExpiresAt) {
+		return false
+	}
+	return true
+}
+
+// ConsentStore persists consent records. Records are never deleted so the
+// history of grants and withdrawals is kept.
+type ConsentStore interface {
+	// Grant stores a new consent record and returns it, generating an ID if none is set
+	Grant(ctx context.Context, record ConsentRecord) (ConsentRecord, error)
+	// Withdraw marks the active consents for a subject and purpose as withdrawn
+	// and returns the most recent one
+	Withdraw(ctx context.Context, tenant string, subjectID string, purpose string, by string, revocationTx string, at time.Time) (ConsentRecord, error)
+	// Active returns the consent for a subject and purpose that is active at t
+	Active(ctx context.Context, tenant string, subjectID string, purpose string, at time.Time) (ConsentRecord, error)
+	// List returns all consent records of a subject, oldest first
+	List(ctx context.Context, tenant string, subjectID string) ([]ConsentRecord, error)
+	// ReplaceSubject replaces a subject's identifier in its consent records,
+	// keeping the history, and returns the number of records changed
+	ReplaceSubject(ctx context.Context, tenant string, subjectID string, replacement string) (int, error)
+}
+
+// MemoryConsentStore is an in-memory ConsentStore. Its records are lost on
+// restart, so it is only suitable for development.
+type MemoryConsentStore struct {
+	mu      sync.RWMutex
+	records map[string][]ConsentRecord
+}
+
+// NewMemoryConsentStore creates a new in-memory consent store
+func NewMemoryConsentStore() *MemoryConsentStore {
+	return &MemoryConsentStore{
+		records: make(map[string][]ConsentRecord),
+	}
+}
+
+// Grant stores a new consent record and returns it, generating an ID if none is set
+func (s *MemoryConsentStore) Grant(ctx context.Context, record ConsentRecord) (ConsentRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record.ID == "" {
+		record.ID = uuid.NewString()
+	}
+	key := consentKey(record.Tenant, record.SubjectID)
+	s.records[key] = append(s.records[key], record)
+	return record, nil
+}
+
+// Withdraw marks the active consents for a subject and purpose as withdrawn
+// and returns the most recent one
+func (s *MemoryConsentStore) Withdraw(ctx context.Context, tenant string, subjectID string, purpose string, by string, revocationTx string, at time.Time) (ConsentRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var latest *ConsentRecord
+	records := s.records[consentKey(tenant, subjectID)]
+	for i := range records {
+		if records[i].Purpose == purpose && records[i].ActiveAt(at) {
+			withdrawnAt := at
+			records[i].WithdrawnAt = &withdrawnAt
+			records[i].WithdrawnBy = by
+			records[i].RevocationTx = revocationTx
+			latest = &records[i]
+		}
+	}
+	if latest == nil {
+		return ConsentRecord{}, ErrConsentNotFound
+	}
+	return *latest, nil
+}
+
+// Active returns the consent for a subject and purpose that is active at t
+func (s *MemoryConsentStore) Active(ctx context.Context, tenant string, subjectID string, purpose string, at time.Time) (ConsentRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := s.records[consentKey(tenant, subjectID)]
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].Purpose == purpose && records[i].ActiveAt(at) {
+			return records[i], nil
+		}
+	}
+	return ConsentRecord{}, ErrConsentNotFound
+}
+
+// List returns all consent records of a subject, oldest first
+func (s *MemoryConsentStore) List(ctx context.Context, tenant string, subjectID string) ([]ConsentRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := s.records[consentKey(tenant, subjectID)]
+	return append([]ConsentRecord(nil), records...), nil
+}
+
+// ReplaceSubject replaces a subject's identifier in its consent records,
+// keeping the history, and returns the number of records changed
+func (s *MemoryConsentStore) ReplaceSubject(ctx context.Context, tenant string, subjectID string, replacement string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := consentKey(tenant, subjectID)
+	records := s.records[key]
+	if len(records) == 0 {
+		return 0, nil
+	}
+	for i := range records {
+		records[i].SubjectID = replacement
+	}
+
+	newKey := consentKey(tenant, replacement)
+	s.records[newKey] = append(s.records[newKey], records...)
+	delete(s.records, key)
+	return len(records), nil
+}
+
+// consentKey scopes consent records to a tenant
+func consentKey(tenant string, subjectID string) string {
+	return tenant + "|" + subjectID
+}
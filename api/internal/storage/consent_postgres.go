@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// consentColumns are the consents columns scanned by scanConsent
+const consentColumns = `external_id, tenant, subject_id, purpose, granted_by, granted_at, expires_at,
+	withdrawn_at, COALESCE(withdrawn_by, ''), COALESCE(attestation_tx, ''), COALESCE(revocation_tx, '')`
+
+// consentActiveAt is the condition selecting the consents of a subject and
+// purpose that are active at $4, as ConsentRecord.ActiveAt
+const consentActiveAt = `tenant = $1 AND subject_id = $2 AND purpose = $3 AND granted_at <= $4
+	AND (withdrawn_at IS NULL OR withdrawn_at > $4) AND (expires_at IS NULL OR expires_at > $4)`
+
+// PostgresConsentStore is a ConsentStore backed by the consents table, so
+// grants survive restarts and are shared between replicas
+type PostgresConsentStore struct {
+	db *sql.DB
+}
+
+// NewPostgresConsentStore creates a consent store backed by the consents table
+func NewPostgresConsentStore(db *sql.DB) *PostgresConsentStore {
+	return &PostgresConsentStore{db: db}
+}
+
+// Grant stores a new consent record and returns it, generating an ID if none is set
+func (s *PostgresConsentStore) Grant(ctx context.Context, record ConsentRecord) (ConsentRecord, error) {
+	if record.ID == "" {
+		record.ID = uuid.NewString()
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO consents (external_id, tenant, subject_id, purpose, granted_by, granted_at, expires_at, attestation_tx)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''))`,
+		record.ID, record.Tenant, record.SubjectID, record.Purpose, record.GrantedBy, record.GrantedAt,
+		record.ExpiresAt, record.AttestationTx,
+	)
+	if err != nil {
+		return ConsentRecord{}, fmt.Errorf("failed to insert consent: %w", err)
+	}
+	return record, nil
+}
+
+// Withdraw marks the active consents for a subject and purpose as withdrawn
+// and returns the most recent one
+func (s *PostgresConsentStore) Withdraw(ctx context.Context, tenant string, subjectID string, purpose string, by string, revocationTx string, at time.Time) (ConsentRecord, error) {
+	row := s.db.QueryRowContext(ctx, `
+		WITH withdrawn AS (
+			UPDATE consents SET withdrawn_at = $4, withdrawn_by = $5, revocation_tx = NULLIF($6, '')
+			WHERE `+consentActiveAt+`
+			RETURNING id, `+consentColumns+`
+		)
+		SELECT `+consentColumns+` FROM withdrawn ORDER BY granted_at DESC, id DESC LIMIT 1`,
+		tenant, subjectID, purpose, at, by, revocationTx,
+	)
+	record, err := scanConsent(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ConsentRecord{}, ErrConsentNotFound
+	}
+	if err != nil {
+		return ConsentRecord{}, fmt.Errorf("failed to withdraw consent: %w", err)
+	}
+	return record, nil
+}
+
+// Active returns the consent for a subject and purpose that is active at t
+func (s *PostgresConsentStore) Active(ctx context.Context, tenant string, subjectID string, purpose string, at time.Time) (ConsentRecord, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+consentColumns+` FROM consents
+		WHERE `+consentActiveAt+`
+		ORDER BY granted_at DESC, id DESC LIMIT 1`,
+		tenant, subjectID, purpose, at,
+	)
+	record, err := scanConsent(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ConsentRecord{}, ErrConsentNotFound
+	}
+	if err != nil {
+		return ConsentRecord{}, fmt.Errorf("failed to get consent: %w", err)
+	}
+	return record, nil
+}
+
+// List returns all consent records of a subject, oldest first
+func (s *PostgresConsentStore) List(ctx context.Context, tenant string, subjectID string) ([]ConsentRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+consentColumns+` FROM consents
+		WHERE tenant = $1 AND subject_id = $2
+		ORDER BY granted_at, id`,
+		tenant, subjectID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query consents: %w", err)
+	}
+	defer rows.Close()
+
+	var records []ConsentRecord
+	for rows.Next() {
+		record, err := scanConsent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read consent: %w", err)
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query consents: %w", err)
+	}
+	return records, nil
+}
+
+// ReplaceSubject replaces a subject's identifier in its consent records,
+// keeping the history, and returns the number of records changed
+func (s *PostgresConsentStore) ReplaceSubject(ctx context.Context, tenant string, subjectID string, replacement string) (int, error) {
+	result, err := s.db.ExecContext(ctx, `UPDATE consents SET subject_id = $3 WHERE tenant = $1 AND subject_id = $2`,
+		tenant, subjectID, replacement,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update consents: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to update consents: %w", err)
+	}
+	return int(affected), nil
+}
+
+// scanConsent reads a record selected with consentColumns
+func scanConsent(row rowScanner) (ConsentRecord, error) {
+	var record ConsentRecord
+	err := row.Scan(&record.ID, &record.Tenant, &record.SubjectID, &record.Purpose, &record.GrantedBy,
+		&record.GrantedAt, &record.ExpiresAt, &record.WithdrawnAt, &record.WithdrawnBy, &record.AttestationTx, &record.RevocationTx)
+	if err != nil {
+		return ConsentRecord{}, err
+	}
+	record.GrantedAt = record.GrantedAt.UTC()
+	record.ExpiresAt = utcTime(record.ExpiresAt)
+	record.WithdrawnAt = utcTime(record.WithdrawnAt)
+	return record, nil
+}
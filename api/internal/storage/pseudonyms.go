@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PseudonymEntry is a decrypted pseudonym vault entry mapping a pseudonym to
+// the personal data it replaces
+type PseudonymEntry struct {
+	ID         string    `json:"id"`
+	Tenant     string    `json:"tenant,omitempty"`
+	SubjectID  string    `json:"subject_id"`
+	EntityType string    `json:"entity_type"`
+	Pseudonym  string    `json:"pseudonym"`
+	Value      string    `json:"value"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// PseudonymVault stores personal data detected in requests, encrypted with a
+// key per data subject. Shredding a subject's key makes its entries
+// unrecoverable wherever they were copied to.
+type PseudonymVault interface {
+	// Put stores a value for a subject and returns its pseudonym. The same
+	// value always maps to the same pseudonym for a subject.
+	Put(ctx context.Context, tenant string, subjectID string, entityType string, value string, at time.Time) (string, error)
+	// List returns the decrypted entries of a subject, oldest first
+	List(ctx context.Context, tenant string, subjectID string) ([]PseudonymEntry, error)
+	// Shred destroys a subject's key and entries and returns the number of entries destroyed
+	Shred(ctx context.Context, tenant string, subjectID string) (int, error)
+}
+
+// sealedPseudonym is a vault entry with its value encrypted
+type sealedPseudonym struct {
+	id         string
+	entityType string
+	pseudonym  string
+	ciphertext []byte
+	createdAt  time.Time
+}
+
+// MemoryPseudonymVault is an in-memory PseudonymVault using AES-256-GCM. Its
+// entries are lost on restart, so it is only suitable for development.
+type MemoryPseudonymVault struct {
+	mu      sync.RWMutex
+	keys    map[string][]byte
+	entries map[string][]sealedPseudonym
+}
+
+// NewMemoryPseudonymVault creates a new in-memory pseudonym vault
+func NewMemoryPseudonymVault() *MemoryPseudonymVault {
+	return &MemoryPseudonymVault{
+		keys:    make(map[string][]byte),
+		entries: make(map[string][]sealedPseudonym),
+	}
+}
+
+// Put stores a value for a subject and returns its pseudonym. The same
+// value always maps to the same pseudonym for a subject.
+func (v *MemoryPseudonymVault) Put(ctx context.Context, tenant string, subjectID string, entityType string, value string, at time.Time) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	subject := consentKey(tenant, subjectID)
+	key, ok := v.keys[subject]
+	if !ok {
+		var err error
+		if key, err = newSubjectKey(); err != nil {
+			return "", err
+		}
+		v.keys[subject] = key
+	}
+
+	pseudonym := pseudonymFor(key, entityType, value)
+	for _, entry := range v.entries[subject] {
+		if entry.pseudonym == pseudonym {
+			return pseudonym, nil
+		}
+	}
+
+	ciphertext, err := seal(key, []byte(value))
+	if err != nil {
+		return "", err
+	}
+	v.entries[subject] = append(v.entries[subject], sealedPseudonym{
+		id:         uuid.NewString(),
+		entityType: entityType,
+		pseudonym:  pseudonym,
+		ciphertext: ciphertext,
+		createdAt:  at,
+	})
+	return pseudonym, nil
+}
+
+// List returns the decrypted entries of a subject, oldest first
+func (v *MemoryPseudonymVault) List(ctx context.Context, tenant string, subjectID string) ([]PseudonymEntry, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	subject := consentKey(tenant, subjectID)
+	key, ok := v.keys[subject]
+	if !ok {
+		return nil, nil
+	}
+
+	entries := make([]PseudonymEntry, 0, len(v.entries[subject]))
+	for _, sealed := range v.entries[subject] {
+		value, err := open(key, sealed.ciphertext)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, PseudonymEntry{
+			ID:         sealed.id,
+			Tenant:     tenant,
+			SubjectID:  subjectID,
+			EntityType: sealed.entityType,
+			Pseudonym:  sealed.pseudonym,
+			Value:      string(value),
+			CreatedAt:  sealed.createdAt,
+		})
+	}
+	return entries, nil
+}
+
+// Shred destroys a subject's key and entries and returns the number of entries destroyed
+func (v *MemoryPseudonymVault) Shred(ctx context.Context, tenant string, subjectID string) (int, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	subject := consentKey(tenant, subjectID)
+	if key, ok := v.keys[subject]; ok {
+		for i := range key {
+			key[i] = 0
+		}
+	}
+	shredded := len(v.entries[subject])
+	delete(v.keys, subject)
+	delete(v.entries, subject)
+	return shredded, nil
+}
+
+// newSubjectKey generates a data subject's AES-256 key
+func newSubjectKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate subject key: %w", err)
+	}
+	return key, nil
+}
+
+// pseudonymFor derives the pseudonym of a value from the subject's key, so
+// the same value always maps to the same pseudonym for a subject
+func pseudonymFor(key []byte, entityType string, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(entityType + "|" + value))
+	return strings.ToUpper(entityType) + "_" + hex.EncodeToString(mac.Sum(nil))[:12]
+}
+
+// seal encrypts plaintext with AES-GCM, prefixing the nonce
+func seal(key []byte, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open decrypts a ciphertext produced by seal
+func open(key []byte, ciphertext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("failed to decrypt pseudonym: ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt pseudonym: %w", err)
+	}
+	return plaintext, nil
+}
+
+// newGCM creates an AES-GCM cipher for a key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return gcm, nil
+}
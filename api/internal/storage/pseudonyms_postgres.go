@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PostgresPseudonymVault is a PseudonymVault backed by the pseudonym_keys and
+// pseudonym_vault tables. Subject keys are stored apart from the entries they
+// encrypt, so deleting a key shreds entries copied elsewhere, such as backups.
+type PostgresPseudonymVault struct {
+	db *sql.DB
+}
+
+// NewPostgresPseudonymVault creates a pseudonym vault backed by the
+// pseudonym_keys and pseudonym_vault tables
+func NewPostgresPseudonymVault(db *sql.DB) *PostgresPseudonymVault {
+	return &PostgresPseudonymVault{db: db}
+}
+
+// Put stores a value for a subject and returns its pseudonym. The same
+// value always maps to the same pseudonym for a subject.
+func (v *PostgresPseudonymVault) Put(ctx context.Context, tenant string, subjectID string, entityType string, value string, at time.Time) (string, error) {
+	key, err := newSubjectKey()
+	if err != nil {
+		return "", err
+	}
+
+	// Create the subject's key unless it has one, then read whichever key won
+	_, err = v.db.ExecContext(ctx, `
+		INSERT INTO pseudonym_keys (tenant, subject_id, encryption_key) VALUES ($1, $2, $3)
+		ON CONFLICT (tenant, subject_id) DO NOTHING`,
+		tenant, subjectID, key,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to create subject key: %w", err)
+	}
+	err = v.db.QueryRowContext(ctx, `SELECT encryption_key FROM pseudonym_keys WHERE tenant = $1 AND subject_id = $2`,
+		tenant, subjectID,
+	).Scan(&key)
+	if err != nil {
+		return "", fmt.Errorf("failed to get subject key: %w", err)
+	}
+
+	pseudonym := pseudonymFor(key, entityType, value)
+	ciphertext, err := seal(key, []byte(value))
+	if err != nil {
+		return "", err
+	}
+	_, err = v.db.ExecContext(ctx, `
+		INSERT INTO pseudonym_vault (external_id, tenant, subject_id, entity_type, pseudonym, ciphertext, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (tenant, subject_id, pseudonym) DO NOTHING`,
+		uuid.NewString(), tenant, subjectID, entityType, pseudonym, ciphertext, at,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to store pseudonym: %w", err)
+	}
+	return pseudonym, nil
+}
+
+// List returns the decrypted entries of a subject, oldest first
+func (v *PostgresPseudonymVault) List(ctx context.Context, tenant string, subjectID string) ([]PseudonymEntry, error) {
+	var key []byte
+	err := v.db.QueryRowContext(ctx, `SELECT encryption_key FROM pseudonym_keys WHERE tenant = $1 AND subject_id = $2`,
+		tenant, subjectID,
+	).Scan(&key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subject key: %w", err)
+	}
+
+	rows, err := v.db.QueryContext(ctx, `
+		SELECT external_id, entity_type, pseudonym, ciphertext, created_at FROM pseudonym_vault
+		WHERE tenant = $1 AND subject_id = $2
+		ORDER BY created_at, id`,
+		tenant, subjectID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pseudonyms: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []PseudonymEntry
+	for rows.Next() {
+		entry := PseudonymEntry{Tenant: tenant, SubjectID: subjectID}
+		var ciphertext []byte
+		if err := rows.Scan(&entry.ID, &entry.EntityType, &entry.Pseudonym, &ciphertext, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to read pseudonym: %w", err)
+		}
+		value, err := open(key, ciphertext)
+		if err != nil {
+			return nil, err
+		}
+		entry.Value = string(value)
+		entry.CreatedAt = entry.CreatedAt.UTC()
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query pseudonyms: %w", err)
+	}
+	return entries, nil
+}
+
+// Shred destroys a subject's key and entries and returns the number of entries destroyed
+func (v *PostgresPseudonymVault) Shred(ctx context.Context, tenant string, subjectID string) (int, error) {
+	tx, err := v.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to shred pseudonyms: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM pseudonym_keys WHERE tenant = $1 AND subject_id = $2`, tenant, subjectID); err != nil {
+		return 0, fmt.Errorf("failed to delete subject key: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM pseudonym_vault WHERE tenant = $1 AND subject_id = $2`, tenant, subjectID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete pseudonyms: %w", err)
+	}
+	shredded, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete pseudonyms: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to shred pseudonyms: %w", err)
+	}
+	return int(shredded), nil
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	// Registers the "postgres" driver
+	_ "github.com/lib/pq"
+)
+
+// testDatabase opens the database named by SECURA_TEST_DATABASE_URL, which
+// must be initialized with db/init.sql, or skips the test if none is set
+func testDatabase(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("SECURA_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("SECURA_TEST_DATABASE_URL is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Ping(); err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+	return db
+}
+
+func TestPseudonymVaultShred(t *testing.T) {
+	vaults := []struct {
+		name string
+		open func(t *testing.T) PseudonymVault
+	}{
+		{name: "memory", open: func(*testing.T) PseudonymVault { return NewMemoryPseudonymVault() }},
+		{name: "postgres", open: func(t *testing.T) PseudonymVault { return NewPostgresPseudonymVault(testDatabase(t)) }},
+	}
+	for _, tt := range vaults {
+		t.Run(tt.name, func(t *testing.T) {
+			vault := tt.open(t)
+			ctx := context.Background()
+			now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+			// A tenant of its own keeps runs against a shared database apart
+			tenant := "test-" + uuid.NewString()
+
+			pseudonym, err := vault.Put(ctx, tenant, "subject-1", "PERSON", "John Smith", now)
+			if err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+			again, err := vault.Put(ctx, tenant, "subject-1", "PERSON", "John Smith", now.Add(time.Second))
+			if err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+			if again != pseudonym {
+				t.Errorf("Put() of the same value = %s, want %s", again, pseudonym)
+			}
+			if _, err := vault.Put(ctx, tenant, "subject-1", "EMAIL_ADDRESS", "john@example.com", now.Add(2*time.Second)); err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+			if _, err := vault.Put(ctx, tenant, "subject-2", "PERSON", "Jane Doe", now); err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+
+			entries, err := vault.List(ctx, tenant, "subject-1")
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(entries) != 2 || entries[0].Pseudonym != pseudonym || entries[0].Value != "John Smith" || entries[1].Value != "john@example.com" {
+				t.Fatalf("List() = %+v, want the two values stored, oldest first", entries)
+			}
+
+			shredded, err := vault.Shred(ctx, tenant, "subject-1")
+			if err != nil {
+				t.Fatalf("Shred() error = %v", err)
+			}
+			if shredded != 2 {
+				t.Errorf("Shred() = %d, want 2", shredded)
+			}
+			if entries, err := vault.List(ctx, tenant, "subject-1"); err != nil || len(entries) != 0 {
+				t.Errorf("List() after Shred() = %+v, %v, want no entries", entries, err)
+			}
+
+			// Other subjects keep their entries
+			if entries, err := vault.List(ctx, tenant, "subject-2"); err != nil || len(entries) != 1 {
+				t.Errorf("List() of another subject = %+v, %v, want its entry", entries, err)
+			}
+
+			// The shredded key is gone: the same value gets a new pseudonym
+			renewed, err := vault.Put(ctx, tenant, "subject-1", "PERSON", "John Smith", now)
+			if err != nil {
+				t.Fatalf("Put() after Shred() error = %v", err)
+			}
+			if renewed == pseudonym {
+				t.Error("Put() after Shred() reused the shredded key")
+			}
+		})
+	}
+}
+
+func TestPostgresPseudonymVaultShredsKey(t *testing.T) {
+	db := testDatabase(t)
+	vault := NewPostgresPseudonymVault(db)
+	ctx := context.Background()
+	tenant := "test-" + uuid.NewString()
+
+	if _, err := vault.Put(ctx, tenant, "subject-1", "PERSON", "John Smith", time.Now().UTC()); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	// Keep a copy of the ciphertext, as a backup would
+	var key, ciphertext []byte
+	if err := db.QueryRow(`SELECT encryption_key FROM pseudonym_keys WHERE tenant = $1`, tenant).Scan(&key); err != nil {
+		t.Fatalf("failed to read subject key: %v", err)
+	}
+	if err := db.QueryRow(`SELECT ciphertext FROM pseudonym_vault WHERE tenant = $1`, tenant).Scan(&ciphertext); err != nil {
+		t.Fatalf("failed to read ciphertext: %v", err)
+	}
+	if value, err := open(key, ciphertext); err != nil || string(value) != "John Smith" {
+		t.Fatalf("open() = %q, %v, want the stored value", value, err)
+	}
+
+	if _, err := vault.Shred(ctx, tenant, "subject-1"); err != nil {
+		t.Fatalf("Shred() error = %v", err)
+	}
+
+	var keys int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pseudonym_keys WHERE tenant = $1`, tenant).Scan(&keys); err != nil {
+		t.Fatalf("failed to count subject keys: %v", err)
+	}
+	if keys != 0 {
+		t.Errorf("%d subject keys left after Shred(), want 0", keys)
+	}
+
+	// A key created for the subject later cannot open the copy
+	if _, err := vault.Put(ctx, tenant, "subject-1", "PERSON", "John Smith", time.Now().UTC()); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	var renewed []byte
+	if err := db.QueryRow(`SELECT encryption_key FROM pseudonym_keys WHERE tenant = $1`, tenant).Scan(&renewed); err != nil {
+		t.Fatalf("failed to read subject key: %v", err)
+	}
+	if _, err := open(renewed, ciphertext); err == nil {
+		t.Error("a new subject key opened a shredded entry")
+	}
+}
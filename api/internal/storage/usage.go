@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"sync"
+)
+
+// UsageCounter holds accumulated token and cost usage for a scope and period
+type UsageCounter struct {
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	Cost             float64 `json:"cost_usd"`
+}
+
+// UsageStore persists usage counters keyed by scope (e.g. "user:123")
+// and period (e.g. "day:2024-01-31" or "month:2024-01")
+type UsageStore interface {
+	// Add increments the counter for a scope and period and returns the new totals
+	Add(ctx context.Context, scope string, period string, delta UsageCounter) (UsageCounter, error)
+	// Get returns the counter for a scope and period
+	Get(ctx context.Context, scope string, period string) (UsageCounter, error)
+}
+
+// MemoryUsageStore is an in-memory UsageStore
+type MemoryUsageStore struct {
+	mu       sync.RWMutex
+	counters map[string]UsageCounter
+}
+
+// NewMemoryUsageStore creates a new in-memory usage store
+func NewMemoryUsageStore() *MemoryUsageStore {
+	return &MemoryUsageStore{
+		counters: make(map[string]UsageCounter),
+	}
+}
+
+// Add increments the counter for a scope and period and returns the new totals
+func (s *MemoryUsageStore) Add(ctx context.Context, scope string, period string, delta UsageCounter) (UsageCounter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := scope + "|" + period
+	counter := s.counters[key]
+	counter.Requests += delta.Requests
+	counter.PromptTokens += delta.PromptTokens
+	counter.CompletionTokens += delta.CompletionTokens
+	counter.TotalTokens += delta.TotalTokens
+	counter.Cost += delta.Cost
+	s.counters[key] = counter
+
+	return counter, nil
+}
+
+// Get returns the counter for a scope and period
+func (s *MemoryUsageStore) Get(ctx context.Context, scope string, period string) (UsageCounter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.counters[scope+"|"+period], nil
+}
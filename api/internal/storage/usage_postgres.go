@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// usageColumns are the usage_counters columns scanned by scanUsageCounter
+const usageColumns = `requests, prompt_tokens, completion_tokens, total_tokens, cost`
+
+// PostgresUsageStore is a UsageStore backed by the usage_counters table, so
+// quotas survive restarts and are shared between replicas
+type PostgresUsageStore struct {
+	db *sql.DB
+}
+
+// NewPostgresUsageStore creates a usage store backed by the usage_counters table
+func NewPostgresUsageStore(db *sql.DB) *PostgresUsageStore {
+	return &PostgresUsageStore{db: db}
+}
+
+// Add increments the counter for a scope and period and returns the new totals
+func (s *PostgresUsageStore) Add(ctx context.Context, scope string, period string, delta UsageCounter) (UsageCounter, error) {
+	row := s.db.QueryRowContext(ctx, `
+		INSERT INTO usage_counters (scope, period, `+usageColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (scope, period) DO UPDATE
+		SET requests = usage_counters.requests + EXCLUDED.requests,
+			prompt_tokens = usage_counters.prompt_tokens + EXCLUDED.prompt_tokens,
+			completion_tokens = usage_counters.completion_tokens + EXCLUDED.completion_tokens,
+			total_tokens = usage_counters.total_tokens + EXCLUDED.total_tokens,
+			cost = usage_counters.cost + EXCLUDED.cost,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING `+usageColumns,
+		scope, period, delta.Requests, delta.PromptTokens, delta.CompletionTokens, delta.TotalTokens, delta.Cost,
+	)
+	counter, err := scanUsageCounter(row)
+	if err != nil {
+		return UsageCounter{}, fmt.Errorf("failed to add usage: %w", err)
+	}
+	return counter, nil
+}
+
+// Get returns the counter for a scope and period
+func (s *PostgresUsageStore) Get(ctx context.Context, scope string, period string) (UsageCounter, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+usageColumns+` FROM usage_counters WHERE scope = $1 AND period = $2`, scope, period)
+	counter, err := scanUsageCounter(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return UsageCounter{}, nil
+	}
+	if err != nil {
+		return UsageCounter{}, fmt.Errorf("failed to get usage: %w", err)
+	}
+	return counter, nil
+}
+
+// scanUsageCounter reads a counter selected with usageColumns
+func scanUsageCounter(row rowScanner) (UsageCounter, error) {
+	var counter UsageCounter
+	err := row.Scan(&counter.Requests, &counter.PromptTokens, &counter.CompletionTokens, &counter.TotalTokens, &counter.Cost)
+	if err != nil {
+		return UsageCounter{}, err
+	}
+	return counter, nil
+}
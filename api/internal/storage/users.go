@@ -0,0 +1,214 @@
+package storage
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrUserNotFound is returned when a user does not exist
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrAPIKeyNotFound is returned when an API key does not exist
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+// UserRecord is a gateway user
+type UserRecord struct {
+	ID        string     `json:"id"`
+	Username  string     `json:"username"`
+	Email     string     `json:"email,omitempty"`
+	Role      string     `json:"role"`
+	Tenant    string     `json:"tenant,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ErasedAt  *time.Time `json:"erased_at,omitempty"`
+}
+
+// APIKeyRecord is the metadata of a user's API key. The key itself is only
+// stored as a hash.
+type APIKeyRecord struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Tenant     string     `json:"tenant,omitempty"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	Active     bool       `json:"is_active"`
+}
+
+// UserStore persists users and their API key metadata
+type UserStore interface {
+	// Get returns a user by ID
+	Get(ctx context.Context, id string) (UserRecord, error)
+	// Put creates a user or updates their profile
+	Put(ctx context.Context, user UserRecord) error
+	// AddAPIKey stores the metadata of a new API key and returns it with its ID
+	AddAPIKey(ctx context.Context, key APIKeyRecord) (APIKeyRecord, error)
+	// APIKeys returns the API keys a user holds in a tenant, oldest first
+	APIKeys(ctx context.Context, tenant string, userID string) ([]APIKeyRecord, error)
+	// FindAPIKey returns the API key with the given hash
+	FindAPIKey(ctx context.Context, keyHash string) (APIKeyRecord, error)
+	// TouchAPIKey records that an API key was used
+	TouchAPIKey(ctx context.Context, id string, at time.Time) error
+	// RevokeAPIKey deactivates one of the API keys a user holds in a tenant
+	RevokeAPIKey(ctx context.Context, tenant string, userID string, id string) (APIKeyRecord, error)
+	// Erase removes a user's personal data, deactivates their API keys and
+	// discards the key hashes. It returns the erased user and the number of keys revoked.
+	Erase(ctx context.Context, id string, at time.Time) (UserRecord, int, error)
+}
+
+// MemoryUserStore is an in-memory UserStore. Its users and API keys are lost
+// on restart, so it is only suitable for development.
+type MemoryUserStore struct {
+	mu      sync.RWMutex
+	users   map[string]UserRecord
+	apiKeys map[string][]APIKeyRecord
+}
+
+// NewMemoryUserStore creates a new in-memory user store seeded with users
+func NewMemoryUserStore(users ...UserRecord) *MemoryUserStore {
+	s := &MemoryUserStore{
+		users:   make(map[string]UserRecord),
+		apiKeys: make(map[string][]APIKeyRecord),
+	}
+	for _, user := range users {
+		s.users[user.ID] = user
+	}
+	return s
+}
+
+// Get returns a user by ID
+func (s *MemoryUserStore) Get(ctx context.Context, id string) (UserRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return UserRecord{}, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// Put creates a user or updates their profile
+func (s *MemoryUserStore) Put(ctx context.Context, user UserRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users[user.ID] = user
+	return nil
+}
+
+// AddAPIKey stores the metadata of a new API key and returns it with its ID
+func (s *MemoryUserStore) AddAPIKey(ctx context.Context, key APIKeyRecord) (APIKeyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[key.UserID]; !ok {
+		return APIKeyRecord{}, ErrUserNotFound
+	}
+	if key.ID == "" {
+		key.ID = uuid.NewString()
+	}
+	s.apiKeys[key.UserID] = append(s.apiKeys[key.UserID], key)
+	return key, nil
+}
+
+// APIKeys returns the API keys a user holds in a tenant, oldest first
+func (s *MemoryUserStore) APIKeys(ctx context.Context, tenant string, userID string) ([]APIKeyRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var keys []APIKeyRecord
+	for _, key := range s.apiKeys[userID] {
+		if key.Tenant == tenant {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// FindAPIKey returns the API key with the given hash
+func (s *MemoryUserStore) FindAPIKey(ctx context.Context, keyHash string) (APIKeyRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if keyHash == "" {
+		return APIKeyRecord{}, ErrAPIKeyNotFound
+	}
+	for _, keys := range s.apiKeys {
+		for _, key := range keys {
+			if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(keyHash)) == 1 {
+				return key, nil
+			}
+		}
+	}
+	return APIKeyRecord{}, ErrAPIKeyNotFound
+}
+
+// TouchAPIKey records that an API key was used
+func (s *MemoryUserStore) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, keys := range s.apiKeys {
+		for i := range keys {
+			if keys[i].ID == id {
+				usedAt := at
+				keys[i].LastUsedAt = &usedAt
+				return nil
+			}
+		}
+	}
+	return ErrAPIKeyNotFound
+}
+
+// RevokeAPIKey deactivates one of the API keys a user holds in a tenant
+func (s *MemoryUserStore) RevokeAPIKey(ctx context.Context, tenant string, userID string, id string) (APIKeyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := s.apiKeys[userID]
+	for i := range keys {
+		if keys[i].ID == id && keys[i].Tenant == tenant {
+			keys[i].Active = false
+			return keys[i], nil
+		}
+	}
+	return APIKeyRecord{}, ErrAPIKeyNotFound
+}
+
+// Erase removes a user's personal data, deactivates their API keys and
+// discards the key hashes. It returns the erased user and the number of keys revoked.
+func (s *MemoryUserStore) Erase(ctx context.Context, id string, at time.Time) (UserRecord, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return UserRecord{}, 0, ErrUserNotFound
+	}
+
+	erasedAt := at
+	user.Username = ""
+	user.Email = ""
+	user.ErasedAt = &erasedAt
+	s.users[id] = user
+
+	revoked := 0
+	keys := s.apiKeys[id]
+	for i := range keys {
+		if keys[i].Active {
+			revoked++
+		}
+		keys[i].Active = false
+		keys[i].KeyHash = ""
+		keys[i].Name = ""
+	}
+	return user, revoked, nil
+}
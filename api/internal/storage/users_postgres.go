@@ -0,0 +1,240 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// userColumns are the users columns scanned by scanUser
+const userColumns = `external_id, COALESCE(username, ''), COALESCE(email, ''), role, created_at, erased_at`
+
+// apiKeyColumns are the api_keys columns, joined with users as u, scanned by scanAPIKey
+const apiKeyColumns = `k.id::text, u.external_id, k.tenant, COALESCE(k.name, ''), COALESCE(k.key_prefix, ''),
+	COALESCE(k.key_hash, ''), k.created_at, k.expires_at, k.last_used_at, COALESCE(k.is_active, FALSE)`
+
+// PostgresUserStore is a UserStore backed by the users and api_keys tables.
+// Users are identified by their external ID, the subject of their tokens.
+type PostgresUserStore struct {
+	db *sql.DB
+}
+
+// NewPostgresUserStore creates a user store backed by the users and api_keys tables
+func NewPostgresUserStore(db *sql.DB) *PostgresUserStore {
+	return &PostgresUserStore{db: db}
+}
+
+// Get returns a user by ID
+func (s *PostgresUserStore) Get(ctx context.Context, id string) (UserRecord, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE external_id = $1`, id)
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return UserRecord{}, ErrUserNotFound
+	}
+	if err != nil {
+		return UserRecord{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// Put creates a user or updates their profile. Users created here have no
+// password and can only authenticate with tokens issued for them.
+func (s *PostgresUserStore) Put(ctx context.Context, user UserRecord) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO users (external_id, username, email, password_hash, role, created_at)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), '', $4, $5)
+		ON CONFLICT (external_id) DO UPDATE
+		SET username = EXCLUDED.username, email = EXCLUDED.email, role = EXCLUDED.role, updated_at = CURRENT_TIMESTAMP`,
+		user.ID, user.Username, user.Email, user.Role, user.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store user: %w", err)
+	}
+	return nil
+}
+
+// AddAPIKey stores the metadata of a new API key and returns it with the ID
+// assigned by the database
+func (s *PostgresUserStore) AddAPIKey(ctx context.Context, key APIKeyRecord) (APIKeyRecord, error) {
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO api_keys (user_id, tenant, name, key_prefix, key_hash, created_at, expires_at, is_active)
+		SELECT id, $2, $3, $4, $5, $6, $7, $8 FROM users WHERE external_id = $1
+		RETURNING id::text`,
+		key.UserID, key.Tenant, key.Name, key.Prefix, key.KeyHash, key.CreatedAt, key.ExpiresAt, key.Active,
+	).Scan(&key.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKeyRecord{}, ErrUserNotFound
+	}
+	if err != nil {
+		return APIKeyRecord{}, fmt.Errorf("failed to insert API key: %w", err)
+	}
+	return key, nil
+}
+
+// APIKeys returns the API keys a user holds in a tenant, oldest first
+func (s *PostgresUserStore) APIKeys(ctx context.Context, tenant string, userID string) ([]APIKeyRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+apiKeyColumns+` FROM api_keys k JOIN users u ON u.id = k.user_id
+		WHERE u.external_id = $1 AND k.tenant = $2
+		ORDER BY k.created_at, k.id`,
+		userID, tenant,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []APIKeyRecord
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read API key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// FindAPIKey returns the API key with the given hash
+func (s *PostgresUserStore) FindAPIKey(ctx context.Context, keyHash string) (APIKeyRecord, error) {
+	if keyHash == "" {
+		return APIKeyRecord{}, ErrAPIKeyNotFound
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+apiKeyColumns+` FROM api_keys k JOIN users u ON u.id = k.user_id
+		WHERE k.key_hash = $1`,
+		keyHash,
+	)
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKeyRecord{}, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return APIKeyRecord{}, fmt.Errorf("failed to find API key: %w", err)
+	}
+	return key, nil
+}
+
+// TouchAPIKey records that an API key was used
+func (s *PostgresUserStore) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+	key, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return ErrAPIKeyNotFound
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, key, at); err != nil {
+		return fmt.Errorf("failed to update API key: %w", err)
+	}
+	return nil
+}
+
+// RevokeAPIKey deactivates one of the API keys a user holds in a tenant
+func (s *PostgresUserStore) RevokeAPIKey(ctx context.Context, tenant string, userID string, id string) (APIKeyRecord, error) {
+	keyID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return APIKeyRecord{}, ErrAPIKeyNotFound
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		UPDATE api_keys k SET is_active = FALSE FROM users u
+		WHERE u.id = k.user_id AND k.id = $1 AND k.tenant = $2 AND u.external_id = $3
+		RETURNING `+apiKeyColumns,
+		keyID, tenant, userID,
+	)
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKeyRecord{}, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return APIKeyRecord{}, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	return key, nil
+}
+
+// Erase removes a user's personal data, deactivates their API keys and
+// discards the key hashes. It returns the erased user and the number of keys revoked.
+func (s *PostgresUserStore) Erase(ctx context.Context, id string, at time.Time) (UserRecord, int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return UserRecord{}, 0, fmt.Errorf("failed to erase user: %w", err)
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRowContext(ctx, `
+		UPDATE users SET username = NULL, email = NULL, erased_at = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE external_id = $1
+		RETURNING `+userColumns,
+		id, at,
+	)
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return UserRecord{}, 0, ErrUserNotFound
+	}
+	if err != nil {
+		return UserRecord{}, 0, fmt.Errorf("failed to erase user: %w", err)
+	}
+
+	var revoked int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM api_keys k JOIN users u ON u.id = k.user_id
+		WHERE u.external_id = $1 AND k.is_active`,
+		id,
+	).Scan(&revoked)
+	if err != nil {
+		return UserRecord{}, 0, fmt.Errorf("failed to revoke API keys: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE api_keys k SET is_active = FALSE, key_hash = NULL, name = NULL FROM users u
+		WHERE u.id = k.user_id AND u.external_id = $1`,
+		id,
+	)
+	if err != nil {
+		return UserRecord{}, 0, fmt.Errorf("failed to revoke API keys: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return UserRecord{}, 0, fmt.Errorf("failed to erase user: %w", err)
+	}
+	return user, revoked, nil
+}
+
+// scanUser reads a user selected with userColumns
+func scanUser(row rowScanner) (UserRecord, error) {
+	var user UserRecord
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.CreatedAt, &user.ErasedAt)
+	if err != nil {
+		return UserRecord{}, err
+	}
+	user.CreatedAt = user.CreatedAt.UTC()
+	user.ErasedAt = utcTime(user.ErasedAt)
+	return user, nil
+}
+
+// scanAPIKey reads an API key selected with apiKeyColumns
+func scanAPIKey(row rowScanner) (APIKeyRecord, error) {
+	var key APIKeyRecord
+	err := row.Scan(&key.ID, &key.UserID, &key.Tenant, &key.Name, &key.Prefix,
+		&key.KeyHash, &key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt, &key.Active)
+	if err != nil {
+		return APIKeyRecord{}, err
+	}
+	key.CreatedAt = key.CreatedAt.UTC()
+	key.ExpiresAt = utcTime(key.ExpiresAt)
+	key.LastUsedAt = utcTime(key.LastUsedAt)
+	return key, nil
+}
+
+// utcTime returns an optional time in UTC
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
@@ -0,0 +1,27 @@
+package telemetry
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys used on gateway spans. Spans must never carry prompt or
+// completion content, only counts and identifiers.
+const (
+	AttrLLMProvider         = attribute.Key("llm.provider")
+	AttrLLMModel            = attribute.Key("llm.model")
+	AttrLLMDeployment       = attribute.Key("llm.deployment")
+	AttrLLMPromptTokens     = attribute.Key("llm.usage.prompt_tokens")
+	AttrLLMCompletionTokens = attribute.Key("llm.usage.completion_tokens")
+	AttrLLMTotalTokens      = attribute.Key("llm.usage.total_tokens")
+	AttrAnonymizedEntities  = attribute.Key("anonymization.entities")
+	AttrAuditActionType     = attribute.Key("audit.action_type")
+	AttrAuditTxHash         = attribute.Key("audit.tx_hash")
+)
+
+// RecordError marks a span as failed
+func RecordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
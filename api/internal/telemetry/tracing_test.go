@@ -0,0 +1,239 @@
+package telemetry_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/secura/api/internal/config"
+	"github.com/secura/api/internal/providers"
+	"github.com/secura/api/internal/services"
+	"github.com/secura/api/internal/telemetry"
+)
+
+// prompt is sensitive content that must never reach a span
+const prompt = "My name is John Smith"
+
+// traceparentServer answers every request with the JSON fixture and records
+// the traceparent header it received
+type traceparentServer struct {
+	*httptest.Server
+	mu          sync.Mutex
+	traceparent string
+}
+
+func newTraceparentServer(t *testing.T, fixture string) *traceparentServer {
+	t.Helper()
+	s := &traceparentServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.traceparent = r.Header.Get("traceparent")
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(fixture))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *traceparentServer) received() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.traceparent
+}
+
+// setupTracing installs a tracer provider exporting to an in-memory exporter
+// for the duration of the test
+func setupTracing(t *testing.T) (*tracetest.InMemoryExporter, func()) {
+	t.Helper()
+	if _, err := telemetry.Setup(context.Background(), &config.Config{}); err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := telemetry.NewTracerProvider(exporter, "secura-api-test", 1)
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		provider.Shutdown(context.Background())
+	})
+
+	flush := func() {
+		if err := provider.ForceFlush(context.Background()); err != nil {
+			t.Fatalf("ForceFlush() error = %v", err)
+		}
+	}
+	return exporter, flush
+}
+
+// spanByName returns the exported span with the given name
+func spanByName(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("no %q span among %d exported spans", name, len(spans))
+	return tracetest.SpanStub{}
+}
+
+// attributes returns the attributes of a span by key
+func attributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	values := make(map[attribute.Key]attribute.Value, len(span.Attributes))
+	for _, kv := range span.Attributes {
+		values[kv.Key] = kv.Value
+	}
+	return values
+}
+
+func TestChatRequestSpans(t *testing.T) {
+	exporter, flush := setupTracing(t)
+
+	nlp := newTraceparentServer(t, `{
+		"anonymized_text": "My name is <PERSON>",
+		"entities": [{"type": "PERSON", "text": "John Smith", "start_offset": 11, "end_offset": 21}]
+	}`)
+	upstream := newTraceparentServer(t, `{
+		"id": "chatcmpl-1",
+		"object": "chat.completion",
+		"choices": [{"index": 0, "message": {"role": "assistant", "content": "Hello <PERSON>"}, "finish_reason": "stop"}],
+		"usage": {"prompt_tokens": 12, "completion_tokens": 4, "total_tokens": 16}
+	}`)
+	blockchainService, err := services.NewBlockchainService("http://127.0.0.1:8545", "0x0000000000000000000000000000000000000001", zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewBlockchainService() error = %v", err)
+	}
+
+	// Run the steps of a chat request under a request span, as the tracing
+	// middleware does
+	ctx, root := telemetry.Tracer().Start(context.Background(), "POST /api/v1/llm/chat")
+	anonymized, err := services.NewAnonymizationService(nlp.URL).AnonymizeText(ctx, prompt)
+	if err != nil {
+		t.Fatalf("AnonymizeText() error = %v", err)
+	}
+	deployment := config.DeploymentConfig{Name: "openai-test", Provider: config.ProviderOpenAI, Model: "gpt-4o", BaseURL: upstream.URL, APIKey: "sk-test"}
+	resp, err := providers.NewOpenAI().Send(ctx, deployment, "/chat/completions", map[string]interface{}{
+		"model":    "gpt-4o",
+		"messages": []interface{}{map[string]interface{}{"role": "user", "content": anonymized}},
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if _, err := blockchainService.RecordLLMInteraction(ctx, "user-123", "llm_chat",
+		map[string]interface{}{"prompt": prompt}, resp, map[string]interface{}{"model": "gpt-4o"}); err != nil {
+		t.Fatalf("RecordLLMInteraction() error = %v", err)
+	}
+	root.End()
+	flush()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("exported %d spans, want 4: %v", len(spans), spans.Snapshots())
+	}
+	traceID := root.SpanContext().TraceID()
+
+	anonymization := spanByName(t, spans, "anonymization.AnonymizeText")
+	provider := spanByName(t, spans, "openai completions")
+	audit := spanByName(t, spans, "audit.RecordLLMInteraction")
+
+	for _, span := range []tracetest.SpanStub{anonymization, provider, audit} {
+		if span.SpanContext.TraceID() != traceID || span.Parent.SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("span %q is not a child of the request span", span.Name)
+		}
+		if span.Status.Code == codes.Error {
+			t.Errorf("span %q status = %v, want not an error", span.Name, span.Status)
+		}
+	}
+	if anonymization.SpanKind != trace.SpanKindClient || provider.SpanKind != trace.SpanKindClient {
+		t.Error("anonymization and provider spans are not client spans")
+	}
+
+	// Calls to the NLP service and the provider carry the W3C trace context
+	// of their own span
+	for _, tt := range []struct {
+		server *traceparentServer
+		span   tracetest.SpanStub
+	}{
+		{server: nlp, span: anonymization},
+		{server: upstream, span: provider},
+	} {
+		want := "00-" + traceID.String() + "-" + tt.span.SpanContext.SpanID().String() + "-01"
+		if got := tt.server.received(); got != want {
+			t.Errorf("traceparent sent from %q = %q, want %q", tt.span.Name, got, want)
+		}
+	}
+
+	if got := attributes(anonymization)[telemetry.AttrAnonymizedEntities].AsInt64(); got != 1 {
+		t.Errorf("%s = %d, want 1", telemetry.AttrAnonymizedEntities, got)
+	}
+
+	providerAttrs := attributes(provider)
+	for key, want := range map[attribute.Key]attribute.Value{
+		telemetry.AttrLLMProvider:         attribute.StringValue("openai"),
+		telemetry.AttrLLMModel:            attribute.StringValue("gpt-4o"),
+		telemetry.AttrLLMDeployment:       attribute.StringValue("openai-test"),
+		telemetry.AttrLLMPromptTokens:     attribute.Int64Value(12),
+		telemetry.AttrLLMCompletionTokens: attribute.Int64Value(4),
+		telemetry.AttrLLMTotalTokens:      attribute.Int64Value(16),
+	} {
+		if got := providerAttrs[key]; got != want {
+			t.Errorf("provider span %s = %v, want %v", key, got.Emit(), want.Emit())
+		}
+	}
+
+	auditAttrs := attributes(audit)
+	if got := auditAttrs[telemetry.AttrAuditActionType].AsString(); got != "llm_chat" {
+		t.Errorf("%s = %q, want llm_chat", telemetry.AttrAuditActionType, got)
+	}
+	if got := auditAttrs[telemetry.AttrAuditTxHash].AsString(); !strings.HasPrefix(got, "0x") {
+		t.Errorf("%s = %q, want the audit transaction hash", telemetry.AttrAuditTxHash, got)
+	}
+
+	// Spans carry counts and identifiers, never prompt or completion content
+	data, err := json.Marshal(spans.Snapshots())
+	if err != nil {
+		t.Fatalf("failed to encode spans: %v", err)
+	}
+	for _, content := range []string{"John Smith", "<PERSON>"} {
+		if strings.Contains(string(data), content) {
+			t.Errorf("exported spans contain content %q", content)
+		}
+	}
+}
+
+func TestProviderErrorSpan(t *testing.T) {
+	exporter, flush := setupTracing(t)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": {"message": "Rate limit reached"}}`))
+	}))
+	t.Cleanup(upstream.Close)
+
+	deployment := config.DeploymentConfig{Name: "openai-test", Provider: config.ProviderOpenAI, Model: "gpt-4o", BaseURL: upstream.URL}
+	if _, err := providers.NewOpenAI().Send(context.Background(), deployment, "/chat/completions", map[string]interface{}{}); err == nil {
+		t.Fatal("Send() error = nil, want the upstream error")
+	}
+	flush()
+
+	span := spanByName(t, exporter.GetSpans(), "openai completions")
+	if span.Status.Code != codes.Error {
+		t.Errorf("provider span status = %v, want an error", span.Status)
+	}
+	if got := attributes(span)["http.status_code"].AsInt64(); got != http.StatusTooManyRequests {
+		t.Errorf("http.status_code = %d, want %d", got, http.StatusTooManyRequests)
+	}
+}
@@ -0,0 +1,196 @@
+package tokenizer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+// Encoding names
+const (
+	EncodingCL100K = "cl100k_base"
+	EncodingO200K  = "o200k_base"
+)
+
+// Per-message overhead used by OpenAI chat models, see
+// https://github.com/openai/openai-cookbook/blob/main/examples/How_to_count_tokens_with_tiktoken.ipynb
+const (
+	tokensPerMessage = 3
+	tokensPerName    = 1
+	tokensPerReply   = 3
+)
+
+// o200kPrefixes lists the model families that use the o200k_base encoding.
+// All other models are counted with cl100k_base.
+var o200kPrefixes = []string{"gpt-4o", "gpt-4.1", "gpt-4.5", "o1", "o3", "o4"}
+
+// contextWindows maps model names to their context window in tokens. A dated
+// snapshot of a model, such as "gpt-4-0613" or "gpt-4o-2024-08-06", has the
+// window of the model unless it is listed itself.
+var contextWindows = map[string]int{
+	"gpt-3.5-turbo":          16385,
+	"gpt-3.5-turbo-0301":     4096,
+	"gpt-3.5-turbo-0613":     4096,
+	"gpt-3.5-turbo-16k":      16385,
+	"gpt-3.5-turbo-instruct": 4096,
+	"gpt-4":                  8192,
+	"gpt-4-32k":              32768,
+	"gpt-4-turbo":            128000,
+	"gpt-4-turbo-preview":    128000,
+	"gpt-4-1106-preview":     128000,
+	"gpt-4-0125-preview":     128000,
+	"gpt-4-vision-preview":   128000,
+	"gpt-4o":                 128000,
+	"gpt-4o-mini":            128000,
+	"gpt-4.1":                1047576,
+	"gpt-4.1-mini":           1047576,
+	"gpt-4.1-nano":           1047576,
+	"gpt-4.5":                128000,
+	"gpt-4.5-preview":        128000,
+	"o1":                     200000,
+	"o1-mini":                128000,
+	"o1-preview":             128000,
+	"o3":                     200000,
+	"o3-mini":                200000,
+	"o4-mini":                200000,
+}
+
+// snapshotSuffix matches the date suffix of a model snapshot, such as "-0613"
+// or "-2024-08-06"
+var snapshotSuffix = regexp.MustCompile(`^-(\d{4}|\d{4}-\d{2}-\d{2})$`)
+
+// Message is a chat message for token counting
+type Message struct {
+	Role    string
+	Name    string
+	Content string
+}
+
+var (
+	mu       sync.Mutex
+	encoders = make(map[string]*tiktoken.Tiktoken)
+)
+
+func init() {
+	// Use the BPE ranks embedded in the binary instead of downloading them
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+// EncodingForModel returns the name of the encoding used by a model
+func EncodingForModel(model string) string {
+	for _, prefix := range o200kPrefixes {
+		if strings.HasPrefix(model, prefix) {
+			return EncodingO200K
+		}
+	}
+	return EncodingCL100K
+}
+
+// ContextWindow returns the context window of a model in tokens.
+// The second return value is false if the model is unknown.
+func ContextWindow(model string) (int, bool) {
+	if window, ok := contextWindows[model]; ok {
+		return window, true
+	}
+
+	// Only a date suffix is stripped, so a model of another family that
+	// shares a prefix, such as "gpt-4.5" for "gpt-4", stays unknown
+	for name, window := range contextWindows {
+		if strings.HasPrefix(model, name) && snapshotSuffix.MatchString(model[len(name):]) {
+			return window, true
+		}
+	}
+	return 0, false
+}
+
+// CountTokens returns the number of tokens in text for a model
+func CountTokens(model string, text string) (int, error) {
+	enc, err := encoder(EncodingForModel(model))
+	if err != nil {
+		return 0, err
+	}
+	return len(enc.EncodeOrdinary(text)), nil
+}
+
+// CountChatTokens returns the number of prompt tokens a list of chat messages
+// consumes, including the per-message formatting overhead
+func CountChatTokens(model string, messages []Message) (int, error) {
+	enc, err := encoder(EncodingForModel(model))
+	if err != nil {
+		return 0, err
+	}
+
+	total := tokensPerReply
+	for _, msg := range messages {
+		total += tokensPerMessage
+		total += len(enc.EncodeOrdinary(msg.Role))
+		total += len(enc.EncodeOrdinary(msg.Content))
+		if msg.Name != "" {
+			total += tokensPerName + len(enc.EncodeOrdinary(msg.Name))
+		}
+	}
+	return total, nil
+}
+
+// ContextLimitError is returned when a request does not fit a model's context window
+type ContextLimitError struct {
+	Model         string
+	PromptTokens  int
+	MaxTokens     int
+	ContextWindow int
+}
+
+// Error implements the error interface
+func (e *ContextLimitError) Error() string {
+	if e.MaxTokens > 0 {
+		return fmt.Sprintf("prompt is %d tokens and max_tokens is %d, which exceeds the %d token context window of model %s",
+			e.PromptTokens, e.MaxTokens, e.ContextWindow, e.Model)
+	}
+	return fmt.Sprintf("prompt is %d tokens, which exceeds the %d token context window of model %s",
+		e.PromptTokens, e.ContextWindow, e.Model)
+}
+
+// CheckContextWindow returns a *ContextLimitError if the prompt plus the requested
+// completion tokens exceed the model's context window. Unknown models are not checked.
+func CheckContextWindow(model string, promptTokens int, maxTokens int) error {
+	window, ok := ContextWindow(model)
+	if !ok {
+		return nil
+	}
+	return CheckContextLimit(model, promptTokens, maxTokens, window)
+}
+
+// CheckContextLimit returns a *ContextLimitError if the prompt plus the requested
+// completion tokens exceed the given context window
+func CheckContextLimit(model string, promptTokens int, maxTokens int, window int) error {
+	if promptTokens+maxTokens > window {
+		return &ContextLimitError{
+			Model:         model,
+			PromptTokens:  promptTokens,
+			MaxTokens:     maxTokens,
+			ContextWindow: window,
+		}
+	}
+	return nil
+}
+
+// encoder returns a cached encoder for an encoding
+func encoder(encoding string) (*tiktoken.Tiktoken, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if enc, ok := encoders[encoding]; ok {
+		return enc, nil
+	}
+
+	enc, err := tiktoken.GetEncoding(encoding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s encoding: %w", encoding, err)
+	}
+	encoders[encoding] = enc
+	return enc, nil
+}
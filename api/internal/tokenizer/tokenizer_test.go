@@ -0,0 +1,176 @@
+package tokenizer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEncodingForModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{model: "gpt-4o", want: EncodingO200K},
+		{model: "gpt-4o-2024-08-06", want: EncodingO200K},
+		{model: "gpt-4o-mini", want: EncodingO200K},
+		{model: "gpt-4.1-nano", want: EncodingO200K},
+		{model: "o1-preview", want: EncodingO200K},
+		{model: "o3-mini", want: EncodingO200K},
+		{model: "gpt-4", want: EncodingCL100K},
+		{model: "gpt-4-0613", want: EncodingCL100K},
+		{model: "gpt-4-turbo", want: EncodingCL100K},
+		{model: "gpt-3.5-turbo", want: EncodingCL100K},
+		{model: "text-embedding-3-small", want: EncodingCL100K},
+		{model: "llama3", want: EncodingCL100K},
+	}
+	for _, tt := range tests {
+		if got := EncodingForModel(tt.model); got != tt.want {
+			t.Errorf("EncodingForModel(%q) = %q, want %q", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	tests := []struct {
+		model  string
+		want   int
+		wantOK bool
+	}{
+		{model: "gpt-4", want: 8192, wantOK: true},
+		{model: "gpt-4-0613", want: 8192, wantOK: true},
+		{model: "gpt-4o", want: 128000, wantOK: true},
+		{model: "gpt-4o-2024-08-06", want: 128000, wantOK: true},
+		{model: "gpt-4o-mini-2024-07-18", want: 128000, wantOK: true},
+		{model: "gpt-4-turbo-2024-04-09", want: 128000, wantOK: true},
+		{model: "o1-2024-12-17", want: 200000, wantOK: true},
+		// Listed snapshots keep their own window
+		{model: "gpt-3.5-turbo-0613", want: 4096, wantOK: true},
+		{model: "gpt-3.5-turbo-0125", want: 16385, wantOK: true},
+		// Only date suffixes are stripped
+		{model: "gpt-4-custom", wantOK: false},
+		{model: "gpt-4o-audio-preview", wantOK: false},
+		{model: "gpt-4-06131", wantOK: false},
+		{model: "llama3", wantOK: false},
+	}
+	for _, tt := range tests {
+		got, ok := ContextWindow(tt.model)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ContextWindow(%q) = %d, %v, want %d, %v", tt.model, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestCountTokens(t *testing.T) {
+	tests := []struct {
+		model string
+		text  string
+		want  int
+	}{
+		{model: "gpt-4", text: "", want: 0},
+		{model: "gpt-4", text: "hello world", want: 2},
+		{model: "gpt-4o", text: "hello world", want: 2},
+		// Special tokens in caller text are counted as ordinary text
+		{model: "gpt-4", text: "<|endoftext|>", want: 7},
+	}
+	for _, tt := range tests {
+		got, err := CountTokens(tt.model, tt.text)
+		if err != nil {
+			t.Fatalf("CountTokens(%q, %q) error = %v", tt.model, tt.text, err)
+		}
+		if got != tt.want {
+			t.Errorf("CountTokens(%q, %q) = %d, want %d", tt.model, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestCountChatTokens(t *testing.T) {
+	messages := []Message{
+		{Role: "system", Content: "You are a helpful assistant."},
+		{Role: "user", Name: "alice", Content: "What is the capital of France?"},
+		{Role: "assistant", Content: "Paris."},
+	}
+
+	for _, model := range []string{"gpt-4", "gpt-4o-2024-08-06"} {
+		got, err := CountChatTokens(model, messages)
+		if err != nil {
+			t.Fatalf("CountChatTokens(%q) error = %v", model, err)
+		}
+
+		// Each message costs 3 tokens of formatting plus its role and content,
+		// a name costs 1 more plus the name, and the reply is primed with 3
+		want := tokensPerReply
+		for _, msg := range messages {
+			want += tokensPerMessage + mustCount(t, model, msg.Role) + mustCount(t, model, msg.Content)
+			if msg.Name != "" {
+				want += tokensPerName + mustCount(t, model, msg.Name)
+			}
+		}
+		if got != want {
+			t.Errorf("CountChatTokens(%q) = %d, want %d", model, got, want)
+		}
+	}
+
+	// An empty conversation still primes the reply
+	if got, err := CountChatTokens("gpt-4", nil); err != nil || got != tokensPerReply {
+		t.Errorf("CountChatTokens() of no messages = %d, %v, want %d", got, err, tokensPerReply)
+	}
+	// "hello world" is 2 tokens and "user" 1, plus 3 for the message and 3 for the reply
+	if got, err := CountChatTokens("gpt-4", []Message{{Role: "user", Content: "hello world"}}); err != nil || got != 9 {
+		t.Errorf("CountChatTokens() of one message = %d, %v, want 9", got, err)
+	}
+}
+
+func mustCount(t *testing.T, model string, text string) int {
+	t.Helper()
+	count, err := CountTokens(model, text)
+	if err != nil {
+		t.Fatalf("CountTokens(%q, %q) error = %v", model, text, err)
+	}
+	return count
+}
+
+func TestCheckContextLimit(t *testing.T) {
+	tests := []struct {
+		name         string
+		promptTokens int
+		maxTokens    int
+		wantErr      bool
+	}{
+		{name: "fits", promptTokens: 1000, maxTokens: 1000},
+		{name: "fills the window", promptTokens: 4000, maxTokens: 96},
+		{name: "prompt alone fills the window", promptTokens: 4096},
+		{name: "completion overruns", promptTokens: 4000, maxTokens: 97, wantErr: true},
+		{name: "prompt overruns", promptTokens: 4097, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckContextLimit("custom-model", tt.promptTokens, tt.maxTokens, 4096)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("CheckContextLimit() error = %v", err)
+				}
+				return
+			}
+
+			var limitErr *ContextLimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("CheckContextLimit() error = %v, want a *ContextLimitError", err)
+			}
+			if limitErr.Model != "custom-model" || limitErr.PromptTokens != tt.promptTokens || limitErr.MaxTokens != tt.maxTokens || limitErr.ContextWindow != 4096 {
+				t.Errorf("CheckContextLimit() error = %+v", limitErr)
+			}
+		})
+	}
+}
+
+func TestCheckContextWindow(t *testing.T) {
+	if err := CheckContextWindow("gpt-4-0613", 8000, 192); err != nil {
+		t.Errorf("CheckContextWindow() of a request filling gpt-4 error = %v", err)
+	}
+	if err := CheckContextWindow("gpt-4-0613", 8000, 193); err == nil {
+		t.Error("CheckContextWindow() of a request overrunning gpt-4 error = nil")
+	}
+	if err := CheckContextWindow("llama3", 1000000, 1000000); err != nil {
+		t.Errorf("CheckContextWindow() of an unknown model error = %v, want no check", err)
+	}
+}
@@ -0,0 +1,10 @@
+package version
+
+// Build information, injected at build time with
+//
+//	go build -ldflags "-X github.com/secura/api/internal/version.Version=1.2.3 -X github.com/secura/api/internal/version.Commit=$(git rev-parse --short HEAD)"
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
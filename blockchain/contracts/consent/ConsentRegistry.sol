@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+/**
+ * @title ConsentRegistry
+ * @dev Smart contract for tamper-evident attestation of data subject consent.
+ * Subjects are identified by a hash so no personal data is stored on-chain.
+ */
+contract ConsentRegistry {
+    // Struct to represent a consent window for a subject and purpose
+    struct ConsentWindow {
+        string consentId;   // Off-chain consent reference
+        uint256 grantedAt;  // Timestamp when consent was granted
+        uint256 expiresAt;  // Timestamp when consent expires (0 = no expiry)
+        uint256 revokedAt;  // Timestamp when consent was revoked (0 = not revoked)
+    }
+
+    // Owner allowed to manage attesters
+    address public owner;
+
+    // Addresses allowed to attest consent
+    mapping(address => bool) public attesters;
+
+    // Mapping from consent key (subject hash and purpose) to its consent history
+    mapping(bytes32 => ConsentWindow[]) private history;
+
+    // Events
+    event ConsentGranted(bytes32 indexed subjectHash, string purpose, string consentId, uint256 expiresAt, uint256 timestamp);
+    event ConsentRevoked(bytes32 indexed subjectHash, string purpose, string consentId, uint256 timestamp);
+    event AttesterUpdated(address indexed attester, bool allowed);
+
+    modifier onlyOwner() {
+        require(msg.sender == owner, "Caller is not the owner");
+        _;
+    }
+
+    modifier onlyAttester() {
+        require(attesters[msg.sender], "Caller is not an attester");
+        _;
+    }
+
+    constructor() {
+        owner = msg.sender;
+        attesters[msg.sender] = true;
+        emit AttesterUpdated(msg.sender, true);
+    }
+
+    /**
+     * @dev Allow or disallow an address to attest consent
+     * @param attester The address to update
+     * @param allowed Whether the address may attest consent
+     */
+    function setAttester(address attester, bool allowed) public onlyOwner {
+        attesters[attester] = allowed;
+        emit AttesterUpdated(attester, allowed);
+    }
+
+    /**
+     * @dev Attest that a subject granted consent for a purpose. An active
+     * earlier grant for the same purpose is superseded.
+     * @param subjectHash Hash of the tenant-scoped subject identifier
+     * @param purpose Processing purpose consented to
+     * @param consentId Off-chain consent reference
+     * @param expiresAt Timestamp when consent expires (0 = no expiry)
+     */
+    function grantConsent(
+        bytes32 subjectHash,
+        string memory purpose,
+        string memory consentId,
+        uint256 expiresAt
+    ) public onlyAttester {
+        require(expiresAt == 0 || expiresAt > block.timestamp, "Expiry must be in the future");
+
+        ConsentWindow[] storage windows = history[consentKey(subjectHash, purpose)];
+        if (windows.length > 0 && windows[windows.length - 1].revokedAt == 0) {
+            windows[windows.length - 1].revokedAt = block.timestamp;
+        }
+
+        windows.push(ConsentWindow({
+            consentId: consentId,
+            grantedAt: block.timestamp,
+            expiresAt: expiresAt,
+            revokedAt: 0
+        }));
+
+        emit ConsentGranted(subjectHash, purpose, consentId, expiresAt, block.timestamp);
+    }
+
+    /**
+     * @dev Attest that a subject revoked consent for a purpose
+     * @param subjectHash Hash of the tenant-scoped subject identifier
+     * @param purpose Processing purpose to revoke consent for
+     */
+    function revokeConsent(bytes32 subjectHash, string memory purpose) public onlyAttester {
+        ConsentWindow[] storage windows = history[consentKey(subjectHash, purpose)];
+        require(windows.length > 0 && windows[windows.length - 1].revokedAt == 0, "No active consent");
+
+        ConsentWindow storage window = windows[windows.length - 1];
+        window.revokedAt = block.timestamp;
+
+        emit ConsentRevoked(subjectHash, purpose, window.consentId, block.timestamp);
+    }
+
+    /**
+     * @dev Get the latest consent for a subject and purpose
+     * @param subjectHash Hash of the tenant-scoped subject identifier
+     * @param purpose Processing purpose
+     * @return valid Whether the consent is currently valid
+     * @return consentId Off-chain consent reference
+     * @return grantedAt Timestamp when consent was granted
+     * @return expiresAt Timestamp when consent expires (0 = no expiry)
+     * @return revokedAt Timestamp when consent was revoked (0 = not revoked)
+     */
+    function getConsent(bytes32 subjectHash, string memory purpose) public view returns (
+        bool valid,
+        string memory consentId,
+        uint256 grantedAt,
+        uint256 expiresAt,
+        uint256 revokedAt
+    ) {
+        ConsentWindow[] storage windows = history[consentKey(subjectHash, purpose)];
+        if (windows.length == 0) {
+            return (false, "", 0, 0, 0);
+        }
+
+        ConsentWindow storage window = windows[windows.length - 1];
+        return (
+            isValidAt(window, block.timestamp),
+            window.consentId,
+            window.grantedAt,
+            window.expiresAt,
+            window.revokedAt
+        );
+    }
+
+    /**
+     * @dev Check whether a subject had valid consent for a purpose at a point in time
+     * @param subjectHash Hash of the tenant-scoped subject identifier
+     * @param purpose Processing purpose
+     * @param timestamp The point in time to check
+     * @return valid Whether consent was valid at the timestamp
+     * @return consentId Off-chain reference of the consent that was valid
+     */
+    function wasConsentValidAt(bytes32 subjectHash, string memory purpose, uint256 timestamp) public view returns (
+        bool valid,
+        string memory consentId
+    ) {
+        ConsentWindow[] storage windows = history[consentKey(subjectHash, purpose)];
+        for (uint256 i = windows.length; i > 0; i--) {
+            if (isValidAt(windows[i - 1], timestamp)) {
+                return (true, windows[i - 1].consentId);
+            }
+        }
+        return (false, "");
+    }
+
+    /**
+     * @dev Get the number of consent grants for a subject and purpose
+     * @param subjectHash Hash of the tenant-scoped subject identifier
+     * @param purpose Processing purpose
+     * @return The number of grants recorded
+     */
+    function getConsentCount(bytes32 subjectHash, string memory purpose) public view returns (uint256) {
+        return history[consentKey(subjectHash, purpose)].length;
+    }
+
+    function consentKey(bytes32 subjectHash, string memory purpose) private pure returns (bytes32) {
+        return keccak256(abi.encode(subjectHash, purpose));
+    }
+
+    function isValidAt(ConsentWindow storage window, uint256 timestamp) private view returns (bool) {
+        return timestamp >= window.grantedAt &&
+            (window.revokedAt == 0 || timestamp < window.revokedAt) &&
+            (window.expiresAt == 0 || timestamp < window.expiresAt);
+    }
+}
@@ -0,0 +1,6 @@
+const ConsentRegistry = artifacts.require("ConsentRegistry");
+
+module.exports = function (deployer) {
+  // Deploy the ConsentRegistry contract
+  deployer.deploy(ConsentRegistry);
+};
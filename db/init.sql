@@ -43,6 +43,91 @@ CREATE TABLE IF NOT EXISTS api_keys (
 -- Create index on user_id
 CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
 
+-- Scope audit logs and API keys to tenants. Tenants are configured in the
+-- gateway config; an empty tenant is the single tenant of a deployment without tenants.
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS tenant VARCHAR(64) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_audit_logs_tenant_user_timestamp ON audit_logs(tenant, user_id, timestamp);
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant VARCHAR(64) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant_user_id ON api_keys(tenant, user_id);
+
+-- Create consents table for data subject consent per purpose
+CREATE TABLE IF NOT EXISTS consents (
+    id SERIAL PRIMARY KEY,
+    external_id VARCHAR(64) UNIQUE NOT NULL, -- Consent reference recorded in audit metadata
+    tenant VARCHAR(64) NOT NULL DEFAULT '',
+    subject_id VARCHAR(255) NOT NULL, -- Data subject identifier
+    purpose VARCHAR(255) NOT NULL, -- e.g. 'clinical summarization'
+    granted_by VARCHAR(64) NOT NULL,
+    granted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    withdrawn_at TIMESTAMP WITH TIME ZONE,
+    withdrawn_by VARCHAR(64),
+    attestation_tx VARCHAR(66), -- ConsentRegistry grant transaction hash
+    revocation_tx VARCHAR(66) -- ConsentRegistry revocation transaction hash
+);
+
+CREATE INDEX IF NOT EXISTS idx_consents_subject_purpose ON consents(tenant, subject_id, purpose);
+
+-- Support data subject access and erasure requests
+ALTER TABLE users ALTER COLUMN username DROP NOT NULL;
+ALTER TABLE users ALTER COLUMN email DROP NOT NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS erased_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS subject_id VARCHAR(255); -- Data subject, replaced by a random tombstone on erasure
+CREATE INDEX IF NOT EXISTS idx_audit_logs_tenant_subject ON audit_logs(tenant, subject_id);
+
+-- Create pseudonym vault with per-subject keys for crypto-shredding
+CREATE TABLE IF NOT EXISTS pseudonym_keys (
+    tenant VARCHAR(64) NOT NULL DEFAULT '',
+    subject_id VARCHAR(255) NOT NULL,
+    encryption_key BYTEA NOT NULL, -- Per-subject key; deleting it crypto-shreds the subject's entries
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tenant, subject_id)
+);
+
+CREATE TABLE IF NOT EXISTS pseudonym_vault (
+    id SERIAL PRIMARY KEY,
+    external_id VARCHAR(64) UNIQUE NOT NULL,
+    tenant VARCHAR(64) NOT NULL DEFAULT '',
+    subject_id VARCHAR(255) NOT NULL,
+    entity_type VARCHAR(64) NOT NULL, -- e.g. 'PERSON', 'EMAIL_ADDRESS'
+    pseudonym VARCHAR(128) NOT NULL,
+    ciphertext BYTEA NOT NULL, -- AES-256-GCM under the subject's key
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tenant, subject_id, pseudonym)
+);
+
+-- Exempt audit records under legal hold from retention purges
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS legal_hold BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS legal_hold_reason TEXT;
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action_timestamp ON audit_logs(action_type, timestamp) WHERE NOT legal_hold;
+
+-- Show API keys by prefix
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS key_prefix VARCHAR(32);
+
+-- Index audit entries by token subject, including entries not recorded on-chain
+ALTER TABLE audit_logs DROP CONSTRAINT IF EXISTS audit_logs_user_id_fkey;
+ALTER TABLE audit_logs ALTER COLUMN user_id TYPE VARCHAR(64) USING user_id::text;
+ALTER TABLE audit_logs ALTER COLUMN blockchain_tx DROP NOT NULL;
+
+-- Discard the hashes and names of erased users' API keys
+ALTER TABLE api_keys ALTER COLUMN key_hash DROP NOT NULL;
+ALTER TABLE api_keys ALTER COLUMN name DROP NOT NULL;
+
+-- Persist usage counters for quotas
+CREATE TABLE IF NOT EXISTS usage_counters (
+    scope VARCHAR(255) NOT NULL, -- e.g. 'user:user-123', 'tenant:acme/team:research'
+    period VARCHAR(32) NOT NULL, -- e.g. 'day:2024-01-31', 'month:2024-01'
+    requests BIGINT NOT NULL DEFAULT 0,
+    prompt_tokens BIGINT NOT NULL DEFAULT 0,
+    completion_tokens BIGINT NOT NULL DEFAULT 0,
+    total_tokens BIGINT NOT NULL DEFAULT 0,
+    cost DOUBLE PRECISION NOT NULL DEFAULT 0, -- USD
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (scope, period)
+);
+
 -- Insert a default admin user (password: admin123)
 INSERT INTO users (external_id, username, email, password_hash, role)
 VALUES ('user-123', 'admin', 'admin@example.com', '$2a$10$zL.MmDQXIaQNgVLTj6Shs.Xs.R2f1QZn2qWbGa.EOOE3NwR9F5G8.', 'admin')
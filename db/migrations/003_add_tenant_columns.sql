@@ -0,0 +1,16 @@
+-- Migration: 003_add_tenant_columns
+
+-- Up migration
+-- Tenants are configured in the gateway config and referenced by their tenant
+-- claim; an empty tenant is the single tenant of a deployment without tenants
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS tenant VARCHAR(64) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_audit_logs_tenant_user_timestamp ON audit_logs(tenant, user_id, timestamp);
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant VARCHAR(64) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant_user_id ON api_keys(tenant, user_id);
+
+-- Down migration
+DROP INDEX IF EXISTS idx_api_keys_tenant_user_id;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS tenant;
+DROP INDEX IF EXISTS idx_audit_logs_tenant_user_timestamp;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS tenant;
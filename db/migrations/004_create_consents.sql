@@ -0,0 +1,21 @@
+-- Migration: 004_create_consents
+
+-- Up migration
+CREATE TABLE IF NOT EXISTS consents (
+    id SERIAL PRIMARY KEY,
+    external_id VARCHAR(64) UNIQUE NOT NULL, -- Consent reference recorded in audit metadata
+    tenant VARCHAR(64) NOT NULL DEFAULT '',
+    subject_id VARCHAR(255) NOT NULL, -- Data subject identifier
+    purpose VARCHAR(255) NOT NULL, -- e.g. 'clinical summarization'
+    granted_by VARCHAR(64) NOT NULL,
+    granted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    withdrawn_at TIMESTAMP WITH TIME ZONE,
+    withdrawn_by VARCHAR(64)
+);
+
+CREATE INDEX IF NOT EXISTS idx_consents_subject_purpose ON consents(tenant, subject_id, purpose);
+
+-- Down migration
+DROP INDEX IF EXISTS idx_consents_subject_purpose;
+DROP TABLE IF EXISTS consents;
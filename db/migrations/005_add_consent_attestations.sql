@@ -0,0 +1,9 @@
+-- Migration: 005_add_consent_attestations
+
+-- Up migration
+ALTER TABLE consents ADD COLUMN IF NOT EXISTS attestation_tx VARCHAR(66); -- ConsentRegistry grant transaction hash
+ALTER TABLE consents ADD COLUMN IF NOT EXISTS revocation_tx VARCHAR(66); -- ConsentRegistry revocation transaction hash
+
+-- Down migration
+ALTER TABLE consents DROP COLUMN IF EXISTS revocation_tx;
+ALTER TABLE consents DROP COLUMN IF EXISTS attestation_tx;
@@ -0,0 +1,36 @@
+-- Migration: 006_create_dsar
+
+-- Up migration
+ALTER TABLE users ALTER COLUMN username DROP NOT NULL; -- Cleared on erasure
+ALTER TABLE users ALTER COLUMN email DROP NOT NULL; -- Cleared on erasure
+ALTER TABLE users ADD COLUMN IF NOT EXISTS erased_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS subject_id VARCHAR(255); -- Data subject, replaced by its on-chain hash on erasure
+CREATE INDEX IF NOT EXISTS idx_audit_logs_tenant_subject ON audit_logs(tenant, subject_id);
+
+CREATE TABLE IF NOT EXISTS pseudonym_keys (
+    tenant VARCHAR(64) NOT NULL DEFAULT '',
+    subject_id VARCHAR(255) NOT NULL,
+    encryption_key BYTEA NOT NULL, -- Per-subject key; deleting it crypto-shreds the subject's entries
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tenant, subject_id)
+);
+
+CREATE TABLE IF NOT EXISTS pseudonym_vault (
+    id SERIAL PRIMARY KEY,
+    external_id VARCHAR(64) UNIQUE NOT NULL,
+    tenant VARCHAR(64) NOT NULL DEFAULT '',
+    subject_id VARCHAR(255) NOT NULL,
+    entity_type VARCHAR(64) NOT NULL, -- e.g. 'PERSON', 'EMAIL_ADDRESS'
+    pseudonym VARCHAR(128) NOT NULL,
+    ciphertext BYTEA NOT NULL, -- AES-256-GCM under the subject's key
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tenant, subject_id, pseudonym)
+);
+
+-- Down migration
+DROP TABLE IF EXISTS pseudonym_vault;
+DROP TABLE IF EXISTS pseudonym_keys;
+DROP INDEX IF EXISTS idx_audit_logs_tenant_subject;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS subject_id;
+ALTER TABLE users DROP COLUMN IF EXISTS erased_at;
@@ -0,0 +1,11 @@
+-- Migration: 007_add_audit_legal_holds
+
+-- Up migration
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS legal_hold BOOLEAN NOT NULL DEFAULT FALSE; -- Exempts the record from retention purges
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS legal_hold_reason TEXT;
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action_timestamp ON audit_logs(action_type, timestamp) WHERE NOT legal_hold;
+
+-- Down migration
+DROP INDEX IF EXISTS idx_audit_logs_action_timestamp;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS legal_hold_reason;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS legal_hold;
@@ -0,0 +1,7 @@
+-- Migration: 008_add_api_key_prefix
+
+-- Up migration
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS key_prefix VARCHAR(32); -- Leading characters of the key, for display
+
+-- Down migration
+ALTER TABLE api_keys DROP COLUMN IF EXISTS key_prefix;
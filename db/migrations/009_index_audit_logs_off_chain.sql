@@ -0,0 +1,16 @@
+-- Migration: 009_index_audit_logs_off_chain
+
+-- Up migration
+-- Audit entries name users by their token subject, which need not be a row in
+-- users, and are indexed even when the interaction was not recorded on-chain
+ALTER TABLE audit_logs DROP CONSTRAINT IF EXISTS audit_logs_user_id_fkey;
+ALTER TABLE audit_logs ALTER COLUMN user_id TYPE VARCHAR(64) USING user_id::text;
+UPDATE audit_logs SET user_id = users.external_id FROM users WHERE audit_logs.user_id = users.id::text;
+ALTER TABLE audit_logs ALTER COLUMN blockchain_tx DROP NOT NULL;
+
+-- Down migration
+UPDATE audit_logs SET blockchain_tx = '' WHERE blockchain_tx IS NULL;
+ALTER TABLE audit_logs ALTER COLUMN blockchain_tx SET NOT NULL;
+UPDATE audit_logs SET user_id = users.id::text FROM users WHERE audit_logs.user_id = users.external_id;
+ALTER TABLE audit_logs ALTER COLUMN user_id TYPE INTEGER USING CASE WHEN user_id ~ '^[0-9]+$' THEN user_id::integer END;
+ALTER TABLE audit_logs ADD CONSTRAINT audit_logs_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
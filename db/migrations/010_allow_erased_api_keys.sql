@@ -0,0 +1,10 @@
+-- Migration: 010_allow_erased_api_keys
+
+-- Up migration
+ALTER TABLE api_keys ALTER COLUMN key_hash DROP NOT NULL; -- Discarded when the key's user is erased
+ALTER TABLE api_keys ALTER COLUMN name DROP NOT NULL; -- Discarded when the key's user is erased
+
+-- Down migration
+DELETE FROM api_keys WHERE key_hash IS NULL;
+ALTER TABLE api_keys ALTER COLUMN name SET NOT NULL;
+ALTER TABLE api_keys ALTER COLUMN key_hash SET NOT NULL;
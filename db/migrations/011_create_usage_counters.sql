@@ -0,0 +1,17 @@
+-- Migration: 011_create_usage_counters
+
+-- Up migration
+CREATE TABLE IF NOT EXISTS usage_counters (
+    scope VARCHAR(255) NOT NULL, -- e.g. 'user:user-123', 'tenant:acme/team:research'
+    period VARCHAR(32) NOT NULL, -- e.g. 'day:2024-01-31', 'month:2024-01'
+    requests BIGINT NOT NULL DEFAULT 0,
+    prompt_tokens BIGINT NOT NULL DEFAULT 0,
+    completion_tokens BIGINT NOT NULL DEFAULT 0,
+    total_tokens BIGINT NOT NULL DEFAULT 0,
+    cost DOUBLE PRECISION NOT NULL DEFAULT 0, -- USD
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (scope, period)
+);
+
+-- Down migration
+DROP TABLE IF EXISTS usage_counters;